@@ -3,10 +3,11 @@ module ai-thunderdome
 go 1.24.5
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/go-chi/chi/v5 v5.2.2
 	github.com/jackc/pgx/v5 v5.7.5
 	github.com/joho/godotenv v1.5.1
-	github.com/paulhankin/poker v1.0.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
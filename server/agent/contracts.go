@@ -17,9 +17,15 @@ type Observation struct {
 	Pot        int            `json:"pot"`
 	ToCall     int            `json:"to_call"`
 	MinRaiseTo int            `json:"min_raise_to"`  // absolute raise-to
-	MaxRaiseTo int            `json:"max_raise_to"`  // absolute raise-to (all-in)
+	MaxRaiseTo int            `json:"max_raise_to"`  // absolute raise-to (all-in, the pot under pot limit, one bet under fixed limit)
 	Legal      []string       `json:"legal_actions"` // subset of fold/check/call/raise
 	HistoryLen int            `json:"history_len"`
+
+	// Game names the variant when it is not hold'em, e.g. "plo".
+	Game string `json:"game,omitempty"`
+	// Betting names the structure when it is not no limit, e.g.
+	// "fixed_limit". Fixed-limit raises are always to MinRaiseTo.
+	Betting string `json:"betting,omitempty"`
 }
 
 type ActionOut struct {
@@ -27,6 +33,9 @@ type ActionOut struct {
 	Amount  *int               `json:"amount,omitempty"` // required if raise
 	Policy  map[string]float64 `json:"policy,omitempty"`
 	Comment string             `json:"comment,omitempty"` // <=120 chars
+	// Reasoning is the provider's reasoning summary when one was returned;
+	// it is captured by the harness, not produced in the model's JSON.
+	Reasoning string `json:"reasoning,omitempty"`
 }
 
 // BuildObservation converts engine state into the JSON we send the model.
@@ -47,8 +56,19 @@ func BuildObservation(h *engine.Hand, seat engine.Seat) Observation {
 		legal = append(legal, string(k))
 	}
 
+	game := ""
+	if v := h.Cfg.Variant; v != "" && v != engine.Holdem {
+		game = string(v)
+	}
+	betting := ""
+	if b := h.Cfg.Limit(); b != engine.NoLimit {
+		betting = string(b)
+	}
+
 	return Observation{
 		HandID:     h.ID,
+		Game:       game,
+		Betting:    betting,
 		Seat:       string(seat),
 		Street:     h.Street,
 		HoleCards:  cardsToStr(p.Hole),
@@ -58,7 +78,7 @@ func BuildObservation(h *engine.Hand, seat engine.Seat) Observation {
 		Pot:        h.Pot,
 		ToCall:     toCall,
 		MinRaiseTo: h.CurBet + h.MinRaise,
-		MaxRaiseTo: p.Stack + p.Committed,
+		MaxRaiseTo: h.MaxRaiseTo(seat),
 		Legal:      legal,
 		HistoryLen: len(h.History),
 	}
@@ -87,7 +107,8 @@ func Validate(o Observation, a ActionOut) error {
 	}
 
 	// size checks if raise
-	if a.Action == "raise" {
+	// Fixed-limit raises have one size, so any amount is ignored.
+	if a.Action == "raise" && o.Betting != string(engine.FixedLimit) {
 		if a.Amount == nil {
 			return fmt.Errorf("raise requires amount")
 		}
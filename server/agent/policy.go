@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"ai-thunderdome/server/engine"
+)
+
+// Raise size buckets offered in mixed-strategy mode. Fractions are of the
+// pot after calling; min and all-in map to the legal bounds. Pot-limit games
+// offer no all-in bucket, since the pot raise is already the largest, and
+// fixed-limit games offer only RaiseFixed, the one legal size.
+const (
+	RaiseMin     = "raise_min"
+	RaiseHalfPot = "raise_half_pot"
+	RaisePot     = "raise_pot"
+	RaiseAllIn   = "raise_all_in"
+	RaiseFixed   = "raise"
+)
+
+var raiseBuckets = []string{RaiseMin, RaiseHalfPot, RaisePot, RaiseAllIn}
+
+// PolicyKeys lists the keys a model may weight for this observation, in a
+// fixed order so sampling is reproducible for a given RNG state.
+func PolicyKeys(o Observation) []string {
+	var keys []string
+	for _, k := range []string{"fold", "check", "call"} {
+		if hasLegal(o, k) {
+			keys = append(keys, k)
+		}
+	}
+	if hasLegal(o, "raise") {
+		switch engine.Betting(o.Betting) {
+		case engine.FixedLimit:
+			keys = append(keys, RaiseFixed)
+		case engine.PotLimit:
+			keys = append(keys, raiseBuckets[:len(raiseBuckets)-1]...)
+		default:
+			keys = append(keys, raiseBuckets...)
+		}
+	}
+	return keys
+}
+
+// BucketRaiseTo converts a raise bucket into an absolute raise-to amount
+// clamped to [MinRaiseTo, MaxRaiseTo]. curBet is the bet the hero faces.
+func BucketRaiseTo(o Observation, curBet int, bucket string) (int, bool) {
+	potAfterCall := o.Pot + o.ToCall
+	var to int
+	switch bucket {
+	case RaiseMin, RaiseFixed:
+		to = o.MinRaiseTo
+	case RaiseHalfPot:
+		to = curBet + potAfterCall/2
+	case RaisePot:
+		to = curBet + potAfterCall
+	case RaiseAllIn:
+		to = o.MaxRaiseTo
+	default:
+		return 0, false
+	}
+	if to < o.MinRaiseTo {
+		to = o.MinRaiseTo
+	}
+	if to > o.MaxRaiseTo {
+		to = o.MaxRaiseTo
+	}
+	return to, true
+}
+
+// SamplePolicy draws one action from a model-provided distribution. Unknown
+// keys and negative weights are ignored; the rest are renormalized. It returns
+// the sampled action, its raise-to amount (raise only) and the bucket key.
+func SamplePolicy(o Observation, curBet int, policy map[string]float64, r *rand.Rand) (ActionOut, string, error) {
+	keys := PolicyKeys(o)
+	total := 0.0
+	for _, k := range keys {
+		if p := policy[k]; p > 0 {
+			total += p
+		}
+	}
+	if total <= 0 {
+		return ActionOut{}, "", errors.New("policy has no weight on legal actions")
+	}
+	x := r.Float64() * total
+	pick := ""
+	for _, k := range keys {
+		p := policy[k]
+		if p <= 0 {
+			continue
+		}
+		pick = k
+		if x < p {
+			break
+		}
+		x -= p
+	}
+
+	norm := make(map[string]float64, len(keys))
+	for _, k := range keys {
+		if p := policy[k]; p > 0 {
+			norm[k] = p / total
+		}
+	}
+	out := ActionOut{Action: pick, Policy: norm}
+	if to, ok := BucketRaiseTo(o, curBet, pick); ok {
+		out.Action = "raise"
+		out.Amount = &to
+	}
+	if err := Validate(o, out); err != nil {
+		return ActionOut{}, pick, fmt.Errorf("sampled %s: %w", pick, err)
+	}
+	return out, pick, nil
+}
+
+func hasLegal(o Observation, act string) bool {
+	for _, la := range o.Legal {
+		if la == act {
+			return true
+		}
+	}
+	return false
+}
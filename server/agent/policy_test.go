@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"ai-thunderdome/server/engine"
+)
+
+func TestSamplePolicyReproducible(t *testing.T) {
+	o := Observation{
+		Pot: 300, ToCall: 100, MinRaiseTo: 400, MaxRaiseTo: 10000,
+		Legal: []string{"fold", "call", "raise"},
+	}
+	policy := map[string]float64{"fold": 0.2, "call": 0.5, RaisePot: 0.3, "bogus": 5}
+	a := rand.New(rand.NewSource(42))
+	b := rand.New(rand.NewSource(42))
+	for i := 0; i < 50; i++ {
+		x, kx, err := SamplePolicy(o, 200, policy, a)
+		if err != nil {
+			t.Fatalf("SamplePolicy returned error: %v", err)
+		}
+		y, ky, _ := SamplePolicy(o, 200, policy, b)
+		if kx != ky || x.Action != y.Action {
+			t.Fatalf("draw %d differs: %s vs %s", i, kx, ky)
+		}
+		if kx == RaisePot && (x.Amount == nil || *x.Amount != 600) {
+			t.Fatalf("raise_pot should raise to 600, got %v", x.Amount)
+		}
+	}
+	if got := len(PolicyKeys(o)); got != 6 {
+		t.Fatalf("expected 6 policy keys, got %d", got)
+	}
+}
+
+// samplePolicyFreqs draws n actions and returns how often each bucket came up.
+func samplePolicyFreqs(t *testing.T, o Observation, curBet int, policy map[string]float64, n int) map[string]float64 {
+	t.Helper()
+	r := rand.New(rand.NewSource(7))
+	freq := map[string]float64{}
+	for i := 0; i < n; i++ {
+		_, k, err := SamplePolicy(o, curBet, policy, r)
+		if err != nil {
+			t.Fatalf("draw %d: %v", i, err)
+		}
+		freq[k] += 1 / float64(n)
+	}
+	return freq
+}
+
+func TestSamplePolicyFrequencies(t *testing.T) {
+	o := Observation{
+		Pot: 300, ToCall: 100, MinRaiseTo: 400, MaxRaiseTo: 10000,
+		Legal: []string{"fold", "call", "raise"},
+	}
+	policy := map[string]float64{"fold": 0.2, "call": 0.5, RaiseHalfPot: 0.1, RaisePot: 0.2}
+	const n = 20000
+	freq := samplePolicyFreqs(t, o, 200, policy, n)
+	for k, p := range policy {
+		// four standard errors of a binomial proportion
+		if tol := 4 * math.Sqrt(p*(1-p)/n); math.Abs(freq[k]-p) > tol {
+			t.Errorf("%s drawn %.4f of the time, want %.2f ± %.4f", k, freq[k], p, tol)
+		}
+	}
+	if len(freq) != len(policy) {
+		t.Errorf("drew keys outside the policy: %v", freq)
+	}
+}
+
+func TestSamplePolicyRenormalizes(t *testing.T) {
+	// Facing no bet: fold and call are not legal, so their weight (and the
+	// unknown and negative entries) drops out and check/raise_min split 1:3.
+	o := Observation{Pot: 200, MinRaiseTo: 100, MaxRaiseTo: 5000, Legal: []string{"check", "raise"}}
+	policy := map[string]float64{"fold": 3, "call": 2, "check": 0.25, RaiseMin: 0.75, "bogus": 1, RaisePot: -1}
+	out, _, err := SamplePolicy(o, 0, policy, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"check": 0.25, RaiseMin: 0.75}
+	if len(out.Policy) != len(want) || out.Policy["check"] != want["check"] || out.Policy[RaiseMin] != want[RaiseMin] {
+		t.Fatalf("normalized policy = %v, want %v", out.Policy, want)
+	}
+	const n = 20000
+	freq := samplePolicyFreqs(t, o, 0, policy, n)
+	for k, p := range want {
+		if tol := 4 * math.Sqrt(p*(1-p)/n); math.Abs(freq[k]-p) > tol {
+			t.Errorf("%s drawn %.4f of the time, want %.2f ± %.4f", k, freq[k], p, tol)
+		}
+	}
+	if freq["fold"] != 0 || freq["call"] != 0 || freq[RaisePot] != 0 {
+		t.Errorf("sampled an illegal or negative-weight key: %v", freq)
+	}
+}
+
+func TestSamplePolicyRejectsEmpty(t *testing.T) {
+	o := Observation{Legal: []string{"check", "raise"}, MinRaiseTo: 100, MaxRaiseTo: 500}
+	if _, _, err := SamplePolicy(o, 0, map[string]float64{"fold": 1}, rand.New(rand.NewSource(1))); err == nil {
+		t.Fatalf("expected error when no legal key has weight")
+	}
+}
+
+func TestPotLimitObservation(t *testing.T) {
+	cfg := engine.Config{SB: 50, BB: 100, StartStack: 10000, Variant: engine.PLO}
+	h := engine.NewHand("t", cfg, engine.NewDeck(1))
+	o := BuildObservation(h, engine.SB)
+	// SB calls 50 into a 150 pot, then raises 200: to 300.
+	if o.Game != "plo" || len(o.HoleCards) != 4 || o.MaxRaiseTo != 300 {
+		t.Fatalf("observation = %+v", o)
+	}
+	for _, k := range PolicyKeys(o) {
+		if k == RaiseAllIn {
+			t.Fatalf("pot-limit keys include %s", k)
+		}
+	}
+	if err := h.Apply(engine.Raise, 301); err == nil {
+		t.Fatalf("raise over the pot accepted")
+	}
+	if err := h.Apply(engine.Raise, 300); err != nil {
+		t.Fatal(err)
+	}
+	// BB faces 200 more into a 400 pot: 300 + 400 + 200.
+	if got := BuildObservation(h, engine.BB).MaxRaiseTo; got != 900 {
+		t.Fatalf("BB max raise to %d, want 900", got)
+	}
+}
+
+func TestFixedLimitObservation(t *testing.T) {
+	cfg := engine.Config{SB: 50, BB: 100, StartStack: 10000, Betting: engine.FixedLimit}
+	h := engine.NewHand("t", cfg, engine.NewDeck(1))
+	o := BuildObservation(h, engine.SB)
+	if o.Betting != "fixed_limit" || o.MinRaiseTo != 200 || o.MaxRaiseTo != 200 {
+		t.Fatalf("observation = %+v", o)
+	}
+	keys := PolicyKeys(o)
+	if len(keys) != 3 || keys[2] != RaiseFixed {
+		t.Fatalf("fixed-limit keys = %v", keys)
+	}
+	if err := Validate(o, ActionOut{Action: "raise"}); err != nil {
+		t.Fatalf("fixed-limit raise without amount: %v", err)
+	}
+	if to, ok := BucketRaiseTo(o, h.CurBet, RaiseFixed); !ok || to != 200 {
+		t.Fatalf("raise bucket to %d, %v", to, ok)
+	}
+}
@@ -0,0 +1,132 @@
+// Package aivat takes the luck out of heads-up hand results, after AIVAT
+// (Burch et al., "AIVAT: A New Variance Reduction Technique for Agent
+// Evaluation in Imperfect Information Games", 2018).
+//
+// A baseline value V(state) — the small blind's showdown equity times the
+// pot, minus what it has put in — is tracked through the hand. Every chance
+// event (the deal, each street) adds V after it minus its expectation over
+// all outcomes, and every action drawn from a known mixed policy adds V
+// after the action minus its expectation under the policy. Each term has
+// zero mean, so the hand result minus their sum is an unbiased estimate of
+// the result with most of the card and sampling luck removed.
+package aivat
+
+import (
+	"math/rand"
+
+	"ai-thunderdome/server/engine"
+)
+
+// PreflopSamples is the number of random boards behind preflop equity; the
+// sampling noise is unbiased and so does not bias the estimate.
+const PreflopSamples = 2000
+
+// Choice is one action open to the player to act: folding, or putting Chips
+// more into the pot (0 for a check). Prob is its policy weight.
+type Choice struct {
+	Prob  float64
+	Fold  bool
+	Chips int
+}
+
+// Hand tracks one hand from the small blind's point of view; the big
+// blind's estimate is the negation.
+type Hand struct {
+	variant        engine.Variant
+	sbHole, bbHole []engine.Card
+	board          []engine.Card
+	sb, bb         int         // chips each seat has put in this hand
+	folded         engine.Seat // "" while both are in
+	rng            *rand.Rand
+
+	eq      float64
+	eqBoard int // board length eq was computed for; -1 when stale
+
+	correction float64
+}
+
+// NewHand starts a hand of variant v after the blinds are posted and the
+// hole cards dealt. rng drives preflop equity sampling.
+func NewHand(v engine.Variant, sbHole, bbHole []engine.Card, sbBlind, bbBlind int, rng *rand.Rand) *Hand {
+	h := &Hand{
+		variant: v,
+		sbHole:  append([]engine.Card(nil), sbHole...),
+		bbHole:  append([]engine.Card(nil), bbHole...),
+		sb:      sbBlind, bb: bbBlind,
+		rng:     rng,
+		eqBoard: -1,
+	}
+	// Over all deals the small blind's equity averages ½ by symmetry.
+	h.correction = h.value(h.sb, h.bb, "") - (float64(sbBlind+bbBlind)/2 - float64(sbBlind))
+	return h
+}
+
+// Deal records a chance event: board is the whole board after it. Equity is
+// a martingale, so V before the cards is V's expectation over them.
+func (h *Hand) Deal(board []engine.Card) {
+	before := h.value(h.sb, h.bb, h.folded)
+	h.board = append(h.board[:0], board...)
+	h.eqBoard = -1
+	h.correction += h.value(h.sb, h.bb, h.folded) - before
+}
+
+// Act records seat taking chosen. policy is the distribution chosen was
+// sampled from, or nil when the action is not known to be random (a
+// deterministic reply, a fallback or an override); only then is no
+// correction made.
+func (h *Hand) Act(seat engine.Seat, chosen Choice, policy []Choice) {
+	total, exp := 0.0, 0.0
+	for _, c := range policy {
+		if c.Prob > 0 {
+			total += c.Prob
+			exp += c.Prob * h.after(seat, c)
+		}
+	}
+	if total > 0 {
+		h.correction += h.after(seat, chosen) - exp/total
+	}
+	h.sb, h.bb, h.folded = h.step(seat, chosen)
+}
+
+// Estimate is the small blind's variance-reduced result given its actual
+// net chips for the hand.
+func (h *Hand) Estimate(netSB int) float64 { return float64(netSB) - h.correction }
+
+// after is V once seat takes c.
+func (h *Hand) after(seat engine.Seat, c Choice) float64 {
+	sb, bb, folded := h.step(seat, c)
+	return h.value(sb, bb, folded)
+}
+
+func (h *Hand) step(seat engine.Seat, c Choice) (sb, bb int, folded engine.Seat) {
+	sb, bb, folded = h.sb, h.bb, h.folded
+	switch {
+	case c.Fold:
+		folded = seat
+	case seat == engine.SB:
+		sb += c.Chips
+	default:
+		bb += c.Chips
+	}
+	return sb, bb, folded
+}
+
+// value is V for the small blind: exact after a fold, equity share of the
+// pot otherwise.
+func (h *Hand) value(sb, bb int, folded engine.Seat) float64 {
+	switch folded {
+	case engine.SB:
+		return -float64(sb)
+	case engine.BB:
+		return float64(bb)
+	}
+	return h.equity()*float64(sb+bb) - float64(sb)
+}
+
+func (h *Hand) equity() float64 {
+	if h.eqBoard != len(h.board) {
+		h.eq = h.variant.Equity(h.sbHole, h.bbHole, h.board, PreflopSamples, h.rng)
+		h.eqBoard = len(h.board)
+	}
+	return h.eq
+}
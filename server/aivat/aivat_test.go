@@ -0,0 +1,85 @@
+package aivat
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"ai-thunderdome/server/engine"
+)
+
+// checkDown plays a limped hand to showdown and returns the small blind's
+// raw net and AIVAT estimate.
+func checkDown(seed int64) (raw int, est float64) {
+	cfg := engine.Config{SB: 50, BB: 100, StartStack: 10000}
+	h := engine.NewHand("t", cfg, engine.NewDeck(seed))
+	av := NewHand(cfg.Variant, h.SB.Hole, h.BB.Hole, cfg.SB, cfg.BB, rand.New(rand.NewSource(seed)))
+	_ = h.Apply(engine.Call, 0)
+	av.Act(engine.SB, Choice{Chips: 50}, nil)
+	for i := 0; i < 3; i++ {
+		h.NextStreet()
+		av.Deal(h.Board)
+	}
+	switch h.Showdown() {
+	case engine.SB:
+		raw = 100
+	case engine.BB:
+		raw = -100
+	}
+	return raw, av.Estimate(raw)
+}
+
+func TestChanceCorrectionCutsVariance(t *testing.T) {
+	const n = 200
+	var sumRaw, sumEst, sqRaw, sqEst float64
+	for i := 1; i <= n; i++ {
+		raw, est := checkDown(int64(i))
+		sumRaw += float64(raw)
+		sumEst += est
+		sqRaw += float64(raw * raw)
+		sqEst += est * est
+	}
+	varRaw := sqRaw/n - (sumRaw/n)*(sumRaw/n)
+	varEst := sqEst/n - (sumEst/n)*(sumEst/n)
+	if varEst > varRaw/10 {
+		t.Fatalf("variance raw=%.0f aivat=%.0f; want at least a 10x cut", varRaw, varEst)
+	}
+	// Both seats play the same way, so the expectation is zero.
+	if se := math.Sqrt(varEst / n); math.Abs(sumEst/n) > 4*se+1 {
+		t.Fatalf("aivat mean %.2f ± %.2f, want ≈0", sumEst/n, se)
+	}
+}
+
+func TestPolicyCorrectionHasZeroMean(t *testing.T) {
+	sbHole := []engine.Card{{Rank: 14, Suit: 's'}, {Rank: 13, Suit: 's'}}
+	bbHole := []engine.Card{{Rank: 9, Suit: 'h'}, {Rank: 9, Suit: 'd'}}
+	board := []engine.Card{{Rank: 2, Suit: 's'}, {Rank: 9, Suit: 's'}, {Rank: 12, Suit: 'c'}}
+	policy := []Choice{{Prob: 0.2, Fold: true}, {Prob: 0.5, Chips: 200}, {Prob: 0.3, Chips: 800}}
+
+	mean := 0.0
+	for _, c := range policy {
+		h := NewHand(engine.Holdem, sbHole, bbHole, 50, 100, rand.New(rand.NewSource(1)))
+		h.Act(engine.SB, Choice{Chips: 150}, nil)
+		h.Deal(board)
+		h.Act(engine.BB, Choice{Chips: 200}, nil)
+		before := h.correction
+		h.Act(engine.SB, c, policy)
+		mean += c.Prob * (h.correction - before)
+	}
+	if math.Abs(mean) > 1e-9 {
+		t.Fatalf("expected policy correction %.12f, want 0", mean)
+	}
+}
+
+func TestFoldKeepsOnlyDealLuck(t *testing.T) {
+	sbHole := []engine.Card{{Rank: 7, Suit: 'c'}, {Rank: 2, Suit: 'd'}}
+	bbHole := []engine.Card{{Rank: 14, Suit: 'h'}, {Rank: 14, Suit: 'd'}}
+	h := NewHand(engine.Holdem, sbHole, bbHole, 50, 100, rand.New(rand.NewSource(1)))
+	h.Act(engine.SB, Choice{Fold: true}, []Choice{{Prob: 1, Fold: true}})
+	// A certain fold carries no policy luck, so only the deal is corrected:
+	// -50 − (150·eq − 50 − 25).
+	eq := engine.HeadsUpEquity(sbHole, bbHole, nil, PreflopSamples, rand.New(rand.NewSource(1)))
+	if got, want := h.Estimate(-50), 25-150*eq; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("estimate %.3f, want %.3f", got, want)
+	}
+}
@@ -0,0 +1,250 @@
+// Package bt fits Bradley–Terry ratings to pairwise results. The fit is a
+// batch maximum a posteriori estimate over all results at once, so unlike
+// the incremental Elo and Glicko-2 it does not depend on match order.
+package bt
+
+import (
+	"errors"
+	"math"
+)
+
+// Tally is the record between participants I and J (indices into the
+// caller's list). Ties count half a win to each side.
+type Tally struct {
+	I, J         int
+	WinsI, WinsJ float64
+	Ties         float64
+}
+
+// Options tune the fit. PriorSD is the standard deviation of a zero-mean
+// Gaussian prior on every strength (logit units); it keeps strengths finite
+// for unbeaten or disconnected participants and anchors the scale. Zero
+// means DefaultPriorSD.
+type Options struct {
+	PriorSD float64
+	MaxIter int
+	Tol     float64
+}
+
+// DefaultPriorSD is weak: two logits is roughly 350 Elo points.
+const DefaultPriorSD = 2.0
+
+// Result holds the fitted strengths (logit scale, P(i beats j) =
+// 1/(1+exp(θj−θi))) and their posterior covariance. Strengths are centred
+// on the field mean, and SE and Cov describe them relative to that mean:
+// only differences are identified by pairwise results.
+type Result struct {
+	Theta      []float64
+	SE         []float64
+	Cov        [][]float64
+	Iterations int
+}
+
+// EloScale converts logit strengths to Elo points (400/ln 10).
+const EloScale = 400 / math.Ln10
+
+// WinProb is the fitted probability that i beats j.
+func (r Result) WinProb(i, j int) float64 { return logistic(r.Theta[i] - r.Theta[j]) }
+
+// DiffSE is the standard error of θi − θj.
+func (r Result) DiffSE(i, j int) float64 {
+	v := r.Cov[i][i] + r.Cov[j][j] - 2*r.Cov[i][j]
+	return math.Sqrt(math.Max(v, 0))
+}
+
+// Fit estimates n strengths from the tallies by Newton's method on the log
+// posterior, which is strictly concave with the prior in place.
+func Fit(n int, tallies []Tally, opt Options) (Result, error) {
+	if n <= 0 {
+		return Result{}, errors.New("bt: no participants")
+	}
+	if opt.PriorSD <= 0 {
+		opt.PriorSD = DefaultPriorSD
+	}
+	if opt.MaxIter <= 0 {
+		opt.MaxIter = 100
+	}
+	if opt.Tol <= 0 {
+		opt.Tol = 1e-9
+	}
+	for _, t := range tallies {
+		if t.I < 0 || t.J < 0 || t.I >= n || t.J >= n || t.I == t.J {
+			return Result{}, errors.New("bt: tally references an invalid participant")
+		}
+		if t.WinsI < 0 || t.WinsJ < 0 || t.Ties < 0 {
+			return Result{}, errors.New("bt: negative count")
+		}
+	}
+	prec := 1 / (opt.PriorSD * opt.PriorSD)
+
+	theta := make([]float64, n)
+	grad := make([]float64, n)
+	info := newMatrix(n) // negative Hessian of the log posterior
+	iter := 0
+	for iter < opt.MaxIter {
+		iter++
+		fill(theta, grad, info, tallies, prec)
+		step, err := solve(info, grad)
+		if err != nil {
+			return Result{}, err
+		}
+		maxStep := 0.0
+		for i := range theta {
+			theta[i] += step[i]
+			maxStep = math.Max(maxStep, math.Abs(step[i]))
+		}
+		if maxStep < opt.Tol {
+			break
+		}
+	}
+	fill(theta, grad, info, tallies, prec)
+	cov, err := invert(info)
+	if err != nil {
+		return Result{}, err
+	}
+	center(theta, cov)
+	se := make([]float64, n)
+	for i := range se {
+		se[i] = math.Sqrt(cov[i][i])
+	}
+	return Result{Theta: theta, SE: se, Cov: cov, Iterations: iter}, nil
+}
+
+// fill computes the gradient and the negative Hessian at theta.
+func fill(theta, grad []float64, info [][]float64, tallies []Tally, prec float64) {
+	for i := range theta {
+		grad[i] = -prec * theta[i]
+		for j := range info[i] {
+			info[i][j] = 0
+		}
+		info[i][i] = prec
+	}
+	for _, t := range tallies {
+		games := t.WinsI + t.WinsJ + t.Ties
+		if games == 0 {
+			continue
+		}
+		p := logistic(theta[t.I] - theta[t.J])
+		r := t.WinsI + t.Ties/2 - games*p
+		grad[t.I] += r
+		grad[t.J] -= r
+		w := games * p * (1 - p)
+		info[t.I][t.I] += w
+		info[t.J][t.J] += w
+		info[t.I][t.J] -= w
+		info[t.J][t.I] -= w
+	}
+}
+
+// center shifts theta to mean zero and maps cov through the same
+// projection, C' = P·C·P with P = I − 11ᵀ/n.
+func center(theta []float64, cov [][]float64) {
+	n := len(theta)
+	mean := 0.0
+	for _, t := range theta {
+		mean += t
+	}
+	mean /= float64(n)
+	for i := range theta {
+		theta[i] -= mean
+	}
+	rowMean := make([]float64, n)
+	total := 0.0
+	for i := range cov {
+		for j := range cov[i] {
+			rowMean[i] += cov[i][j]
+		}
+		total += rowMean[i]
+		rowMean[i] /= float64(n)
+	}
+	total /= float64(n * n)
+	for i := range cov {
+		for j := range cov[i] {
+			cov[i][j] += total - rowMean[i] - rowMean[j] // cov is symmetric
+		}
+	}
+}
+
+func logistic(x float64) float64 { return 1 / (1 + math.Exp(-x)) }
+
+func newMatrix(n int) [][]float64 {
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n)
+	}
+	return m
+}
+
+// cholesky factors a symmetric positive-definite matrix as L·Lᵀ.
+func cholesky(a [][]float64) ([][]float64, error) {
+	n := len(a)
+	l := newMatrix(n)
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			s := a[i][j]
+			for k := 0; k < j; k++ {
+				s -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if s <= 0 {
+					return nil, errors.New("bt: information matrix is not positive definite")
+				}
+				l[i][i] = math.Sqrt(s)
+			} else {
+				l[i][j] = s / l[j][j]
+			}
+		}
+	}
+	return l, nil
+}
+
+// solveChol solves L·Lᵀ·x = b.
+func solveChol(l [][]float64, b []float64) []float64 {
+	n := len(l)
+	y := make([]float64, n)
+	for i := 0; i < n; i++ {
+		s := b[i]
+		for k := 0; k < i; k++ {
+			s -= l[i][k] * y[k]
+		}
+		y[i] = s / l[i][i]
+	}
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		s := y[i]
+		for k := i + 1; k < n; k++ {
+			s -= l[k][i] * x[k]
+		}
+		x[i] = s / l[i][i]
+	}
+	return x
+}
+
+func solve(a [][]float64, b []float64) ([]float64, error) {
+	l, err := cholesky(a)
+	if err != nil {
+		return nil, err
+	}
+	return solveChol(l, b), nil
+}
+
+func invert(a [][]float64) ([][]float64, error) {
+	l, err := cholesky(a)
+	if err != nil {
+		return nil, err
+	}
+	n := len(a)
+	inv := newMatrix(n)
+	e := make([]float64, n)
+	for j := 0; j < n; j++ {
+		for i := range e {
+			e[i] = 0
+		}
+		e[j] = 1
+		col := solveChol(l, e)
+		for i := 0; i < n; i++ {
+			inv[i][j] = col[i]
+		}
+	}
+	return inv, nil
+}
@@ -0,0 +1,70 @@
+package bt
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFitRecoversStrengthOrder(t *testing.T) {
+	// 0 beats 1 70% of the time, 1 beats 2 70%, 0 beats 2 84%.
+	tallies := []Tally{
+		{I: 0, J: 1, WinsI: 700, WinsJ: 300},
+		{I: 1, J: 2, WinsI: 700, WinsJ: 300},
+		{I: 0, J: 2, WinsI: 840, WinsJ: 160},
+	}
+	r, err := Fit(3, tallies, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(r.Theta[0] > r.Theta[1] && r.Theta[1] > r.Theta[2]) {
+		t.Fatalf("order wrong: %v", r.Theta)
+	}
+	if p := r.WinProb(0, 1); math.Abs(p-0.7) > 0.03 {
+		t.Fatalf("P(0>1)=%.3f, want ≈0.70", p)
+	}
+	if p := r.WinProb(0, 1) + r.WinProb(1, 0); math.Abs(p-1) > 1e-12 {
+		t.Fatalf("win probabilities do not sum to 1: %v", p)
+	}
+	for i, se := range r.SE {
+		if se <= 0 || se > 0.2 {
+			t.Fatalf("SE[%d]=%v out of range for 1000-game tallies", i, se)
+		}
+	}
+}
+
+func TestFitIsOrderIndependent(t *testing.T) {
+	a := []Tally{{I: 0, J: 1, WinsI: 3, WinsJ: 1, Ties: 2}, {I: 1, J: 2, WinsI: 5, WinsJ: 2}}
+	b := []Tally{{I: 2, J: 1, WinsI: 2, WinsJ: 5}, {I: 1, J: 0, WinsI: 1, WinsJ: 3, Ties: 2}}
+	ra, err := Fit(3, a, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rb, err := Fit(3, b, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range ra.Theta {
+		if math.Abs(ra.Theta[i]-rb.Theta[i]) > 1e-9 || math.Abs(ra.SE[i]-rb.SE[i]) > 1e-9 {
+			t.Fatalf("fit depends on tally order: %v/%v vs %v/%v", ra.Theta, ra.SE, rb.Theta, rb.SE)
+		}
+	}
+}
+
+func TestFitUnbeatenStaysFinite(t *testing.T) {
+	r, err := Fit(2, []Tally{{I: 0, J: 1, WinsI: 10}}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.IsInf(r.Theta[0], 0) || math.IsNaN(r.Theta[0]) || r.Theta[0] <= r.Theta[1] {
+		t.Fatalf("unbeaten fit: %v", r.Theta)
+	}
+	// A participant with no games sits at the prior mean, far less certain
+	// than the two who played.
+	r, err = Fit(3, []Tally{{I: 0, J: 1, WinsI: 10, WinsJ: 10}}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Theta[2]) > 1e-9 || r.SE[2] < 1.5*r.SE[0] {
+		t.Fatalf("idle participant: θ=%v SE=%v", r.Theta, r.SE)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-thunderdome/server/config"
+	"ai-thunderdome/server/store"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// duelCheckpoint is runDuel's state after its last completed mirrored pair,
+// saved to match_checkpoints after every pair. Resuming restores it and
+// continues the same seed stream and deck key, so the remaining pairs are
+// dealt exactly as they would have been without the interruption.
+type duelCheckpoint struct {
+	MatchID   int64  `json:"match_id"`
+	Spec      string `json:"spec"` // resolved run spec (YAML), players A and B
+	SeedBase  uint64 `json:"seed_base"`
+	SeedState uint64 `json:"seed_state"` // stream position after Pairs pairs
+	DeckKey   string `json:"deck_key"`   // hex; see engine.DeckKey
+	Pairs     int    `json:"pairs"`
+
+	BotA  int64 `json:"bot_a"`
+	BotB  int64 `json:"bot_b"`
+	BankA int   `json:"bank_a"`
+	BankB int   `json:"bank_b"`
+	WinsA int   `json:"wins_a"`
+	WinsB int   `json:"wins_b"`
+
+	Elo     Elo                     `json:"elo"`
+	GA      Glicko2                 `json:"glicko_a"`
+	GB      Glicko2                 `json:"glicko_b"`
+	StatsA  ModelStats              `json:"stats_a"`
+	StatsB  ModelStats              `json:"stats_b"`
+	Tallies map[string]*ActionTally `json:"tallies"`
+
+	PairWinsA int       `json:"pair_wins_a"`
+	PairTies  int       `json:"pair_ties"`
+	Margins   []float64 `json:"margins"`
+	Aivat     []float64 `json:"aivat"`
+	SeqWinsA  int       `json:"seq_wins_a"`
+	SeqWinsB  int       `json:"seq_wins_b"`
+	SeqTies   int       `json:"seq_ties"`
+}
+
+// duelSpec is runSpec pinned to a duel between a and b, as stored in a
+// checkpoint so a resume does not depend on the caller's env or config.
+func duelSpec(a, b Player) (string, error) {
+	spec := runSpec
+	spec.Mode = config.ModeDuel
+	spec.Players = []config.Player{specPlayer(a), specPlayer(b)}
+	return spec.YAML()
+}
+
+// errMatchEnded means the match completed, so there is nothing to resume.
+var errMatchEnded = errors.New("already completed")
+
+// loadDuelCheckpoint reads a match's checkpoint. It fails when the match
+// has none or already ended.
+func loadDuelCheckpoint(ctx context.Context, db *store.DB, matchID int64) (*duelCheckpoint, error) {
+	row, err := db.LoadMatchCheckpoint(ctx, matchID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("match %d has no checkpoint", matchID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeDuelCheckpoint(row)
+}
+
+// decodeDuelCheckpoint is loadDuelCheckpoint after the query.
+func decodeDuelCheckpoint(row store.MatchCheckpoint) (*duelCheckpoint, error) {
+	if row.EndedAt != nil {
+		return nil, fmt.Errorf("match %d %w at %s", row.MatchID, errMatchEnded, row.EndedAt.Format(time.RFC3339))
+	}
+	var cp duelCheckpoint
+	if err := json.Unmarshal(row.State, &cp); err != nil {
+		return nil, fmt.Errorf("match %d: checkpoint: %w", row.MatchID, err)
+	}
+	cp.MatchID = row.MatchID
+	return &cp, nil
+}
+
+// resumeMatch continues an interrupted duel (--resume) from its last
+// completed pair with the run spec it was started with.
+func resumeMatch(checkStop func(bool) bool, gracefulOnly bool, db *store.DB, matchID int64) {
+	if db == nil {
+		log.Println("--resume needs DATABASE_URL.")
+		return
+	}
+	cp, err := loadDuelCheckpoint(context.Background(), db, matchID)
+	if err != nil {
+		log.Printf("Resume: %v", err)
+		return
+	}
+	spec, err := config.Parse([]byte(cp.Spec), ".yaml")
+	if err != nil {
+		log.Printf("Match %d: stored spec: %v", matchID, err)
+		return
+	}
+	runSpec = *spec
+	applyLimits(runSpec)
+	a, b := loadPlayers()
+	mustPlayerKeys(a, b)
+	runDuel(checkStop, gracefulOnly, db, a, b, duelOpts{Resume: cp})
+}
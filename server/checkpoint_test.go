@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-thunderdome/server/engine"
+	"ai-thunderdome/server/store"
+)
+
+func TestDecodeDuelCheckpoint(t *testing.T) {
+	// What runDuel saves after pair 3 of a match then stopped with Ctrl-C.
+	key := engine.DeckKeyFromSeed(11)
+	saved := duelCheckpoint{
+		MatchID: 99, Spec: "mode: duel\n", SeedBase: 11, SeedState: 12345, DeckKey: key.String(), Pairs: 3,
+		BotA: 1, BotB: 2, BankA: 10400, BankB: 9600, WinsA: 4, WinsB: 2,
+		Elo: NewElo(1500, 24), GA: *NewGlicko2(), GB: *NewGlicko2(),
+		Tallies:   map[string]*ActionTally{"A": {Check: 2, Raise: 1}},
+		PairWinsA: 2, PairTies: 1, Margins: []float64{0.1, 0, -0.02}, Aivat: []float64{80, 0, -15},
+		SeqWinsA: 2, SeqTies: 1,
+	}
+	raw, err := json.Marshal(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The stopped match is still open, so it loads.
+	cp, err := decodeDuelCheckpoint(store.MatchCheckpoint{MatchID: 7, Pairs: 3, State: raw})
+	if err != nil {
+		t.Fatalf("stopped match: %v", err)
+	}
+	if cp.MatchID != 7 {
+		t.Errorf("MatchID = %d, want the row's 7", cp.MatchID)
+	}
+	if cp.Pairs != 3 || cp.SeedState != 12345 || cp.BankA != 10400 || cp.SeqWinsA != 2 || cp.SeqTies != 1 {
+		t.Errorf("restored %+v", cp)
+	}
+	if k, err := engine.ParseDeckKey(cp.DeckKey); err != nil || k != key {
+		t.Errorf("deck key %q: %v", cp.DeckKey, err)
+	}
+	if len(cp.Margins) != 3 || len(cp.Aivat) != 3 || cp.Tallies["A"].Raise != 1 {
+		t.Errorf("margins %v, aivat %v, tallies %v", cp.Margins, cp.Aivat, cp.Tallies)
+	}
+
+	ended := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := decodeDuelCheckpoint(store.MatchCheckpoint{MatchID: 7, State: raw, EndedAt: &ended}); !errors.Is(err, errMatchEnded) {
+		t.Errorf("completed match: err = %v, want errMatchEnded", err)
+	}
+	if _, err := decodeDuelCheckpoint(store.MatchCheckpoint{MatchID: 7, State: []byte("{")}); err == nil {
+		t.Error("corrupt state accepted")
+	}
+}
@@ -0,0 +1,451 @@
+// Package config defines the declarative run spec (`--config run.yaml`).
+// A spec pins everything that affects a benchmark result — players and
+// their providers, game format, seeds, rating and judge settings, harness
+// behaviour — so a published run can be reproduced from one file.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"ai-thunderdome/server/engine"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Run modes.
+const (
+	ModeDuel   = "duel"
+	ModeMatrix = "matrix"
+	ModeLadder = "ladder"
+)
+
+// Matrix schedule formats.
+const (
+	FormatRoundRobin       = "round_robin"
+	FormatDoubleRoundRobin = "double_round_robin"
+)
+
+// Spec is a complete run configuration. Zero-valued optional fields mean
+// "provider default"; everything else is filled by Default.
+type Spec struct {
+	Mode     string   `yaml:"mode" toml:"mode"`
+	Players  []Player `yaml:"players" toml:"players"`
+	Game     Game     `yaml:"game" toml:"game"`
+	Seeds    Seeds    `yaml:"seeds" toml:"seeds"`
+	Rating   Rating   `yaml:"rating" toml:"rating"`
+	Judge    Judge    `yaml:"judge" toml:"judge"`
+	Behavior Behavior `yaml:"behavior" toml:"behavior"`
+	Schedule Schedule `yaml:"schedule" toml:"schedule"`
+	Limits   Limits   `yaml:"limits" toml:"limits"`
+	Ladder   Ladder   `yaml:"ladder" toml:"ladder"`
+	Stopping Stopping `yaml:"stopping" toml:"stopping"`
+
+	// Raw is the file exactly as read; stored with each match row.
+	Raw  string `yaml:"-" toml:"-"`
+	Path string `yaml:"-" toml:"-"`
+}
+
+// Player is one seat (duel) or one entrant (matrix).
+type Player struct {
+	Model           string   `yaml:"model" toml:"model"`
+	Provider        string   `yaml:"provider" toml:"provider"`
+	APIBase         string   `yaml:"api_base" toml:"api_base"`
+	APIKeyEnv       string   `yaml:"api_key_env" toml:"api_key_env"`
+	APIKeyFile      string   `yaml:"api_key_file" toml:"api_key_file"`
+	Temperature     *float64 `yaml:"temperature" toml:"temperature"`
+	TopP            *float64 `yaml:"top_p" toml:"top_p"`
+	ReasoningEffort string   `yaml:"reasoning_effort" toml:"reasoning_effort"`
+	MaxOutputTokens *int     `yaml:"max_output_tokens" toml:"max_output_tokens"`
+}
+
+// Game variants.
+const (
+	VariantHoldem = "holdem"     // no-limit Texas Hold'em
+	VariantPLO    = "plo"        // pot-limit Omaha
+	VariantShort  = "short_deck" // short-deck (6+) hold'em
+)
+
+// Betting structures. An empty Game.Betting uses the variant's own.
+const (
+	BettingNoLimit    = "no_limit"
+	BettingPotLimit   = "pot_limit"
+	BettingFixedLimit = "fixed_limit"
+)
+
+// Game is the table format.
+type Game struct {
+	Variant    string `yaml:"variant" toml:"variant"`
+	Betting    string `yaml:"betting" toml:"betting"`
+	SB         int    `yaml:"sb" toml:"sb"`
+	BB         int    `yaml:"bb" toml:"bb"`
+	StartStack int    `yaml:"start_stack" toml:"start_stack"`
+	// Fixed limit only; 0 means one big blind, twice the small bet and
+	// four bets per street.
+	SmallBet int `yaml:"small_bet" toml:"small_bet"`
+	BigBet   int `yaml:"big_bet" toml:"big_bet"`
+	RaiseCap int `yaml:"raise_cap" toml:"raise_cap"`
+}
+
+// Seeds controls how many mirrored pairs are dealt and from which base seed.
+// A nil Base draws a fresh crypto seed (recorded in the match row).
+type Seeds struct {
+	Pairs int    `yaml:"pairs" toml:"pairs"`
+	Base  *int64 `yaml:"base" toml:"base"`
+}
+
+// Sequential stopping rules.
+const (
+	StopNone = "none"
+	StopSPRT = "sprt" // two-sided SPRT on pair wins, H1: p = 0.5 ± Effect
+	StopCI   = "ci"   // stop once the Wilson interval at Alpha excludes 0.5
+)
+
+// Stopping makes a duel adaptive: a sequential test runs after every
+// mirrored pair and Seeds.Pairs becomes the maximum.
+type Stopping struct {
+	Rule     string  `yaml:"rule" toml:"rule"`
+	MinPairs int     `yaml:"min_pairs" toml:"min_pairs"`
+	Alpha    float64 `yaml:"alpha" toml:"alpha"`
+	Beta     float64 `yaml:"beta" toml:"beta"`
+	Effect   float64 `yaml:"effect" toml:"effect"`
+}
+
+// Glicko-2 rating periods for career ratings.
+const (
+	PeriodPair    = "pair"    // legacy: update after every mirrored pair, in-match
+	PeriodMatches = "matches" // close a period every PeriodMatches completed matches
+	PeriodDay     = "day"     // close a period at every UTC midnight
+)
+
+// Rating holds Elo settings and the Glicko-2 rating period. In a period
+// every bot is updated once from all of its pairs against all opponents;
+// bots that did not play have their RD inflated.
+type Rating struct {
+	EloStart      float64 `yaml:"elo_start" toml:"elo_start"`
+	EloK          float64 `yaml:"elo_k" toml:"elo_k"`
+	WeightByPot   bool    `yaml:"weight_by_pot" toml:"weight_by_pot"`
+	GlickoPeriod  string  `yaml:"glicko_period" toml:"glicko_period"`
+	PeriodMatches int     `yaml:"period_matches" toml:"period_matches"`
+}
+
+// Judge toggles the post-match Monte Carlo judge.
+type Judge struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+}
+
+// Schedule shapes matrix mode: which pairings are played and how often.
+// Double round-robin replays every pairing with the A/B labels swapped.
+// Workers > 1 plays independent matches concurrently.
+type Schedule struct {
+	Format         string `yaml:"format" toml:"format"`
+	MatchesPerPair int    `yaml:"matches_per_pair" toml:"matches_per_pair"`
+	Workers        int    `yaml:"workers" toml:"workers"`
+}
+
+// Legs is the number of times each pairing meets per cycle.
+func (s Schedule) Legs() int {
+	if s.Format == FormatDoubleRoundRobin {
+		return 2
+	}
+	return 1
+}
+
+// Ladder bounds ladder mode, which keeps picking the most informative
+// pairing until a budget runs out. Either budget may be zero (unbounded),
+// not both. BudgetUSD needs a price for every player's model.
+type Ladder struct {
+	BudgetHands int              `yaml:"budget_hands" toml:"budget_hands"`
+	BudgetUSD   float64          `yaml:"budget_usd" toml:"budget_usd"`
+	Prices      map[string]Price `yaml:"prices" toml:"prices"`
+}
+
+// Price is a model's list price in USD per million tokens.
+type Price struct {
+	Input  float64 `yaml:"input" toml:"input"`
+	Output float64 `yaml:"output" toml:"output"`
+}
+
+// Limits caps provider traffic, shared by every match in the process. Keys
+// are provider names (openai, openrouter, anthropic, gemini) and model ids.
+type Limits struct {
+	Providers map[string]Limit `yaml:"providers" toml:"providers"`
+	Models    map[string]Limit `yaml:"models" toml:"models"`
+	Retry     Retry            `yaml:"retry" toml:"retry"`
+}
+
+// Limit is one provider's or model's budget; zero fields are unlimited.
+type Limit struct {
+	Concurrency int `yaml:"concurrency" toml:"concurrency"`
+	RPM         int `yaml:"rpm" toml:"rpm"`
+	TPM         int `yaml:"tpm" toml:"tpm"`
+}
+
+// Retry is the backoff for 429 and 5xx responses.
+type Retry struct {
+	MaxAttempts int `yaml:"max_attempts" toml:"max_attempts"`
+	BaseMS      int `yaml:"base_ms" toml:"base_ms"`
+	MaxMS       int `yaml:"max_ms" toml:"max_ms"`
+}
+
+// Behavior collects the harness knobs that used to be env-only.
+type Behavior struct {
+	UseTools           bool    `yaml:"use_tools" toml:"use_tools"`
+	MixedPolicy        bool    `yaml:"mixed_policy" toml:"mixed_policy"`
+	CaptureRationale   bool    `yaml:"capture_rationale" toml:"capture_rationale"`
+	EncourageProbeZero bool    `yaml:"encourage_probe_zero" toml:"encourage_probe_zero"`
+	RaiseFirstZeroCall bool    `yaml:"raise_first_zero_call" toml:"raise_first_zero_call"`
+	RaiseZeroCallProb  float64 `yaml:"raise_zero_call_prob" toml:"raise_zero_call_prob"`
+	ForceNonCheck      string  `yaml:"force_noncheck" toml:"force_noncheck"`
+	MaxOutputTokens    *int    `yaml:"max_output_tokens" toml:"max_output_tokens"`
+	ReasoningEffort    string  `yaml:"reasoning_effort" toml:"reasoning_effort"`
+}
+
+// Default returns the spec the harness uses when a field is not set. The
+// values match the historical env-var defaults.
+func Default() Spec {
+	return Spec{
+		Mode:   ModeDuel,
+		Game:   Game{Variant: VariantHoldem, SB: 50, BB: 100, StartStack: 10000},
+		Seeds:  Seeds{Pairs: 5},
+		Rating: Rating{EloStart: 1500, EloK: 24, GlickoPeriod: PeriodMatches, PeriodMatches: 1},
+		Judge:  Judge{Enabled: true},
+		Behavior: Behavior{
+			UseTools:          true,
+			RaiseZeroCallProb: 0.35,
+		},
+		Schedule: Schedule{Format: FormatRoundRobin, MatchesPerPair: 1, Workers: 1},
+		Limits:   Limits{Retry: Retry{MaxAttempts: 4, BaseMS: 1000, MaxMS: 30000}},
+		Stopping: Stopping{Rule: StopNone, MinPairs: 10, Alpha: 0.05, Beta: 0.1, Effect: 0.1},
+	}
+}
+
+// Load reads a YAML (.yaml, .yml, .json) or TOML (.toml) spec, applies
+// defaults for omitted fields and validates it. Unknown keys are errors so
+// typos do not silently fall back to defaults.
+func Load(path string) (*Spec, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Parse(b, filepath.Ext(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	s.Path = path
+	return s, nil
+}
+
+// Parse is Load for in-memory specs; ext selects the syntax as in Load.
+func Parse(b []byte, ext string) (*Spec, error) {
+	s := Default()
+	switch ext = strings.ToLower(ext); ext {
+	case ".yaml", ".yml", ".json":
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		if err := dec.Decode(&s); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		md, err := toml.Decode(string(b), &s)
+		if err != nil {
+			return nil, err
+		}
+		if extra := md.Undecoded(); len(extra) > 0 {
+			keys := make([]string, len(extra))
+			for i, k := range extra {
+				keys[i] = k.String()
+			}
+			return nil, fmt.Errorf("unknown keys: %s", strings.Join(keys, ", "))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config extension %q (want .yaml, .yml, .json or .toml)", ext)
+	}
+	s.Raw = string(b)
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// YAML renders the resolved spec (defaults and env values filled in) in a
+// form Parse reads back; schedules store it so a resume needs no env.
+func (s Spec) YAML() (string, error) {
+	b, err := yaml.Marshal(s)
+	return string(b), err
+}
+
+// Validate reports every problem with the spec at once, one per line.
+func (s *Spec) Validate() error {
+	var errs []error
+	bad := func(field, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: "+format, append([]any{field}, args...)...))
+	}
+
+	switch s.Mode {
+	case ModeDuel:
+		if len(s.Players) != 2 {
+			bad("players", "duel mode needs exactly 2 players, got %d", len(s.Players))
+		}
+	case ModeMatrix, ModeLadder:
+		if len(s.Players) < 2 {
+			bad("players", "%s mode needs at least 2 players, got %d", s.Mode, len(s.Players))
+		}
+	default:
+		bad("mode", "must be %q, %q or %q, got %q", ModeDuel, ModeMatrix, ModeLadder, s.Mode)
+	}
+	for i, p := range s.Players {
+		f := fmt.Sprintf("players[%d]", i)
+		if strings.TrimSpace(p.Model) == "" {
+			bad(f+".model", "required")
+		}
+		switch strings.ToLower(p.Provider) {
+		case "", "openai", "openrouter", "anthropic", "gemini", "google":
+		default:
+			bad(f+".provider", "unknown provider %q (want openai, openrouter, anthropic or gemini)", p.Provider)
+		}
+		if p.APIKeyEnv != "" && p.APIKeyFile != "" {
+			bad(f, "set only one of api_key_env and api_key_file")
+		}
+		if p.Temperature != nil && (*p.Temperature < 0 || *p.Temperature > 2) {
+			bad(f+".temperature", "must be within [0, 2]")
+		}
+		if p.TopP != nil && (*p.TopP <= 0 || *p.TopP > 1) {
+			bad(f+".top_p", "must be within (0, 1]")
+		}
+		if p.MaxOutputTokens != nil && *p.MaxOutputTokens <= 0 {
+			bad(f+".max_output_tokens", "must be positive")
+		}
+		if !validEffort(p.ReasoningEffort) {
+			bad(f+".reasoning_effort", "want minimal, low, medium, high or a token budget, got %q", p.ReasoningEffort)
+		}
+	}
+
+	// The engine decides which names (and aliases) it can deal.
+	if _, err := engine.ParseVariant(s.Game.Variant); err != nil {
+		bad("game.variant", "%v", err)
+	}
+	if _, err := engine.ParseBetting(s.Game.Betting); err != nil {
+		bad("game.betting", "%v", err)
+	}
+	if s.Game.SmallBet < 0 {
+		bad("game.small_bet", "must not be negative")
+	}
+	if s.Game.BigBet < 0 || (s.Game.BigBet > 0 && s.Game.BigBet < s.Game.SmallBet) {
+		bad("game.big_bet", "must not be below game.small_bet")
+	}
+	if s.Game.RaiseCap < 0 {
+		bad("game.raise_cap", "must not be negative")
+	}
+	if s.Game.SB <= 0 {
+		bad("game.sb", "must be positive")
+	}
+	if s.Game.BB <= s.Game.SB {
+		bad("game.bb", "must be greater than game.sb")
+	}
+	if s.Game.StartStack < 2*s.Game.BB {
+		bad("game.start_stack", "must be at least two big blinds")
+	}
+	if s.Seeds.Pairs <= 0 {
+		bad("seeds.pairs", "must be positive")
+	}
+	st := s.Stopping
+	switch st.Rule {
+	case StopNone, StopSPRT, StopCI:
+	default:
+		bad("stopping.rule", "want %q, %q or %q, got %q", StopNone, StopSPRT, StopCI, st.Rule)
+	}
+	if st.MinPairs < 1 {
+		bad("stopping.min_pairs", "must be positive")
+	}
+	if st.Alpha <= 0 || st.Alpha >= 1 {
+		bad("stopping.alpha", "must be within (0, 1)")
+	}
+	if st.Beta <= 0 || st.Beta >= 1 {
+		bad("stopping.beta", "must be within (0, 1)")
+	}
+	if st.Effect <= 0 || st.Effect >= 0.5 {
+		bad("stopping.effect", "must be within (0, 0.5)")
+	}
+	if s.Rating.EloK <= 0 {
+		bad("rating.elo_k", "must be positive")
+	}
+	if s.Rating.EloStart <= 0 {
+		bad("rating.elo_start", "must be positive")
+	}
+	switch s.Rating.GlickoPeriod {
+	case PeriodPair, PeriodMatches, PeriodDay:
+	default:
+		bad("rating.glicko_period", "want %q, %q or %q, got %q", PeriodPair, PeriodMatches, PeriodDay, s.Rating.GlickoPeriod)
+	}
+	if s.Rating.GlickoPeriod == PeriodMatches && s.Rating.PeriodMatches < 1 {
+		bad("rating.period_matches", "must be positive")
+	}
+	if p := s.Behavior.RaiseZeroCallProb; p < 0 || p > 1 {
+		bad("behavior.raise_zero_call_prob", "must be within [0, 1]")
+	}
+	switch strings.ToLower(s.Behavior.ForceNonCheck) {
+	case "", "raise", "fold", "any":
+	default:
+		bad("behavior.force_noncheck", "want raise, fold or any, got %q", s.Behavior.ForceNonCheck)
+	}
+	if s.Behavior.MaxOutputTokens != nil && *s.Behavior.MaxOutputTokens <= 0 {
+		bad("behavior.max_output_tokens", "must be positive")
+	}
+	if !validEffort(s.Behavior.ReasoningEffort) {
+		bad("behavior.reasoning_effort", "want minimal, low, medium, high or a token budget, got %q", s.Behavior.ReasoningEffort)
+	}
+	switch s.Schedule.Format {
+	case FormatRoundRobin, FormatDoubleRoundRobin:
+	default:
+		bad("schedule.format", "want %q or %q, got %q", FormatRoundRobin, FormatDoubleRoundRobin, s.Schedule.Format)
+	}
+	if s.Schedule.MatchesPerPair <= 0 {
+		bad("schedule.matches_per_pair", "must be positive")
+	}
+	if s.Schedule.Workers <= 0 {
+		bad("schedule.workers", "must be positive")
+	}
+	for group, m := range map[string]map[string]Limit{"providers": s.Limits.Providers, "models": s.Limits.Models} {
+		for name, l := range m {
+			if l.Concurrency < 0 || l.RPM < 0 || l.TPM < 0 {
+				bad(fmt.Sprintf("limits.%s.%s", group, name), "limits must not be negative")
+			}
+		}
+	}
+	if s.Ladder.BudgetHands < 0 || s.Ladder.BudgetUSD < 0 {
+		bad("ladder", "budgets must not be negative")
+	}
+	if s.Mode == ModeLadder && s.Ladder.BudgetHands == 0 && s.Ladder.BudgetUSD == 0 {
+		bad("ladder", "ladder mode needs budget_hands or budget_usd")
+	}
+	if s.Ladder.BudgetUSD > 0 {
+		for i, p := range s.Players {
+			if _, ok := s.Ladder.Prices[p.Model]; !ok {
+				bad("ladder.prices", "no price for players[%d] model %q", i, p.Model)
+			}
+		}
+	}
+	if s.Limits.Retry.MaxAttempts <= 0 {
+		bad("limits.retry.max_attempts", "must be positive")
+	}
+	if s.Limits.Retry.BaseMS < 0 || s.Limits.Retry.MaxMS < 0 {
+		bad("limits.retry", "delays must not be negative")
+	}
+	return errors.Join(errs...)
+}
+
+func validEffort(e string) bool {
+	switch strings.ToLower(strings.TrimSpace(e)) {
+	case "", "none", "minimal", "low", "medium", "high":
+		return true
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(e))
+	return err == nil && n > 0
+}
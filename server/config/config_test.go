@@ -0,0 +1,200 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadExample(t *testing.T) {
+	s, err := Load(filepath.Join("..", "..", "run.example.yaml"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(s.Players) != 2 || s.Players[1].Temperature == nil || *s.Players[1].Temperature != 0.7 {
+		t.Fatalf("players = %+v", s.Players)
+	}
+	if !strings.Contains(s.Raw, "mode: duel") {
+		t.Fatalf("raw config not kept verbatim")
+	}
+}
+
+func TestLoadDefaultsAndTOML(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.toml")
+	src := `
+[[players]]
+model = "a"
+[[players]]
+model = "b"
+reasoning_effort = "high"
+
+[seeds]
+pairs = 50
+`
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if s.Seeds.Pairs != 50 || s.Game.BB != 100 || !s.Behavior.UseTools || s.Players[1].ReasoningEffort != "high" {
+		t.Fatalf("spec = %+v", s)
+	}
+}
+
+func TestLoadRejectsUnknownAndInvalid(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	if _, err := Load(write("typo.yaml", "players: []\ngame:\n  big_blind: 100\n")); err == nil || !strings.Contains(err.Error(), "big_blind") {
+		t.Fatalf("expected unknown-field error, got %v", err)
+	}
+
+	_, err := Load(write("bad.yaml", `
+players:
+  - model: a
+    provider: bogus
+game:
+  sb: 100
+  bb: 50
+behavior:
+  raise_zero_call_prob: 2
+`))
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"players: duel mode needs exactly 2", "players[0].provider", "game.bb", "behavior.raise_zero_call_prob"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	s := Default()
+	s.Mode = ModeMatrix
+	temp := 0.3
+	seed := int64(42)
+	s.Players = []Player{{Model: "a", Temperature: &temp}, {Model: "b", Provider: "anthropic"}, {Model: "c"}}
+	s.Seeds.Base = &seed
+	s.Schedule = Schedule{Format: FormatDoubleRoundRobin, MatchesPerPair: 2, Workers: 3}
+	s.Limits.Providers = map[string]Limit{"openrouter": {Concurrency: 8, RPM: 120}}
+
+	text, err := s.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse([]byte(text), ".yaml")
+	if err != nil {
+		t.Fatalf("Parse: %v\n%s", err, text)
+	}
+	if len(got.Players) != 3 || *got.Players[0].Temperature != 0.3 || got.Players[1].Provider != "anthropic" ||
+		*got.Seeds.Base != 42 || got.Schedule.Legs() != 2 || got.Schedule.MatchesPerPair != 2 ||
+		got.Schedule.Workers != 3 || got.Limits.Providers["openrouter"].RPM != 120 {
+		t.Fatalf("round trip = %+v", got)
+	}
+}
+
+func TestValidateLadderBudget(t *testing.T) {
+	s := Default()
+	s.Mode = ModeLadder
+	s.Players = []Player{{Model: "a"}, {Model: "b"}}
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "budget_hands or budget_usd") {
+		t.Fatalf("expected missing budget error, got %v", err)
+	}
+	s.Ladder = Ladder{BudgetUSD: 5, Prices: map[string]Price{"a": {Input: 1, Output: 2}}}
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), `model "b"`) {
+		t.Fatalf("expected missing price error, got %v", err)
+	}
+	s.Ladder.Prices["b"] = Price{Input: 1, Output: 2}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateGlickoPeriod(t *testing.T) {
+	s := Default()
+	s.Players = []Player{{Model: "a"}, {Model: "b"}}
+	if s.Rating.GlickoPeriod != PeriodMatches || s.Rating.PeriodMatches != 1 {
+		t.Fatalf("default rating = %+v", s.Rating)
+	}
+	s.Rating.PeriodMatches = 0
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "rating.period_matches") {
+		t.Fatalf("expected period_matches error, got %v", err)
+	}
+	s.Rating.GlickoPeriod = PeriodDay
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	s.Rating.GlickoPeriod = "week"
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "rating.glicko_period") {
+		t.Fatalf("expected glicko_period error, got %v", err)
+	}
+}
+
+func TestValidateGameVariant(t *testing.T) {
+	s := Default()
+	s.Players = []Player{{Model: "a"}, {Model: "b"}}
+	if s.Game.Variant != VariantHoldem {
+		t.Fatalf("default variant = %q", s.Game.Variant)
+	}
+	// Aliases the engine accepts are valid too.
+	for _, v := range []string{VariantPLO, VariantShort, "omaha", "6+", "Short", "HOLDEM"} {
+		s.Game.Variant = v
+		if err := s.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Game.Variant = "razz"
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "game.variant") {
+		t.Fatalf("expected game.variant error, got %v", err)
+	}
+}
+
+func TestValidateGameBetting(t *testing.T) {
+	s := Default()
+	s.Players = []Player{{Model: "a"}, {Model: "b"}}
+	s.Game.Betting = BettingFixedLimit
+	s.Game.SmallBet, s.Game.BigBet, s.Game.RaiseCap = 100, 200, 4
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []string{"nl", "PL", "limit"} {
+		s.Game.Betting = b
+		if err := s.Validate(); err != nil {
+			t.Fatalf("betting %q: %v", b, err)
+		}
+	}
+	s.Game.Betting = "spread_limit"
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "game.betting") {
+		t.Fatalf("expected game.betting error, got %v", err)
+	}
+	s.Game.Betting = BettingFixedLimit
+	s.Game.BigBet = 50
+	if err := s.Validate(); err == nil || !strings.Contains(err.Error(), "game.big_bet") {
+		t.Fatalf("expected game.big_bet error, got %v", err)
+	}
+}
+
+func TestValidEffort(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want bool
+	}{
+		{"", true}, {"High", true}, {"minimal", true}, {"2048", true}, {" 512 ", true},
+		{"0", false}, {"-5", false}, {"12abc", false}, {"1.5", false}, {"extreme", false},
+	} {
+		if got := validEffort(tc.in); got != tc.want {
+			t.Errorf("validEffort(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stdoutMu serializes every write to the terminal.
+var stdoutMu sync.Mutex
+
+// console is where one match prints. The shared stdout console writes
+// through; a buffered console (one per matrix worker) collects output and
+// emits it as a single block on Flush, each line tagged with the console's
+// prefix, so concurrent matches never interleave mid-pair.
+type console struct {
+	buf    *bytes.Buffer // nil → write through
+	prefix string
+}
+
+// stdoutConsole is used by sequential runs.
+var stdoutConsole = &console{}
+
+func newBufferedConsole(prefix string) *console {
+	return &console{buf: &bytes.Buffer{}, prefix: prefix}
+}
+
+func (c *console) Printf(format string, args ...any) { c.write(fmt.Sprintf(format, args...)) }
+func (c *console) Println(args ...any)               { c.write(fmt.Sprintln(args...)) }
+
+// Logf is log.Printf routed through the console so log lines stay in order
+// with the match output around them.
+func (c *console) Logf(format string, args ...any) {
+	if c.buf == nil {
+		log.Printf(format, args...)
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if !strings.HasSuffix(msg, "\n") {
+		msg += "\n"
+	}
+	c.buf.WriteString(time.Now().Format("2006/01/02 15:04:05.000000 ") + msg)
+}
+
+func (c *console) Logln(args ...any) { c.Logf("%s", strings.TrimSuffix(fmt.Sprintln(args...), "\n")) }
+
+func (c *console) section(title string) {
+	c.Printf("\n%s %s %s\n", dim("──"), bold(title), dim("──"))
+}
+func (c *console) sub(title string) { c.Printf("%s %s\n", dim("•"), bold(title)) }
+
+func (c *console) write(s string) {
+	if c.buf == nil {
+		stdoutMu.Lock()
+		os.Stdout.WriteString(s)
+		stdoutMu.Unlock()
+		return
+	}
+	c.buf.WriteString(s)
+}
+
+// Flush writes the buffered block to stdout. No-op for write-through consoles.
+func (c *console) Flush() {
+	if c.buf == nil || c.buf.Len() == 0 {
+		return
+	}
+	var out strings.Builder
+	for _, line := range strings.SplitAfter(c.buf.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		out.WriteString(c.prefix)
+		out.WriteString(line)
+	}
+	c.buf.Reset()
+	stdoutMu.Lock()
+	os.Stdout.WriteString(out.String())
+	stdoutMu.Unlock()
+}
@@ -6,17 +6,17 @@ import (
 	"time"
 )
 
-func NewDeck(seed int64) []Card {
+func NewDeck(seed int64) []Card { return shuffle(fullDeck(), seed) }
+
+// NewShortDeck is NewDeck for short-deck hold'em: the 36 cards six and up.
+func NewShortDeck(seed int64) []Card { return shuffle(shortDeck(), seed) }
+
+// shuffle permutes deck in place from seed (0 draws one from the clock).
+func shuffle(deck []Card, seed int64) []Card {
 	if seed == 0 {
 		seed = time.Now().UnixNano()
 	}
 	r := rand.New(rand.NewSource(seed))
-	var deck []Card
-	for s := 0; s < 4; s++ {
-		for rnk := 2; rnk <= 14; rnk++ {
-			deck = append(deck, Card{Rank: rnk, Suit: "cdhs"[s]})
-		}
-	}
 	for i := len(deck) - 1; i > 0; i-- {
 		j := r.Intn(i + 1)
 		deck[i], deck[j] = deck[j], deck[i]
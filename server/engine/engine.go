@@ -2,7 +2,14 @@ package engine
 
 import "fmt"
 
-type Config struct{ SB, BB, StartStack int }
+type Config struct {
+	SB, BB, StartStack int
+	Variant            Variant // "" plays as Holdem
+	Betting            Betting // "" → the variant's default; see Limit
+
+	// Fixed limit only; zero values mean BB, 2·SmallBet and DefaultRaiseCap.
+	SmallBet, BigBet, RaiseCap int
+}
 
 type Player struct {
 	Seat      Seat
@@ -13,18 +20,22 @@ type Player struct {
 	AllIn     bool
 }
 
+// Hand is the full state of one hand, including the undealt Deck; it
+// round-trips through encoding/json (see snapshot.go).
 type Hand struct {
-	ID       string
-	Cfg      Config
-	Deck     []Card
-	Board    []Card
-	Pot      int
-	Street   string
-	SB, BB   *Player
-	ToAct    Seat
-	CurBet   int
-	MinRaise int
-	History  []Action
+	ID          string
+	Cfg         Config
+	Deck        []Card
+	Board       []Card
+	Pot         int
+	Street      string
+	SB, BB      *Player
+	ToAct       Seat
+	CurBet      int
+	MinRaise    int
+	Bets        int // bets and raises this street; preflop the big blind is one
+	History     []Action
+	StreetStart int // index in History of this street's first action
 }
 
 func NewHand(id string, cfg Config, deck []Card) *Hand {
@@ -37,13 +48,26 @@ func NewHand(id string, cfg Config, deck []Card) *Hand {
 	h.dealHole()
 	h.ToAct = SB        // HU preflop: SB first
 	h.MinRaise = cfg.BB // postflop increment; preflop min to is set on first raise
+	h.Bets = 1
+	if cfg.Limit() == FixedLimit {
+		h.MinRaise = h.BetSize()
+	}
 	return h
 }
 
 func (h *Hand) postBlinds() { h.bet(h.SB, h.Cfg.SB); h.bet(h.BB, h.Cfg.BB) }
-func (h *Hand) dealHole()   { h.SB.Hole = []Card{h.pop(), h.pop()}; h.BB.Hole = []Card{h.pop(), h.pop()} }
 func (h *Hand) pop() Card   { c := h.Deck[0]; h.Deck = h.Deck[1:]; return c }
 
+func (h *Hand) dealHole() {
+	n := h.Cfg.Variant.HoleCards()
+	for _, p := range []*Player{h.SB, h.BB} {
+		p.Hole = make([]Card, n)
+		for i := range p.Hole {
+			p.Hole[i] = h.pop()
+		}
+	}
+}
+
 func (h *Hand) bet(p *Player, amt int) {
 	if amt >= p.Stack {
 		amt = p.Stack
@@ -82,7 +106,7 @@ func (h *Hand) Legal() []ActionKind {
 	} else {
 		out = append(out, Fold, Call)
 	}
-	if !a.AllIn && !h.other(a).AllIn {
+	if !a.AllIn && !h.other(a).AllIn && !h.Capped() {
 		out = append(out, Raise)
 	}
 	return out
@@ -108,6 +132,18 @@ func (h *Hand) Apply(kind ActionKind, amount int) error {
 		h.bet(a, to)
 		h.History = append(h.History, Action{Seat: a.Seat, Kind: Call, Amount: to})
 	case Raise:
+		switch h.Cfg.Limit() {
+		case FixedLimit:
+			// The size is fixed, so amount is ignored.
+			if h.Capped() {
+				return fmt.Errorf("betting is capped at %d bets", h.Cfg.Cap())
+			}
+			amount = h.CurBet + h.BetSize()
+		case PotLimit:
+			if limit := h.MaxRaiseTo(a.Seat); amount > limit {
+				return fmt.Errorf("pot limit: max raise to %d", limit)
+			}
+		}
 		if amount < h.CurBet+h.MinRaise {
 			return fmt.Errorf("min raise to %d", h.CurBet+h.MinRaise)
 		}
@@ -115,6 +151,7 @@ func (h *Hand) Apply(kind ActionKind, amount int) error {
 		raise := amount - a.Committed
 		h.bet(a, raise)
 		h.MinRaise = amount - prevCur // amount minus previous CurBet
+		h.Bets++
 		h.History = append(h.History, Action{Seat: a.Seat, Kind: Raise, Amount: amount})
 	}
 	h.ToAct = h.other(a).Seat
@@ -152,6 +189,11 @@ func (h *Hand) NextStreet() {
 	h.SB.Committed = 0
 	h.BB.Committed = 0
 	h.MinRaise = h.Cfg.BB
+	h.Bets = 0
+	if h.Cfg.Limit() == FixedLimit {
+		h.MinRaise = h.BetSize()
+	}
+	h.StreetStart = len(h.History)
 	h.ToAct = BB // postflop in HU
 }
 
@@ -166,12 +208,11 @@ func (h *Hand) Showdown() Seat {
 	if h.BB.Folded {
 		return SB
 	}
-	sb := best5of7(append(append([]Card{}, h.SB.Hole...), h.Board...))
-	bb := best5of7(append(append([]Card{}, h.BB.Hole...), h.Board...))
+	sb, bb := h.Cfg.Variant.Eval(h.SB.Hole, h.Board), h.Cfg.Variant.Eval(h.BB.Hole, h.Board)
 	switch {
-	case better(sb, bb):
+	case sb > bb:
 		return SB
-	case better(bb, sb):
+	case bb > sb:
 		return BB
 	default:
 		return "" // tie
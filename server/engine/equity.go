@@ -0,0 +1,477 @@
+package engine
+
+import (
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+// Combo is a two-card holding in a range with its relative weight (1 for
+// a plain hand, less for a partially played one).
+type Combo struct {
+	Cards  [2]Card
+	Weight float64
+}
+
+// Range is a weighted set of two-card holdings.
+type Range []Combo
+
+// AnyTwo is every two-card holding at weight 1.
+func AnyTwo() Range {
+	deck := deck52
+	r := make(Range, 0, 1326)
+	for i := range deck {
+		for j := i + 1; j < len(deck); j++ {
+			r = append(r, Combo{Cards: [2]Card{deck[i], deck[j]}, Weight: 1})
+		}
+	}
+	return r
+}
+
+// EquityResult is hero's outcome against a range, weighted by combo.
+type EquityResult struct {
+	Win, Tie, Lose float64 // fractions summing to 1
+	Equity         float64 // Win + Tie/2: hero's share of a heads-up pot
+	StdErr         float64 // Monte-Carlo standard error of Equity; 0 when exact
+	HeroCombos     int     // hero combos left after card removal
+	Combos         int     // villain combos left after card removal
+	Runouts        int     // showdowns evaluated
+	Exact          bool    // every combo × runout was enumerated
+	Seed           uint64  // Monte-Carlo seed; RangeEquitySeed with it repeats the result. 0 when exact
+	// HeroHands and VillainHands are the weighted share of showdowns in
+	// which each side ends with a hand of each Category.
+	HeroHands, VillainHands [StraightFlush + 1]float64
+}
+
+// exactLimit is the most showdowns Equity enumerates before sampling.
+const exactLimit = 2_000_000
+
+// DefaultIterations is the Monte-Carlo sample count when Equity is given none.
+const DefaultIterations = 200_000
+
+// equityChunk is the Monte-Carlo work unit. Each chunk has its own seed,
+// derived from the call's, so a result does not depend on how many workers
+// ran it.
+const equityChunk = 4096
+
+// Equity is hero's equity against villainRange on a 0–5 card board. See
+// RangeEquity.
+func Equity(hero []Card, villainRange Range, board []Card, iterations int) EquityResult {
+	return RangeEquity(Range{{Cards: [2]Card{hero[0], hero[1]}, Weight: 1}}, villainRange, board, iterations)
+}
+
+// RangeEquity is heroRange's equity against villainRange on a 0–5 card
+// board. Combos that share a card with the board are dropped, and a pair of
+// hero and villain combos that share a card never meets; each remaining pair
+// counts by the product of its weights. It enumerates every pair and runout
+// when that takes at most exactLimit showdowns, and otherwise draws
+// iterations (DefaultIterations if ≤ 0) random deals by weight, split across
+// GOMAXPROCS workers. Ranges that cannot meet give a zero result.
+//
+// Sampled results use a fresh random seed per call, reported in Seed; pass
+// it to RangeEquitySeed to repeat one exactly.
+func RangeEquity(heroRange, villainRange Range, board []Card, iterations int) EquityResult {
+	return RangeEquitySeed(heroRange, villainRange, board, iterations, rand.Uint64())
+}
+
+// RangeEquitySeed is RangeEquity with the Monte-Carlo seed given: the same
+// seed and inputs give the same result on any number of workers.
+func RangeEquitySeed(heroRange, villainRange Range, board []Card, iterations int, seed uint64) EquityResult {
+	if iterations <= 0 {
+		iterations = DefaultIterations
+	}
+	hs := liveCombos(heroRange, board)
+	vs := liveCombos(villainRange, board)
+	usedH := make([]bool, len(hs))
+	usedV := make([]bool, len(vs))
+	pairs := 0
+	for i, h := range hs {
+		for j, v := range vs {
+			if !conflict(h.Cards, v.Cards) {
+				pairs++
+				usedH[i], usedV[j] = true, true
+			}
+		}
+	}
+	hs, vs = keep(hs, usedH), keep(vs, usedV)
+	res := EquityResult{HeroCombos: len(hs), Combos: len(vs)}
+	if pairs == 0 {
+		return res
+	}
+
+	runouts := choose(52-len(board)-4, 5-len(board))
+	var tallies []showdownTally
+	if pairs*runouts <= exactLimit {
+		res.Exact = true
+		tallies = parallel(len(hs), 0, func(i int, _ *rand.Rand) showdownTally {
+			var t showdownTally
+			for _, v := range vs {
+				if conflict(hs[i].Cards, v.Cards) {
+					continue
+				}
+				pt := enumerate(hs[i].Cards, v.Cards, board)
+				pt.scale(hs[i].Weight * v.Weight)
+				t.add(pt)
+			}
+			return t
+		})
+	} else {
+		hcum, vcum := cumulative(hs), cumulative(vs)
+		chunks := (iterations + equityChunk - 1) / equityChunk
+		res.Seed = seed
+		tallies = parallel(chunks, seed, func(k int, rng *rand.Rand) showdownTally {
+			n := min(equityChunk, iterations-k*equityChunk)
+			return sample(hs, hcum, vs, vcum, board, n, rng)
+		})
+	}
+	var sum showdownTally
+	for _, t := range tallies {
+		sum.add(t)
+	}
+	n := sum.win + sum.tie + sum.lose
+	res.Win, res.Tie, res.Lose = sum.win/n, sum.tie/n, sum.lose/n
+	res.Equity = res.Win + res.Tie/2
+	for c := range res.HeroHands {
+		res.HeroHands[c] = sum.hands[0][c] / n
+		res.VillainHands[c] = sum.hands[1][c] / n
+	}
+	res.Runouts = sum.runouts
+	if !res.Exact {
+		// Each sample pays 1, ½ or 0.
+		v := res.Win + res.Tie/4 - res.Equity*res.Equity
+		res.StdErr = math.Sqrt(max(v, 0) / float64(sum.runouts))
+	}
+	return res
+}
+
+// liveCombos drops zero-weight, malformed and board-blocked combos.
+func liveCombos(r Range, board []Card) Range {
+	var out Range
+	for _, c := range r {
+		if c.Weight > 0 && c.Cards[0] != c.Cards[1] && !onBoard(c.Cards[0], board) && !onBoard(c.Cards[1], board) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func keep(r Range, used []bool) Range {
+	out := r[:0:0]
+	for i, c := range r {
+		if used[i] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func cumulative(r Range) []float64 {
+	cum := make([]float64, len(r))
+	acc := 0.0
+	for i, c := range r {
+		acc += c.Weight
+		cum[i] = acc
+	}
+	return cum
+}
+
+func onBoard(c Card, board []Card) bool {
+	for _, b := range board {
+		if b == c {
+			return true
+		}
+	}
+	return false
+}
+
+func holds(h [2]Card, c Card) bool { return h[0] == c || h[1] == c }
+
+func conflict(a, b [2]Card) bool {
+	return a[0] == b[0] || a[0] == b[1] || a[1] == b[0] || a[1] == b[1]
+}
+
+// showdownTally counts outcomes from hero's side; counts may be weighted.
+// hands[0] and hands[1] count hero's and villain's final hand categories.
+type showdownTally struct {
+	win, tie, lose float64
+	hands          [2][StraightFlush + 1]float64
+	runouts        int
+}
+
+func (t *showdownTally) add(o showdownTally) {
+	t.win += o.win
+	t.tie += o.tie
+	t.lose += o.lose
+	for s := range t.hands {
+		for c := range t.hands[s] {
+			t.hands[s][c] += o.hands[s][c]
+		}
+	}
+	t.runouts += o.runouts
+}
+
+// scale turns counts into fractions of one unit of weight w.
+func (t *showdownTally) scale(w float64) {
+	n := t.win + t.tie + t.lose
+	if n == 0 {
+		return
+	}
+	f := w / n
+	t.win, t.tie, t.lose = t.win*f, t.tie*f, t.lose*f
+	for s := range t.hands {
+		for c := range t.hands[s] {
+			t.hands[s][c] *= f
+		}
+	}
+}
+
+func (t *showdownTally) record(h, v HandRank) {
+	switch {
+	case h > v:
+		t.win++
+	case h == v:
+		t.tie++
+	default:
+		t.lose++
+	}
+	t.hands[0][h.Category()]++
+	t.hands[1][v.Category()]++
+	t.runouts++
+}
+
+// enumerate plays out every runout for hero against one villain holding.
+func enumerate(hero, villain [2]Card, board []Card) showdownTally {
+	var rest [52]Card
+	n := 0
+	for _, c := range deck52 {
+		if !onBoard(c, board) && !holds(hero, c) && !holds(villain, c) {
+			rest[n] = c
+			n++
+		}
+	}
+	var hs, vs [7]Card
+	copy(hs[:], hero[:])
+	copy(vs[:], villain[:])
+	b := copy(hs[2:], board)
+	copy(vs[2:], board)
+	var t showdownTally
+	var deal func(from, k int)
+	deal = func(from, k int) {
+		if 2+b+k == 7 {
+			t.record(Eval7(&hs), Eval7(&vs))
+			return
+		}
+		for i := from; i < n; i++ {
+			hs[2+b+k], vs[2+b+k] = rest[i], rest[i]
+			deal(i+1, k+1)
+		}
+	}
+	deal(0, 0)
+	return t
+}
+
+// pick draws an index by weight from cumulative weights.
+func pick(cum []float64, rng *rand.Rand) int {
+	x := rng.Float64() * cum[len(cum)-1]
+	lo, hi := 0, len(cum)-1
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if cum[mid] > x {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
+
+// sample draws n (hero combo, villain combo, runout) deals. Combos are drawn
+// independently by weight and redrawn when they collide, which leaves each
+// compatible pair weighted by the product of its weights.
+func sample(heroes Range, hcum []float64, villains Range, vcum []float64, board []Card, n int, rng *rand.Rand) showdownTally {
+	var rest [52]Card
+	m := 0
+	for _, c := range deck52 {
+		if !onBoard(c, board) {
+			rest[m] = c
+			m++
+		}
+	}
+	var hs, vs [7]Card
+	b := copy(hs[2:], board)
+	copy(vs[2:], board)
+	need := 5 - b
+	var t showdownTally
+	for s := 0; s < n; s++ {
+		var h, v [2]Card
+		for {
+			h, v = heroes[pick(hcum, rng)].Cards, villains[pick(vcum, rng)].Cards
+			if !conflict(h, v) {
+				break
+			}
+		}
+		hs[0], hs[1] = h[0], h[1]
+		vs[0], vs[1] = v[0], v[1]
+		// partial Fisher–Yates over the live cards, skipping the hole cards
+		k := 0
+		for top := 0; k < need; top++ {
+			j := top + rng.Intn(m-top)
+			rest[top], rest[j] = rest[j], rest[top]
+			if c := rest[top]; !holds(h, c) && !holds(v, c) {
+				hs[2+b+k], vs[2+b+k] = c, c
+				k++
+			}
+		}
+		t.record(Eval7(&hs), Eval7(&vs))
+	}
+	return t
+}
+
+// parallel runs job(0..n-1) on GOMAXPROCS workers and returns the results
+// in order. Job i gets its own generator seeded from seed and i.
+func parallel(n int, seed uint64, job func(i int, rng *rand.Rand) showdownTally) []showdownTally {
+	out := make([]showdownTally, n)
+	workers := min(runtime.GOMAXPROCS(0), n)
+	var next int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				i := next
+				next++
+				mu.Unlock()
+				if i >= n {
+					return
+				}
+				out[i] = job(i, rand.New(rand.NewSource(int64(chunkSeed(seed, i)))))
+			}
+		}()
+	}
+	wg.Wait()
+	return out
+}
+
+// chunkSeed mixes a call's seed with a chunk index (SplitMix64 finalizer),
+// so neighbouring seeds and chunks get unrelated streams.
+func chunkSeed(seed uint64, i int) uint64 {
+	z := seed + uint64(i+1)*0x9e3779b97f4a7c15
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
+
+func choose(n, k int) int {
+	if k < 0 || k > n {
+		return 0
+	}
+	r := 1
+	for i := 1; i <= k; i++ {
+		r = r * (n - k + i) / i
+	}
+	return r
+}
+
+// deck52 is the full deck in a fixed order; read only.
+var deck52 = fullDeck()
+
+func fullDeck() []Card {
+	deck := make([]Card, 0, 52)
+	for s := 0; s < 4; s++ {
+		for rnk := 2; rnk <= 14; rnk++ {
+			deck = append(deck, Card{Rank: rnk, Suit: "cdhs"[s]})
+		}
+	}
+	return deck
+}
+
+// deck36 is the short deck in the same order; read only.
+var deck36 = shortDeck()
+
+func shortDeck() []Card {
+	deck := make([]Card, 0, 36)
+	for _, c := range fullDeck() {
+		if c.Rank >= 6 {
+			deck = append(deck, c)
+		}
+	}
+	return deck
+}
+
+// HeadsUpEquity is hole a's share of the pot against hole b on a 0–5 card
+// board: wins plus half of ties over the runouts. With at most two board
+// cards missing every runout is enumerated; otherwise samples random runouts
+// are drawn from rng, so the caller controls reproducibility. Four-card
+// holes are scored under Omaha rules; Variant.Equity covers the short deck.
+func HeadsUpEquity(a, b, board []Card, samples int, rng *rand.Rand) float64 {
+	if len(a) != 2 {
+		return variantEquity(PLO, a, b, board, samples, rng)
+	}
+	ha, hb := [2]Card{a[0], a[1]}, [2]Card{b[0], b[1]}
+	var t showdownTally
+	if len(board) >= 3 {
+		t = enumerate(ha, hb, board)
+	} else {
+		t = sample(Range{{Cards: ha, Weight: 1}}, []float64{1}, Range{{Cards: hb, Weight: 1}}, []float64{1}, board, samples, rng)
+	}
+	n := t.win + t.tie + t.lose
+	if n == 0 {
+		return 0.5
+	}
+	return (t.win + t.tie/2) / n
+}
+
+// variantEquity is HeadsUpEquity for any variant, dealing the runouts from
+// v's deck and scoring them with v.Eval.
+func variantEquity(v Variant, a, b, board []Card, samples int, rng *rand.Rand) float64 {
+	var rest [52]Card
+	m := 0
+	for _, c := range v.cards() {
+		if !onBoard(c, a) && !onBoard(c, b) && !onBoard(c, board) {
+			rest[m] = c
+			m++
+		}
+	}
+	var full [5]Card
+	nb := copy(full[:], board)
+	need := 5 - nb
+	var t showdownTally
+	showdown := func() {
+		t.record(v.Eval(a, full[:]), v.Eval(b, full[:]))
+	}
+	switch {
+	case need == 0:
+		showdown()
+	case need <= 2:
+		var deal func(from, k int)
+		deal = func(from, k int) {
+			if k == need {
+				showdown()
+				return
+			}
+			for i := from; i < m; i++ {
+				full[nb+k] = rest[i]
+				deal(i+1, k+1)
+			}
+		}
+		deal(0, 0)
+	default:
+		for s := 0; s < samples; s++ {
+			for k := 0; k < need; k++ {
+				j := k + rng.Intn(m-k)
+				rest[k], rest[j] = rest[j], rest[k]
+				full[nb+k] = rest[k]
+			}
+			showdown()
+		}
+	}
+	n := t.win + t.tie + t.lose
+	if n == 0 {
+		return 0.5
+	}
+	return (t.win + t.tie/2) / n
+}
@@ -0,0 +1,310 @@
+package engine
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// HandRank scores the best five-card hand among up to seven cards; a larger
+// rank is a stronger hand. Bits 20–23 hold the Category and the low 20 bits
+// up to five ranks (4 bits each, 0 = deuce) that order hands within it.
+// Short-deck ranks also set bits 24 and up to the category's place in that
+// game's order, so only ranks from the same evaluator compare.
+type HandRank uint32
+
+// Category is the class of a five-card poker hand.
+type Category uint8
+
+const (
+	HighCard Category = iota
+	OnePair
+	TwoPair
+	Trips
+	Straight
+	Flush
+	FullHouse
+	Quads
+	StraightFlush
+)
+
+var categoryNames = [...]string{"High card", "One pair", "Two pair", "Three of a kind", "Straight", "Flush", "Full house", "Four of a kind", "Straight flush"}
+
+func (c Category) String() string {
+	if int(c) < len(categoryNames) {
+		return categoryNames[c]
+	}
+	return fmt.Sprintf("Category(%d)", c)
+}
+
+// Category is the hand class of r.
+func (r HandRank) Category() Category { return Category(r >> 20 & 0xF) }
+
+// Lookup tables over 13-bit rank masks (bit i = rank i+2).
+var (
+	// straightTop is 1 + the top rank index of the best straight in the
+	// mask (the five for a wheel), 0 without one.
+	straightTop [1 << 13]uint8
+	// topRanks packs the mask's five highest rank indexes into 4-bit
+	// fields, highest first in bits 16–19.
+	topRanks [1 << 13]uint32
+)
+
+func init() {
+	for m := 0; m < 1<<13; m++ {
+		for top := 12; top >= 4; top-- {
+			if run := 0x1F << (top - 4); m&run == run {
+				straightTop[m] = uint8(top + 1)
+				break
+			}
+		}
+		if straightTop[m] == 0 && m&0x100F == 0x100F { // A-2-3-4-5
+			straightTop[m] = 4
+		}
+		var packed uint32
+		rest := m
+		for k := 0; k < 5 && rest != 0; k++ {
+			hi := bits.Len16(uint16(rest)) - 1
+			packed |= uint32(hi) << (16 - 4*k)
+			rest &^= 1 << hi
+		}
+		topRanks[m] = packed
+	}
+}
+
+// suitIndex maps a suit letter to 0–3.
+func suitIndex(s byte) int {
+	switch s {
+	case 'c':
+		return 0
+	case 'd':
+		return 1
+	case 'h':
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Eval5, Eval6 and Eval7 rank five, six or seven cards.
+func Eval5(c *[5]Card) HandRank { return Evaluate(c[:]) }
+func Eval6(c *[6]Card) HandRank { return Evaluate(c[:]) }
+func Eval7(c *[7]Card) HandRank { return Evaluate(c[:]) }
+
+// Evaluate ranks the best five-card hand among cs (at most seven cards; with
+// fewer than five the missing cards simply never complete a hand). It does
+// not allocate.
+func Evaluate(cs []Card) HandRank { return evaluate(cs, false) }
+
+// shortDeckOrder is each category's strength in short-deck hold'em, where
+// a flush, being rarer with 36 cards, beats a full house.
+var shortDeckOrder = [...]HandRank{
+	HighCard: 0, OnePair: 1, TwoPair: 2, Trips: 3, Straight: 4,
+	FullHouse: 5, Flush: 6, Quads: 7, StraightFlush: 8,
+}
+
+// EvaluateShortDeck is Evaluate under short-deck rules: A-6-7-8-9 is the
+// lowest straight and a flush beats a full house. Its ranks compare only
+// with each other.
+func EvaluateShortDeck(cs []Card) HandRank {
+	r := evaluate(cs, true)
+	return r | shortDeckOrder[r.Category()]<<24
+}
+
+// straight is straightTop for m, adding the short deck's ace-low A-6-7-8-9
+// (nine high) when short.
+func straight(m uint16, short bool) uint8 {
+	if st := straightTop[m]; st != 0 || !short {
+		return st
+	}
+	if m&0x10F0 == 0x10F0 {
+		return 8
+	}
+	return 0
+}
+
+func evaluate(cs []Card, short bool) HandRank {
+	var suits [4]uint16
+	var counts [13]uint8
+	for _, c := range cs {
+		r := c.Rank - 2
+		suits[suitIndex(c.Suit)] |= 1 << r
+		counts[r]++
+	}
+	// With at most seven cards a flush rules out quads and full houses.
+	for _, m := range suits {
+		if bits.OnesCount16(m) >= 5 {
+			if st := straight(m, short); st != 0 {
+				return rank(StraightFlush, uint32(st-1)<<16)
+			}
+			return rank(Flush, topRanks[m])
+		}
+	}
+	all := suits[0] | suits[1] | suits[2] | suits[3]
+	var quads, trips, pairs uint16
+	for r, n := range counts {
+		switch n {
+		case 4:
+			quads |= 1 << r
+		case 3:
+			trips |= 1 << r
+		case 2:
+			pairs |= 1 << r
+		}
+	}
+	switch {
+	case quads != 0:
+		q := high(quads)
+		return rank(Quads, uint32(q)<<16|topRanks[all&^(1<<q)]>>4&0xF000)
+	case trips != 0 && (bits.OnesCount16(trips) > 1 || pairs != 0):
+		t := high(trips)
+		p := high(trips&^(1<<t) | pairs)
+		return rank(FullHouse, uint32(t)<<16|uint32(p)<<12)
+	}
+	if st := straight(all, short); st != 0 {
+		return rank(Straight, uint32(st-1)<<16)
+	}
+	switch {
+	case trips != 0:
+		t := high(trips)
+		return rank(Trips, uint32(t)<<16|topRanks[all&^(1<<t)]>>4&0xFF00)
+	case bits.OnesCount16(pairs) >= 2:
+		p1 := high(pairs)
+		p2 := high(pairs &^ (1 << p1))
+		return rank(TwoPair, uint32(p1)<<16|uint32(p2)<<12|topRanks[all&^(1<<p1|1<<p2)]>>8&0xF00)
+	case pairs != 0:
+		p := high(pairs)
+		return rank(OnePair, uint32(p)<<16|topRanks[all&^(1<<p)]>>4&0xFFF0)
+	}
+	return rank(HighCard, topRanks[all])
+}
+
+func rank(c Category, ranks uint32) HandRank { return HandRank(uint32(c)<<20 | ranks) }
+
+func high(m uint16) int { return bits.Len16(m) - 1 }
+
+var rankWords = [...]string{"Twos", "Threes", "Fours", "Fives", "Sixes", "Sevens", "Eights", "Nines", "Tens", "Jacks", "Queens", "Kings", "Aces"}
+var rankWord = [...]string{"Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine", "Ten", "Jack", "Queen", "King", "Ace"}
+
+// String describes the hand, e.g. "Full house, Kings full of Sevens".
+func (r HandRank) String() string {
+	f := func(k int) int { return int(r>>(16-4*k)) & 0xF }
+	c := r.Category()
+	switch c {
+	case StraightFlush, Straight:
+		return fmt.Sprintf("%s, %s high", c, rankWord[f(0)])
+	case Quads, Trips:
+		return fmt.Sprintf("%s, %s", c, rankWords[f(0)])
+	case FullHouse:
+		return fmt.Sprintf("%s, %s full of %s", c, rankWords[f(0)], rankWords[f(1)])
+	case TwoPair:
+		return fmt.Sprintf("%s, %s and %s", c, rankWords[f(0)], rankWords[f(1)])
+	case OnePair:
+		return fmt.Sprintf("%s, %s", c, rankWords[f(0)])
+	case Flush, HighCard:
+		return fmt.Sprintf("%s, %s high", c, rankWord[f(0)])
+	}
+	return c.String()
+}
+
+// evalHole ranks hole plus board without allocating.
+func evalHole(hole, board []Card) HandRank {
+	var buf [7]Card
+	n := copy(buf[:], hole)
+	n += copy(buf[n:], board)
+	return Evaluate(buf[:n])
+}
+
+// evalShortHole is evalHole under short-deck rules.
+func evalShortHole(hole, board []Card) HandRank {
+	var buf [7]Card
+	n := copy(buf[:], hole)
+	n += copy(buf[n:], board)
+	return EvaluateShortDeck(buf[:n])
+}
+
+// EvalOmaha ranks an Omaha hand: exactly two hole cards with exactly three
+// board cards (all of the board while it has fewer than three). It does not
+// allocate.
+func EvalOmaha(hole, board []Card) HandRank {
+	var best HandRank
+	var five [5]Card
+	for i := 0; i < len(hole); i++ {
+		for j := i + 1; j < len(hole); j++ {
+			five[0], five[1] = hole[i], hole[j]
+			if len(board) < 3 {
+				n := 2 + copy(five[2:], board)
+				best = max(best, Evaluate(five[:n]))
+				continue
+			}
+			for a := 0; a < len(board); a++ {
+				for b := a + 1; b < len(board); b++ {
+					for c := b + 1; c < len(board); c++ {
+						five[2], five[3], five[4] = board[a], board[b], board[c]
+						best = max(best, Eval5(&five))
+					}
+				}
+			}
+		}
+	}
+	return best
+}
+
+// Scores returns both seats' hand ranks (larger is better).
+func (h *Hand) Scores() (int, int) {
+	v := h.Cfg.Variant
+	return int(v.Eval(h.SB.Hole, h.Board)), int(v.Eval(h.BB.Hole, h.Board))
+}
+
+// EvalDebug describes both seats' best hands.
+func (h *Hand) EvalDebug() (sbDesc string, bbDesc string) {
+	v := h.Cfg.Variant
+	return v.Eval(h.SB.Hole, h.Board).String(), v.Eval(h.BB.Hole, h.Board).String()
+}
+
+// ParseCard parses a card such as "As" or "Td" (rank then suit, either case).
+func ParseCard(s string) (Card, error) {
+	s = strings.TrimSpace(s)
+	if len(s) != 2 {
+		return Card{}, fmt.Errorf("bad card %q", s)
+	}
+	r := strings.IndexByte("23456789TJQKA", upper(s[0]))
+	if r < 0 {
+		return Card{}, fmt.Errorf("bad card rank in %q", s)
+	}
+	suit := s[1] | 0x20 // lower case
+	if strings.IndexByte("cdhs", suit) < 0 {
+		return Card{}, fmt.Errorf("bad card suit in %q", s)
+	}
+	return Card{Rank: r + 2, Suit: suit}, nil
+}
+
+// ParseCards parses a list of cards written together ("Qs7d2c") or
+// separated by spaces or commas. Duplicates are an error.
+func ParseCards(s string) ([]Card, error) {
+	s = strings.NewReplacer(",", "", " ", "", "\t", "").Replace(s)
+	if len(s)%2 != 0 {
+		return nil, fmt.Errorf("bad card list %q", s)
+	}
+	out := make([]Card, 0, len(s)/2)
+	for i := 0; i < len(s); i += 2 {
+		c, err := ParseCard(s[i : i+2])
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range out {
+			if d == c {
+				return nil, fmt.Errorf("card %s appears twice", c)
+			}
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func upper(b byte) byte {
+	if b >= 'a' && b <= 'z' {
+		return b - 0x20
+	}
+	return b
+}
@@ -0,0 +1,390 @@
+package engine
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func cards(t testing.TB, ss ...string) []Card {
+	t.Helper()
+	out := make([]Card, len(ss))
+	for i, s := range ss {
+		c, err := ParseCard(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[i] = c
+	}
+	return out
+}
+
+// TestEval5Census checks every five-card hand against the known category
+// counts and the 7462 distinct hand values.
+func TestEval5Census(t *testing.T) {
+	want := map[Category]int{
+		StraightFlush: 40, Quads: 624, FullHouse: 3744, Flush: 5108, Straight: 10200,
+		Trips: 54912, TwoPair: 123552, OnePair: 1098240, HighCard: 1302540,
+	}
+	got := map[Category]int{}
+	distinct := map[HandRank]bool{}
+	var h [5]Card
+	d := deck52
+	for a := 0; a < 52; a++ {
+		for b := a + 1; b < 52; b++ {
+			for c := b + 1; c < 52; c++ {
+				for e := c + 1; e < 52; e++ {
+					for f := e + 1; f < 52; f++ {
+						h = [5]Card{d[a], d[b], d[c], d[e], d[f]}
+						r := Eval5(&h)
+						got[r.Category()]++
+						distinct[r] = true
+					}
+				}
+			}
+		}
+	}
+	for c, n := range want {
+		if got[c] != n {
+			t.Errorf("%s: %d hands, want %d", c, got[c], n)
+		}
+	}
+	if len(distinct) != 7462 {
+		t.Errorf("%d distinct values, want 7462", len(distinct))
+	}
+}
+
+// TestEval7MatchesBestSubset compares six- and seven-card ranks with the
+// best five-card subset.
+func TestEval7MatchesBestSubset(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for i := 0; i < 20000; i++ {
+		n := 6 + i%2
+		perm := rng.Perm(52)
+		hand := make([]Card, n)
+		for k := range hand {
+			hand[k] = deck52[perm[k]]
+		}
+		var best HandRank
+		var five [5]Card
+		for skip := 0; skip < 1<<n; skip++ {
+			if popcount(skip) != n-5 {
+				continue
+			}
+			k := 0
+			for j := 0; j < n; j++ {
+				if skip&(1<<j) == 0 {
+					five[k] = hand[j]
+					k++
+				}
+			}
+			best = max(best, Eval5(&five))
+		}
+		if got := Evaluate(hand); got != best {
+			t.Fatalf("%v: Evaluate=%v (%x), best subset=%v (%x)", hand, got, uint32(got), best, uint32(best))
+		}
+	}
+}
+
+func popcount(x int) int {
+	n := 0
+	for ; x != 0; x &= x - 1 {
+		n++
+	}
+	return n
+}
+
+func TestEvalOrdering(t *testing.T) {
+	ordered := [][]string{
+		{"5c", "4d", "3h", "2s", "Ac", "9d", "8d"}, // wheel
+		{"6c", "5d", "4h", "3s", "2c", "Kd", "Kh"}, // six-high straight beats a pair of kings too
+		{"Ah", "Kh", "9h", "5h", "2h", "Ks", "Kd"}, // flush over trips
+		{"Kc", "Kd", "Ks", "7h", "7d", "2c", "3d"}, // kings full
+		{"Ac", "Ad", "As", "2h", "2d", "Kc", "Qd"}, // aces full of twos
+		{"9c", "9d", "9s", "9h", "2d", "3c", "4d"}, // quads
+		{"5h", "4h", "3h", "2h", "Ah", "Kc", "Kd"}, // steel wheel
+		{"Ts", "Js", "Qs", "Ks", "As", "2c", "2d"}, // royal
+	}
+	var prev HandRank
+	for i, hs := range ordered {
+		r := Evaluate(cards(t, hs...))
+		if i > 0 && r <= prev {
+			t.Fatalf("%v (%v) should beat the previous hand (%v)", hs, r, prev)
+		}
+		prev = r
+	}
+	if got := Evaluate(cards(t, "Kc", "Kd", "Ks", "7h", "7d", "2c", "3d")).String(); got != "Full house, Kings full of Sevens" {
+		t.Fatalf("String = %q", got)
+	}
+	// Two pair kickers: the third pair's rank may play as the kicker.
+	a := Evaluate(cards(t, "Ac", "Ad", "Kc", "Kd", "Qc", "Qd", "2s"))
+	b := Evaluate(cards(t, "Ac", "Ad", "Kc", "Kd", "Jc", "Jd", "Ts"))
+	if a <= b {
+		t.Fatalf("AAKKQ should beat AAKKJ")
+	}
+}
+
+func TestEquity(t *testing.T) {
+	// AA vs KK preflop is about 82% and small enough to enumerate.
+	r := Equity(cards(t, "Ah", "As"), Range{{Cards: [2]Card{{Rank: 13, Suit: 'h'}, {Rank: 13, Suit: 's'}}, Weight: 1}}, nil, 0)
+	if !r.Exact || r.Runouts != 1712304 || math.Abs(r.Equity-0.8264) > 0.001 {
+		t.Fatalf("AA vs KK: %+v", r)
+	}
+	if math.Abs(r.Win+r.Tie+r.Lose-1) > 1e-9 {
+		t.Fatalf("fractions do not sum to 1: %+v", r)
+	}
+	// On the turn against any two cards the answer is exact.
+	board := cards(t, "Kc", "7d", "2h", "9s")
+	r = Equity(cards(t, "Kd", "Qs"), AnyTwo(), board, 0)
+	if !r.Exact || r.Combos != 1035 || r.Runouts != 1035*44 {
+		t.Fatalf("turn vs any two: %+v", r)
+	}
+	// A wide preflop range is sampled, reproducibly from its seed.
+	r1 := Equity(cards(t, "7c", "7d"), AnyTwo(), nil, 50000)
+	r2 := RangeEquitySeed(MustParseRange("7c7d"), AnyTwo(), nil, 50000, r1.Seed)
+	if r1.Exact || r1 != r2 {
+		t.Fatalf("sampled equity not reproducible: %+v vs %+v", r1, r2)
+	}
+}
+
+func BenchmarkEval7(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	hands := make([][7]Card, 1024)
+	for i := range hands {
+		perm := rng.Perm(52)
+		for k := 0; k < 7; k++ {
+			hands[i][k] = deck52[perm[k]]
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Eval7(&hands[i&1023])
+	}
+}
+
+func BenchmarkEval5(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	hands := make([][5]Card, 1024)
+	for i := range hands {
+		perm := rng.Perm(52)
+		for k := 0; k < 5; k++ {
+			hands[i][k] = deck52[perm[k]]
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Eval5(&hands[i&1023])
+	}
+}
+
+func BenchmarkEquityFlopVsAnyTwo(b *testing.B) {
+	hero := cards(b, "Ah", "Kh")
+	board := cards(b, "Qh", "7h", "2c")
+	villain := AnyTwo()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Equity(hero, villain, board, 0)
+	}
+}
+
+func BenchmarkEquityPreflopMC(b *testing.B) {
+	hero := cards(b, "Ah", "Kh")
+	villain := AnyTwo()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Equity(hero, villain, nil, 100000)
+	}
+}
+
+func TestRangeEquity(t *testing.T) {
+	r := RangeEquity(MustParseRange("AA"), MustParseRange("KK"), nil, 100000)
+	if r.Exact || r.HeroCombos != 6 || r.Combos != 6 || math.Abs(r.Equity-0.82) > 0.01 || r.StdErr <= 0 {
+		t.Fatalf("AA vs KK: %+v", r)
+	}
+	// A one-combo hero range is the same as Equity.
+	board := cards(t, "Qh", "7h", "2c", "Td")
+	hero := cards(t, "Ah", "Kh")
+	villain := MustParseRange("TT+, AQs+, KQ")
+	a := Equity(hero, villain, board, 0)
+	b := RangeEquity(MustParseRange("AhKh"), villain, board, 0)
+	if !a.Exact || a != b {
+		t.Fatalf("Equity %+v != RangeEquity %+v", a, b)
+	}
+	var hs, vs float64
+	for c := range a.HeroHands {
+		hs += a.HeroHands[c]
+		vs += a.VillainHands[c]
+	}
+	if math.Abs(hs-1) > 1e-9 || math.Abs(vs-1) > 1e-9 {
+		t.Fatalf("hand categories sum to %.6f and %.6f", hs, vs)
+	}
+	// Ranges that cannot meet give an empty result.
+	if r := RangeEquity(MustParseRange("AhKh"), MustParseRange("AhQh"), nil, 0); r.Runouts != 0 {
+		t.Fatalf("blocked ranges: %+v", r)
+	}
+}
+
+func TestEvalOmaha(t *testing.T) {
+	// Four hearts on board but only one in hand: no flush, sevens play.
+	r := EvalOmaha(cards(t, "Ah", "Qs", "7c", "7d"), cards(t, "2h", "5h", "9h", "Jh", "Kc"))
+	if r.Category() != OnePair || r.String() != "One pair, Sevens" {
+		t.Fatalf("got %v", r)
+	}
+	// A four-card straight on board needs two connecting hole cards.
+	r = EvalOmaha(cards(t, "9c", "Ac", "Ad", "2s"), cards(t, "5c", "6d", "7h", "8s", "Kd"))
+	if r.String() != "One pair, Aces" {
+		t.Fatalf("got %v", r)
+	}
+	if got := Evaluate(cards(t, "9c", "Ac", "5c", "6d", "7h", "8s", "Kd")).Category(); got != Straight {
+		t.Fatalf("hold'em should see the straight, got %v", got)
+	}
+
+	a := cards(t, "Ah", "Kh", "Qd", "Jd")
+	b := cards(t, "9s", "9c", "8s", "7c")
+	board := cards(t, "Td", "9h", "2c")
+	ab := HeadsUpEquity(a, b, board, 0, nil)
+	ba := HeadsUpEquity(b, a, board, 0, nil)
+	if math.Abs(ab+ba-1) > 1e-9 || ab <= 0 || ab >= 1 {
+		t.Fatalf("equities %.4f and %.4f", ab, ba)
+	}
+}
+
+func TestPLOHand(t *testing.T) {
+	h := NewHand("t", Config{SB: 50, BB: 100, StartStack: 1000, Variant: PLO}, NewDeck(3))
+	if len(h.SB.Hole) != 4 || len(h.BB.Hole) != 4 || len(h.Deck) != 44 {
+		t.Fatalf("dealt %d and %d hole cards, %d left", len(h.SB.Hole), len(h.BB.Hole), len(h.Deck))
+	}
+	for i := 0; i < 3; i++ {
+		h.NextStreet()
+	}
+	want := Seat("")
+	sb, bb := EvalOmaha(h.SB.Hole, h.Board), EvalOmaha(h.BB.Hole, h.Board)
+	if sb > bb {
+		want = SB
+	} else if bb > sb {
+		want = BB
+	}
+	if got := h.Showdown(); got != want {
+		t.Fatalf("showdown %q, want %q", got, want)
+	}
+}
+
+func TestFixedLimitHand(t *testing.T) {
+	h := NewHand("t", Config{SB: 50, BB: 100, StartStack: 10000, Betting: FixedLimit}, NewDeck(5))
+	// Preflop the big blind is the first bet; raises are one small bet each
+	// whatever amount is asked for, and the fourth bet caps the street.
+	for i, to := range []int{200, 300, 400} {
+		if got := h.MaxRaiseTo(h.ToAct); got != to {
+			t.Fatalf("raise %d: max raise to %d, want %d", i, got, to)
+		}
+		if err := h.Apply(Raise, 5000); err != nil {
+			t.Fatal(err)
+		}
+		if h.CurBet != to {
+			t.Fatalf("raise %d: current bet %d, want %d", i, h.CurBet, to)
+		}
+	}
+	for _, k := range h.Legal() {
+		if k == Raise {
+			t.Fatalf("raise legal after %d bets", h.Bets)
+		}
+	}
+	if err := h.Apply(Raise, 500); err == nil {
+		t.Fatal("raise past the cap accepted")
+	}
+	if err := h.Apply(Call, 0); err != nil {
+		t.Fatal(err)
+	}
+	h.NextStreet()
+	if h.Bets != 0 || h.CurBet+h.MinRaise != 100 {
+		t.Fatalf("flop: %d bets, min raise to %d", h.Bets, h.CurBet+h.MinRaise)
+	}
+	h.NextStreet()
+	if got := h.MaxRaiseTo(h.ToAct); got != 200 {
+		t.Fatalf("turn bet to %d, want the big bet 200", got)
+	}
+}
+
+func TestEvaluateShortDeck(t *testing.T) {
+	wheel := cards(t, "Ac", "6d", "7h", "8s", "9c", "Kd", "Kh")
+	if r := EvaluateShortDeck(wheel); r.Category() != Straight || r.String() != "Straight, Nine high" {
+		t.Fatalf("A-6-7-8-9 = %v", r)
+	}
+	if r := Evaluate(wheel); r.Category() != OnePair {
+		t.Fatalf("A-6-7-8-9 in a full deck = %v", r)
+	}
+	six := EvaluateShortDeck(cards(t, "6c", "7d", "8h", "9s", "Tc", "Kd", "Kh"))
+	if six <= EvaluateShortDeck(wheel) {
+		t.Fatalf("T-high straight %v does not beat the ace-low one", six)
+	}
+	flush := cards(t, "Ah", "Jh", "9h", "7h", "6h", "Ks", "Qd")
+	boat := cards(t, "Ks", "Kd", "Kh", "7c", "7d", "Ac", "9s")
+	if EvaluateShortDeck(flush) <= EvaluateShortDeck(boat) {
+		t.Fatal("short deck: full house beats a flush")
+	}
+	if Evaluate(flush) >= Evaluate(boat) {
+		t.Fatal("full deck: flush beats a full house")
+	}
+	if c := EvaluateShortDeck(flush).Category(); c != Flush {
+		t.Fatalf("flush category = %v", c)
+	}
+	quads := cards(t, "9s", "9d", "9h", "9c", "6d", "Ac", "Ks")
+	if EvaluateShortDeck(quads) <= EvaluateShortDeck(flush) {
+		t.Fatal("short deck: flush beats quads")
+	}
+}
+
+func TestShortDeckHand(t *testing.T) {
+	deck := ShortDeck.NewDeck(7)
+	if len(deck) != 36 {
+		t.Fatalf("short deck has %d cards", len(deck))
+	}
+	for _, c := range deck {
+		if c.Rank < 6 {
+			t.Fatalf("short deck holds %v", c)
+		}
+	}
+	h := NewHand("t", Config{SB: 50, BB: 100, StartStack: 1000, Variant: ShortDeck}, deck)
+	for i := 0; i < 3; i++ {
+		h.NextStreet()
+	}
+	want := Seat("")
+	seven := func(hole []Card) []Card { return append(append([]Card{}, hole...), h.Board...) }
+	sb, bb := EvaluateShortDeck(seven(h.SB.Hole)), EvaluateShortDeck(seven(h.BB.Hole))
+	if sb > bb {
+		want = SB
+	} else if bb > sb {
+		want = BB
+	}
+	if got := h.Showdown(); got != want {
+		t.Fatalf("showdown %q, want %q", got, want)
+	}
+	a, b := cards(t, "Ah", "Kh"), cards(t, "7c", "7d")
+	rng := rand.New(rand.NewSource(1))
+	eq := ShortDeck.Equity(a, b, cards(t, "Qh", "Jh", "6s"), 0, rng)
+	if back := ShortDeck.Equity(b, a, cards(t, "Qh", "Jh", "6s"), 0, rng); math.Abs(eq+back-1) > 1e-9 {
+		t.Fatalf("equities %v and %v do not sum to 1", eq, back)
+	}
+	if eq < 0.5 {
+		t.Fatalf("AhKh on QhJh6s has %v against sevens", eq)
+	}
+}
+
+func TestRangeEquitySeed(t *testing.T) {
+	hero, villain := MustParseRange("AKs"), MustParseRange("22+, A2s+, KTs+")
+	a := RangeEquitySeed(hero, villain, nil, 20000, 42)
+	b := RangeEquitySeed(hero, villain, nil, 20000, 42)
+	if a.Exact || a.Seed != 42 || a != b {
+		t.Fatalf("seed 42 twice: %+v vs %+v", a, b)
+	}
+	if c := RangeEquitySeed(hero, villain, nil, 20000, 43); c.Equity == a.Equity {
+		t.Fatalf("seeds 42 and 43 sampled the same deals: %v", c.Equity)
+	}
+	// An unseeded call reports the seed that repeats it.
+	r := RangeEquity(hero, villain, nil, 20000)
+	if again := RangeEquitySeed(hero, villain, nil, 20000, r.Seed); again != r {
+		t.Fatalf("RangeEquity seed %d does not repeat: %+v vs %+v", r.Seed, r, again)
+	}
+}
@@ -0,0 +1,322 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseRange parses standard range notation: comma- or space-separated
+// terms such as "AA", "AKs", "AKo", "AK" (all 16 combos), "TT+", "ATs+",
+// "22-55", "A5s-A2s", a single combo like "AhKh", or "any". A term may end
+// in ":w" to give its combos weight w in (0, 1]. When terms overlap the
+// higher weight wins.
+func ParseRange(s string) (Range, error) {
+	acc := map[[2]Card]float64{}
+	terms := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' })
+	for _, term := range terms {
+		if err := parseTerm(term, acc); err != nil {
+			return nil, err
+		}
+	}
+	return fromWeights(acc), nil
+}
+
+// MustParseRange is ParseRange for literals known to be valid.
+func MustParseRange(s string) Range {
+	r, err := ParseRange(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func parseTerm(term string, acc map[[2]Card]float64) error {
+	w := 1.0
+	if i := strings.IndexByte(term, ':'); i >= 0 {
+		v, err := strconv.ParseFloat(term[i+1:], 64)
+		if err != nil || v <= 0 || v > 1 {
+			return fmt.Errorf("bad weight in range term %q", term)
+		}
+		term, w = term[:i], v
+	}
+	add := func(c [2]Card) {
+		c = normCombo(c)
+		acc[c] = max(acc[c], w)
+	}
+	if strings.EqualFold(term, "any") || strings.EqualFold(term, "random") {
+		for _, c := range AnyTwo() {
+			add(c.Cards)
+		}
+		return nil
+	}
+	// A specific combo such as "AhKh".
+	if len(term) == 4 && strings.IndexByte("cdhsCDHS", term[1]) >= 0 {
+		a, err1 := ParseCard(term[:2])
+		b, err2 := ParseCard(term[2:])
+		if err1 != nil || err2 != nil || a == b {
+			return fmt.Errorf("bad combo %q", term)
+		}
+		add([2]Card{a, b})
+		return nil
+	}
+	hands, err := expandClasses(term)
+	if err != nil {
+		return err
+	}
+	for _, h := range hands {
+		for _, c := range h.combos() {
+			add(c)
+		}
+	}
+	return nil
+}
+
+// handClass is a starting-hand class: a pair (hi == lo), or two ranks that
+// are suited, offsuit or either (kind 's', 'o' or 0).
+type handClass struct {
+	hi, lo int
+	kind   byte
+}
+
+func parseClass(s string) (handClass, error) {
+	if len(s) < 2 || len(s) > 3 {
+		return handClass{}, fmt.Errorf("bad hand class %q", s)
+	}
+	a := strings.IndexByte("23456789TJQKA", upper(s[0]))
+	b := strings.IndexByte("23456789TJQKA", upper(s[1]))
+	if a < 0 || b < 0 {
+		return handClass{}, fmt.Errorf("bad hand class %q", s)
+	}
+	h := handClass{hi: max(a, b) + 2, lo: min(a, b) + 2}
+	if len(s) == 3 {
+		h.kind = s[2] | 0x20
+		if h.kind != 's' && h.kind != 'o' {
+			return handClass{}, fmt.Errorf("bad suitedness in %q", s)
+		}
+		if h.hi == h.lo {
+			return handClass{}, fmt.Errorf("a pair cannot be suited or offsuit: %q", s)
+		}
+	}
+	return h, nil
+}
+
+// expandClasses handles "X", "X+" and "X-Y".
+func expandClasses(term string) ([]handClass, error) {
+	if from, to, ok := strings.Cut(term, "-"); ok {
+		a, err := parseClass(from)
+		if err != nil {
+			return nil, err
+		}
+		b, err := parseClass(to)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case a.hi == a.lo && b.hi == b.lo:
+			var out []handClass
+			for r := min(a.hi, b.hi); r <= max(a.hi, b.hi); r++ {
+				out = append(out, handClass{hi: r, lo: r})
+			}
+			return out, nil
+		case a.hi == b.hi && a.kind == b.kind && a.hi != a.lo && b.hi != b.lo:
+			var out []handClass
+			for r := min(a.lo, b.lo); r <= max(a.lo, b.lo); r++ {
+				out = append(out, handClass{hi: a.hi, lo: r, kind: a.kind})
+			}
+			return out, nil
+		}
+		return nil, fmt.Errorf("range %q must span pairs or share a top card", term)
+	}
+	if base, ok := strings.CutSuffix(term, "+"); ok {
+		h, err := parseClass(base)
+		if err != nil {
+			return nil, err
+		}
+		var out []handClass
+		if h.hi == h.lo {
+			for r := h.hi; r <= 14; r++ {
+				out = append(out, handClass{hi: r, lo: r})
+			}
+			return out, nil
+		}
+		for r := h.lo; r < h.hi; r++ {
+			out = append(out, handClass{hi: h.hi, lo: r, kind: h.kind})
+		}
+		return out, nil
+	}
+	h, err := parseClass(term)
+	if err != nil {
+		return nil, err
+	}
+	return []handClass{h}, nil
+}
+
+func (h handClass) combos() [][2]Card {
+	const suits = "cdhs"
+	var out [][2]Card
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if h.hi == h.lo && j <= i {
+				continue
+			}
+			if (h.kind == 's' && i != j) || (h.kind == 'o' && i == j) {
+				continue
+			}
+			out = append(out, [2]Card{{Rank: h.hi, Suit: suits[i]}, {Rank: h.lo, Suit: suits[j]}})
+		}
+	}
+	return out
+}
+
+// normCombo puts the higher card first (by rank, then suit) so each holding
+// has one key.
+func normCombo(c [2]Card) [2]Card {
+	if c[1].Rank > c[0].Rank || (c[1].Rank == c[0].Rank && c[1].Suit > c[0].Suit) {
+		c[0], c[1] = c[1], c[0]
+	}
+	return c
+}
+
+func (r Range) weights() map[[2]Card]float64 {
+	m := make(map[[2]Card]float64, len(r))
+	for _, c := range r {
+		k := normCombo(c.Cards)
+		m[k] = max(m[k], c.Weight)
+	}
+	return m
+}
+
+// fromWeights lists the positive-weight combos strongest class first, in a
+// fixed order.
+func fromWeights(m map[[2]Card]float64) Range {
+	out := make(Range, 0, len(m))
+	for k, w := range m {
+		if w > 0 {
+			out = append(out, Combo{Cards: k, Weight: w})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].Cards, out[j].Cards
+		if a[0].Rank != b[0].Rank {
+			return a[0].Rank > b[0].Rank
+		}
+		if a[1].Rank != b[1].Rank {
+			return a[1].Rank > b[1].Rank
+		}
+		if a[0].Suit != b[0].Suit {
+			return a[0].Suit < b[0].Suit
+		}
+		return a[1].Suit < b[1].Suit
+	})
+	return out
+}
+
+// Add is the union of r and o; a combo in both keeps the higher weight.
+func (r Range) Add(o Range) Range {
+	m := r.weights()
+	for k, w := range o.weights() {
+		m[k] = max(m[k], w)
+	}
+	return fromWeights(m)
+}
+
+// Sub removes o from r, lowering each shared combo's weight by o's weight.
+func (r Range) Sub(o Range) Range {
+	m := r.weights()
+	for k, w := range o.weights() {
+		if _, ok := m[k]; ok {
+			m[k] -= w
+		}
+	}
+	return fromWeights(m)
+}
+
+// Remove drops combos that use any of the dead cards (hole cards or board).
+func (r Range) Remove(dead ...Card) Range {
+	out := make(Range, 0, len(r))
+	for _, c := range r {
+		live := true
+		for _, d := range dead {
+			if c.Cards[0] == d || c.Cards[1] == d {
+				live = false
+				break
+			}
+		}
+		if live {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Count is the number of combos regardless of weight.
+func (r Range) Count() int { return len(r) }
+
+// Combos is the weighted combo count, e.g. 6 for "AA" and 3 for "AA:0.5".
+func (r Range) Combos() float64 {
+	total := 0.0
+	for _, c := range r {
+		total += c.Weight
+	}
+	return total
+}
+
+// Fraction is the share of all 1326 starting hands that r covers, weighted.
+func (r Range) Fraction() float64 { return r.Combos() / 1326 }
+
+// String writes r back in notation, one term per hand class: "AKs" when a
+// class is complete at one weight, its single combos otherwise. Weights
+// below 1 get a ":w" suffix.
+func (r Range) String() string {
+	m := r.weights()
+	var terms []string
+	seen := map[[2]Card]bool{}
+	for _, c := range fromWeights(m) {
+		if seen[c.Cards] {
+			continue
+		}
+		h := handClass{hi: c.Cards[0].Rank, lo: c.Cards[1].Rank}
+		if h.hi != h.lo {
+			h.kind = 'o'
+			if c.Cards[0].Suit == c.Cards[1].Suit {
+				h.kind = 's'
+			}
+		}
+		all := h.combos()
+		whole := true
+		for _, k := range all {
+			if m[normCombo(k)] != c.Weight {
+				whole = false
+				break
+			}
+		}
+		if whole {
+			for _, k := range all {
+				seen[normCombo(k)] = true
+			}
+			terms = append(terms, h.String()+weightSuffix(c.Weight))
+			continue
+		}
+		seen[c.Cards] = true
+		terms = append(terms, c.Cards[0].String()+c.Cards[1].String()+weightSuffix(c.Weight))
+	}
+	return strings.Join(terms, ", ")
+}
+
+func (h handClass) String() string {
+	const ranks = "  23456789TJQKA"
+	s := string([]byte{ranks[h.hi], ranks[h.lo]})
+	if h.kind != 0 {
+		s += string(h.kind)
+	}
+	return s
+}
+
+func weightSuffix(w float64) string {
+	if w >= 1 {
+		return ""
+	}
+	return ":" + strconv.FormatFloat(w, 'g', 4, 64)
+}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseRangeCounts(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int
+	}{
+		{"AA", 6},
+		{"AKs", 4},
+		{"AKo", 12},
+		{"AK", 16},
+		{"TT+", 30},
+		{"22-55", 24},
+		{"55-22", 24},
+		{"A5s-A2s", 16},
+		{"ATs+", 16},
+		{"KQo", 12},
+		{"AhKh", 1},
+		{"TT+, AKs, A5s-A2s, KQo, 22-55", 30 + 4 + 16 + 12 + 24},
+		{"AA, AA, AhAd", 6},
+		{"any", 1326},
+	}
+	for _, c := range cases {
+		r, err := ParseRange(c.in)
+		if err != nil {
+			t.Fatalf("%q: %v", c.in, err)
+		}
+		if r.Count() != c.want {
+			t.Errorf("%q: %d combos, want %d", c.in, r.Count(), c.want)
+		}
+	}
+	for _, bad := range []string{"AKx", "A1s", "AA:2", "AKs-QJs", "AAs", "AhAh", "KK-AKs"} {
+		if _, err := ParseRange(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestRangeAlgebra(t *testing.T) {
+	r := MustParseRange("QQ+, AKs, AK:0.5")
+	// AKs is named at full weight, so it beats the half-weight AK term.
+	if got := r.Combos(); math.Abs(got-(18+4+6)) > 1e-9 {
+		t.Fatalf("weighted combos %.2f, want 28", got)
+	}
+	less := r.Sub(MustParseRange("AA"))
+	if less.Count() != r.Count()-6 {
+		t.Fatalf("Sub: %d combos, want %d", less.Count(), r.Count()-6)
+	}
+	if back := less.Add(MustParseRange("AA")); back.String() != r.String() {
+		t.Fatalf("Add(Sub) = %q, want %q", back, r)
+	}
+	half := r.Sub(MustParseRange("KK:0.5"))
+	if got := r.Combos() - half.Combos(); math.Abs(got-3) > 1e-9 {
+		t.Fatalf("partial Sub removed %.2f combos, want 3", got)
+	}
+	// Card removal: an ace on board leaves 3 AA combos and 3 of each AK.
+	board := cards(t, "As", "7d", "2c")
+	live := MustParseRange("AA, AKs").Remove(board...)
+	if live.Count() != 3+3 {
+		t.Fatalf("Remove: %d combos, want 6", live.Count())
+	}
+}
+
+func TestRangeString(t *testing.T) {
+	for in, want := range map[string]string{
+		"AKs, QQ":       "AKs, QQ",
+		"AK":            "AKs, AKo",
+		"JJ:0.25, AhKh": "AhKh, JJ:0.25",
+		"KK, KhKd:0.5":  "KK",
+		"A5s-A3s:0.5":   "A5s:0.5, A4s:0.5, A3s:0.5",
+	} {
+		if got := MustParseRange(in).String(); got != want {
+			t.Errorf("%q: String = %q, want %q", in, got, want)
+		}
+	}
+	r := MustParseRange("KK").Sub(MustParseRange("KhKd"))
+	if got := r.String(); got != "KdKc, KhKc, KsKc, KsKd, KsKh" {
+		t.Errorf("partial class: %q", got)
+	}
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+// DeckKey is the secret behind a match's verifiable shuffles. Before play
+// only its Commitment is published; revealing the key afterwards lets
+// anyone recompute every deck with VerifiableDeck and check it against the
+// commitment.
+//
+// The scheme, stable so it can be reimplemented outside this repo:
+//
+//   - commitment = hex(SHA-256(key)), the key being 32 bytes;
+//   - the deck for pair p (1-based) is seeded with
+//     SHA-256(key || uint64 big-endian p) as a ChaCha8Rand generator
+//     (https://c2sp.org/chacha8rand), whose 64-bit outputs drive a
+//     Fisher–Yates shuffle of the unshuffled deck (clubs, diamonds, hearts,
+//     spades, each deuce to ace; sixes up for the short deck): for i from
+//     n-1 down to 1, draw x until x ≥ 2^64 mod (i+1), then swap cards i
+//     and x mod (i+1);
+//   - hands then deal from the top (index 0): SB's hole, BB's hole,
+//     flop, turn, river, with no burn cards.
+type DeckKey [32]byte
+
+// NewDeckKey draws a fresh key from the OS's secure random source.
+func NewDeckKey() (DeckKey, error) {
+	var k DeckKey
+	_, err := crand.Read(k[:])
+	return k, err
+}
+
+// DeckKeyFromSeed derives a key from a fixed deck seed so a seeded run
+// deals the same cards again. Such a key is only as secret as the seed.
+func DeckKeyFromSeed(seed uint64) DeckKey {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], seed)
+	return sha256.Sum256(append([]byte("ai-thunderdome deck seed "), b[:]...))
+}
+
+// ParseDeckKey reads a key written by DeckKey.String.
+func ParseDeckKey(s string) (DeckKey, error) {
+	var k DeckKey
+	b, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(b) != len(k) {
+		return k, fmt.Errorf("deck key must be %d hex characters", 2*len(k))
+	}
+	copy(k[:], b)
+	return k, nil
+}
+
+// String is the key in lowercase hex.
+func (k DeckKey) String() string { return hex.EncodeToString(k[:]) }
+
+// Commitment is the published hash of the key: lowercase hex SHA-256.
+func (k DeckKey) Commitment() string {
+	sum := sha256.Sum256(k[:])
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifiableDeck is the variant's deck for pair under key; see DeckKey for
+// the algorithm. Both hands of a mirrored pair use the same deck.
+func (v Variant) VerifiableDeck(key DeckKey, pair int) []Card {
+	var in [len(key) + 8]byte
+	copy(in[:], key[:])
+	binary.BigEndian.PutUint64(in[len(key):], uint64(pair))
+	src := rand.NewChaCha8(sha256.Sum256(in[:]))
+
+	deck := append([]Card(nil), v.cards()...)
+	for i := len(deck) - 1; i > 0; i-- {
+		n := uint64(i + 1)
+		floor := -n % n // 2^64 mod n; rejecting below it keeps x mod n uniform
+		x := src.Uint64()
+		for x < floor {
+			x = src.Uint64()
+		}
+		j := x % n
+		deck[i], deck[j] = deck[j], deck[i]
+	}
+	return deck
+}
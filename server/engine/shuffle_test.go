@@ -0,0 +1,59 @@
+package engine
+
+import "testing"
+
+func TestVerifiableDeck(t *testing.T) {
+	var zero DeckKey
+	if got := zero.Commitment(); got != "66687aadf862bd776c8fc18b8e9f8e20089714856ee233b3902a591d0d5f2925" {
+		t.Fatalf("commitment of the zero key = %s", got)
+	}
+	// Pinned so any change to the published algorithm fails loudly.
+	if got := Holdem.VerifiableDeck(zero, 1); cardsString(got[:6]) != "6h 4c 6c Kh 7d Tc" {
+		t.Fatalf("zero key, pair 1 deals %s", cardsString(got[:6]))
+	}
+	if got := ShortDeck.VerifiableDeck(zero, 2); len(got) != 36 || cardsString(got[:4]) != "Ac Jh Jd Qd" {
+		t.Fatalf("zero key, short deck pair 2 deals %d cards: %s", len(got), cardsString(got[:4]))
+	}
+
+	key := DeckKeyFromSeed(42)
+	if back, err := ParseDeckKey(key.String()); err != nil || back != key {
+		t.Fatalf("ParseDeckKey(%s) = %v, %v", key, back, err)
+	}
+	if _, err := ParseDeckKey("abc"); err == nil {
+		t.Fatal("short key accepted")
+	}
+	a, b := Holdem.VerifiableDeck(key, 3), Holdem.VerifiableDeck(key, 3)
+	if cardsString(a) != cardsString(b) {
+		t.Fatal("same key and pair dealt different decks")
+	}
+	if cardsString(a) == cardsString(Holdem.VerifiableDeck(key, 4)) {
+		t.Fatal("pairs 3 and 4 dealt the same deck")
+	}
+	seen := map[Card]bool{}
+	for _, c := range a {
+		seen[c] = true
+	}
+	if len(a) != 52 || len(seen) != 52 {
+		t.Fatalf("deck has %d cards, %d distinct", len(a), len(seen))
+	}
+
+	// The documented deal order: SB's hole, BB's hole, then the board.
+	h := NewHand("t", Config{SB: 50, BB: 100, StartStack: 1000}, append([]Card(nil), a...))
+	for i := 0; i < 3; i++ {
+		h.NextStreet()
+	}
+	if got := cardsString(append(append(h.SB.Hole, h.BB.Hole...), h.Board...)); got != cardsString(a[:9]) {
+		t.Fatalf("hand dealt %s from a deck starting %s", got, cardsString(a[:9]))
+	}
+}
+
+func cardsString(cs []Card) string {
+	s := ""
+	for i, c := range cs {
+		if i > 0 {
+			s += " "
+		}
+		s += c.String()
+	}
+	return s
+}
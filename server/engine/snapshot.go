@@ -0,0 +1,256 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Clone returns a deep copy of h: applying actions or dealing streets on
+// either one never shows through in the other.
+func (h *Hand) Clone() *Hand {
+	c := *h
+	c.Deck = slices.Clone(h.Deck)
+	c.Board = slices.Clone(h.Board)
+	c.History = slices.Clone(h.History)
+	c.SB, c.BB = h.SB.clone(), h.BB.clone()
+	return &c
+}
+
+func (p *Player) clone() *Player {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	c.Hole = slices.Clone(p.Hole)
+	return &c
+}
+
+// handJSON is a Hand on the wire. Cards are written as strings such as
+// "As"; a bare Card still encodes as its struct.
+type handJSON struct {
+	ID          string      `json:"id"`
+	Config      configJSON  `json:"config"`
+	Deck        []string    `json:"deck"`
+	Board       []string    `json:"board"`
+	Pot         int         `json:"pot"`
+	Street      string      `json:"street"`
+	SB          *playerJSON `json:"sb"`
+	BB          *playerJSON `json:"bb"`
+	ToAct       Seat        `json:"to_act"`
+	CurBet      int         `json:"cur_bet"`
+	MinRaise    int         `json:"min_raise"`
+	Bets        int         `json:"bets"`
+	History     []Action    `json:"history"`
+	StreetStart int         `json:"street_start"`
+}
+
+type configJSON struct {
+	SB         int     `json:"sb"`
+	BB         int     `json:"bb"`
+	StartStack int     `json:"start_stack"`
+	Variant    Variant `json:"variant,omitempty"`
+	Betting    Betting `json:"betting,omitempty"`
+	SmallBet   int     `json:"small_bet,omitempty"`
+	BigBet     int     `json:"big_bet,omitempty"`
+	RaiseCap   int     `json:"raise_cap,omitempty"`
+}
+
+type playerJSON struct {
+	Seat      Seat     `json:"seat"`
+	Stack     int      `json:"stack"`
+	Committed int      `json:"committed"`
+	Hole      []string `json:"hole"`
+	Folded    bool     `json:"folded,omitempty"`
+	AllIn     bool     `json:"all_in,omitempty"`
+}
+
+func cardStrings(cs []Card) []string {
+	out := make([]string, len(cs))
+	for i, c := range cs {
+		out[i] = c.String()
+	}
+	return out
+}
+
+func parseCardStrings(ss []string) ([]Card, error) {
+	out := make([]Card, len(ss))
+	for i, s := range ss {
+		c, err := ParseCard(s)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = c
+	}
+	return out, nil
+}
+
+func (p *Player) toJSON() *playerJSON {
+	if p == nil {
+		return nil
+	}
+	return &playerJSON{Seat: p.Seat, Stack: p.Stack, Committed: p.Committed, Hole: cardStrings(p.Hole), Folded: p.Folded, AllIn: p.AllIn}
+}
+
+func (p *playerJSON) player() (*Player, error) {
+	if p == nil {
+		return nil, nil
+	}
+	hole, err := parseCardStrings(p.Hole)
+	if err != nil {
+		return nil, fmt.Errorf("%s hole: %w", p.Seat, err)
+	}
+	return &Player{Seat: p.Seat, Stack: p.Stack, Committed: p.Committed, Hole: hole, Folded: p.Folded, AllIn: p.AllIn}, nil
+}
+
+// MarshalJSON writes the whole hand, undealt deck included.
+func (h Hand) MarshalJSON() ([]byte, error) {
+	c := h.Cfg
+	return json.Marshal(handJSON{
+		ID: h.ID,
+		Config: configJSON{SB: c.SB, BB: c.BB, StartStack: c.StartStack, Variant: c.Variant, Betting: c.Betting,
+			SmallBet: c.SmallBet, BigBet: c.BigBet, RaiseCap: c.RaiseCap},
+		Deck: cardStrings(h.Deck), Board: cardStrings(h.Board), Pot: h.Pot, Street: h.Street,
+		SB: h.SB.toJSON(), BB: h.BB.toJSON(), ToAct: h.ToAct,
+		CurBet: h.CurBet, MinRaise: h.MinRaise, Bets: h.Bets, History: h.History, StreetStart: h.StreetStart,
+	})
+}
+
+// UnmarshalJSON reads a hand written by MarshalJSON and rejects one that
+// could not have come from play: missing seats, unknown variant or betting,
+// a board that doesn't fit the street, or a card dealt twice.
+func (h *Hand) UnmarshalJSON(data []byte) error {
+	var j handJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	c := j.Config
+	out := Hand{
+		ID: j.ID,
+		Cfg: Config{SB: c.SB, BB: c.BB, StartStack: c.StartStack, Variant: c.Variant, Betting: c.Betting,
+			SmallBet: c.SmallBet, BigBet: c.BigBet, RaiseCap: c.RaiseCap},
+		Pot: j.Pot, Street: j.Street, ToAct: j.ToAct,
+		CurBet: j.CurBet, MinRaise: j.MinRaise, Bets: j.Bets, History: j.History, StreetStart: j.StreetStart,
+	}
+	var err error
+	if out.Deck, err = parseCardStrings(j.Deck); err != nil {
+		return fmt.Errorf("hand %q: deck: %w", j.ID, err)
+	}
+	if out.Board, err = parseCardStrings(j.Board); err != nil {
+		return fmt.Errorf("hand %q: board: %w", j.ID, err)
+	}
+	if out.SB, err = j.SB.player(); err != nil {
+		return fmt.Errorf("hand %q: %w", j.ID, err)
+	}
+	if out.BB, err = j.BB.player(); err != nil {
+		return fmt.Errorf("hand %q: %w", j.ID, err)
+	}
+	if err := out.check(); err != nil {
+		return fmt.Errorf("hand %q: %w", j.ID, err)
+	}
+	*h = out
+	return nil
+}
+
+var boardLen = map[string]int{"preflop": 0, "flop": 3, "turn": 4, "river": 5}
+
+func (h *Hand) check() error {
+	if h.SB == nil || h.BB == nil {
+		return fmt.Errorf("both sb and bb are required")
+	}
+	if h.SB.Seat != SB || h.BB.Seat != BB {
+		return fmt.Errorf("players are seated %q and %q", h.SB.Seat, h.BB.Seat)
+	}
+	if h.ToAct != SB && h.ToAct != BB {
+		return fmt.Errorf("bad to_act %q", h.ToAct)
+	}
+	v, err := ParseVariant(string(h.Cfg.Variant))
+	if err != nil {
+		return err
+	}
+	if _, err := ParseBetting(string(h.Cfg.Betting)); err != nil {
+		return err
+	}
+	n, ok := boardLen[h.Street]
+	if !ok {
+		return fmt.Errorf("bad street %q", h.Street)
+	}
+	if len(h.Board) != n {
+		return fmt.Errorf("%s with %d board cards", h.Street, len(h.Board))
+	}
+	for _, p := range []*Player{h.SB, h.BB} {
+		if len(p.Hole) != v.HoleCards() {
+			return fmt.Errorf("%s has %d hole cards, %s deals %d", p.Seat, len(p.Hole), v, v.HoleCards())
+		}
+	}
+	if h.StreetStart < 0 || h.StreetStart > len(h.History) {
+		return fmt.Errorf("street_start %d outside history of %d", h.StreetStart, len(h.History))
+	}
+	seen := map[Card]bool{}
+	for _, cs := range [][]Card{h.SB.Hole, h.BB.Hole, h.Board, h.Deck} {
+		for _, c := range cs {
+			if seen[c] {
+				return fmt.Errorf("card %s appears twice", c)
+			}
+			seen[c] = true
+		}
+	}
+	return nil
+}
+
+// Branch applies actions to a copy of h and returns it, leaving h as it
+// was, so a judge or what-if tool can play out alternatives from one
+// decision point. An action's Seat, when set, must be the seat to act.
+// When a betting round closes the next street is dealt from the remaining
+// deck, all the way to the river if a seat is all-in.
+func (h *Hand) Branch(actions ...Action) (*Hand, error) {
+	b := h.Clone()
+	for i, a := range actions {
+		if b.branchOver() {
+			return nil, fmt.Errorf("action %d: the hand is over", i+1)
+		}
+		if a.Seat != "" && a.Seat != b.ToAct {
+			return nil, fmt.Errorf("action %d: %s to act, not %s", i+1, b.ToAct, a.Seat)
+		}
+		if !slices.Contains(b.Legal(), a.Kind) {
+			return nil, fmt.Errorf("action %d: %s may not %s", i+1, b.ToAct, a.Kind)
+		}
+		if err := b.Apply(a.Kind, a.Amount); err != nil {
+			return nil, fmt.Errorf("action %d: %w", i+1, err)
+		}
+		for b.Street != "river" && !b.SB.Folded && !b.BB.Folded && b.roundClosed() {
+			b.NextStreet()
+		}
+	}
+	return b, nil
+}
+
+// branchOver reports whether Branch can take no more actions.
+func (h *Hand) branchOver() bool {
+	return h.SB.Folded || h.BB.Folded ||
+		(h.roundClosed() && (h.Street == "river" || h.SB.AllIn || h.BB.AllIn))
+}
+
+// roundClosed reports whether betting on the current street is over, judged
+// from the actions since StreetStart: heads-up it closes on a fold, on a
+// call other than the small blind completing preflop, or on a check by the
+// seat that acts last. With a seat all-in it is closed once nobody owes.
+func (h *Hand) roundClosed() bool {
+	if h.SB.AllIn || h.BB.AllIn {
+		owes := func(p *Player) bool { return !p.AllIn && h.CurBet > p.Committed }
+		return !owes(h.SB) && !owes(h.BB)
+	}
+	if len(h.History) <= h.StreetStart {
+		return false
+	}
+	last := h.History[len(h.History)-1]
+	switch last.Kind {
+	case Fold:
+		return true
+	case Call:
+		return h.Street != "preflop" || last.Seat != SB || h.Bets > 1
+	case Check:
+		return (h.Street == "preflop") == (last.Seat == BB)
+	}
+	return false
+}
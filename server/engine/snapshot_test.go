@@ -0,0 +1,170 @@
+package engine
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestHandCloneAndJSON(t *testing.T) {
+	h := NewHand("snap", Config{SB: 50, BB: 100, StartStack: 1000}, Holdem.VerifiableDeck(DeckKeyFromSeed(7), 1))
+	if err := h.Apply(Raise, 300); err != nil {
+		t.Fatal(err)
+	}
+
+	c := h.Clone()
+	if err := c.Apply(Call, 0); err != nil {
+		t.Fatal(err)
+	}
+	c.NextStreet()
+	c.SB.Hole[0] = c.Deck[0]
+	if h.Street != "preflop" || len(h.Board) != 0 || len(h.History) != 1 || h.BB.Committed != 100 {
+		t.Fatalf("clone changed the original: %s board %v history %v", h.Street, h.Board, h.History)
+	}
+	if h.SB.Hole[0] == c.SB.Hole[0] {
+		t.Fatal("clone shares hole cards")
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"deck":["`) {
+		t.Fatalf("deck not written as cards: %s", data)
+	}
+	c.SB.Hole[0] = h.SB.Hole[0]
+	data, _ = json.Marshal(c)
+	var back Hand
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	again, _ := json.Marshal(&back)
+	if string(again) != string(data) {
+		t.Fatalf("round trip changed the hand:\n%s\n%s", data, again)
+	}
+	if cardsString(back.Deck) != cardsString(c.Deck) || back.StreetStart != 2 {
+		t.Fatalf("deck %s, street_start %d", cardsString(back.Deck), back.StreetStart)
+	}
+
+	// Only snapshots write cards as strings; a bare Card is unchanged.
+	if raw, _ := json.Marshal(Card{Rank: 14, Suit: 's'}); string(raw) != `{"Rank":14,"Suit":115}` {
+		t.Fatalf("Card encodes as %s", raw)
+	}
+	if value, _ := json.Marshal(*c); string(value) != string(data) {
+		t.Fatal("Hand and *Hand encode differently")
+	}
+
+	bad := strings.Replace(string(data), `"deck":["`+back.Deck[0].String(), `"deck":["`+back.Board[0].String(), 1)
+	if err := json.Unmarshal([]byte(bad), &back); err == nil || !strings.Contains(err.Error(), "twice") {
+		t.Fatalf("duplicate card accepted: %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"street":"flop","sb":{"seat":"SB"},"bb":{"seat":"BB"},"to_act":"BB"}`), &back); err == nil {
+		t.Fatal("flop without a board accepted")
+	}
+}
+
+func TestHandBranch(t *testing.T) {
+	h := NewHand("b", Config{SB: 50, BB: 100, StartStack: 1000}, Holdem.VerifiableDeck(DeckKeyFromSeed(9), 1))
+
+	// Limp, check: the flop comes; check, check: the turn.
+	b, err := h.Branch(Action{Seat: SB, Kind: Call}, Action{Kind: Check}, Action{Kind: Check}, Action{Seat: SB, Kind: Check})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Street != "turn" || len(b.Board) != 4 || b.ToAct != BB || b.Pot != 200 {
+		t.Fatalf("branch at %s, board %v, %s to act, pot %d", b.Street, b.Board, b.ToAct, b.Pot)
+	}
+	if h.Street != "preflop" || len(h.History) != 0 || h.ToAct != SB || h.Pot != 150 {
+		t.Fatal("Branch changed the original hand")
+	}
+
+	// Flop check then bet: the round stays open for BB.
+	if b, err = h.Branch(Action{Kind: Call}, Action{Kind: Check}, Action{Kind: Check}, Action{Kind: Raise, Amount: 100}); err != nil || b.Street != "flop" || b.ToAct != BB {
+		t.Fatalf("flop bet: %v", err)
+	}
+
+	// A shove and a call run the board out.
+	b, err = h.Branch(Action{Kind: Raise, Amount: 1000}, Action{Kind: Call})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Done() || b.Street != "river" || len(b.Board) != 5 || b.Pot != 2000 {
+		t.Fatalf("all-in branch at %s with %d board cards, pot %d", b.Street, len(b.Board), b.Pot)
+	}
+	if _, err := b.Branch(Action{Kind: Check}); err == nil {
+		t.Fatal("action after the hand ended accepted")
+	}
+
+	if _, err := h.Branch(Action{Seat: BB, Kind: Check}); err == nil {
+		t.Fatal("BB acted out of turn")
+	}
+	if _, err := h.Branch(Action{Kind: Check}); err == nil {
+		t.Fatal("SB checked facing the big blind")
+	}
+	if b, err = h.Branch(Action{Kind: Fold}); err != nil || !b.Done() || b.Showdown() != BB {
+		t.Fatalf("fold: %v", err)
+	}
+}
+
+func TestHandBranchRounds(t *testing.T) {
+	h := NewHand("r", Config{SB: 50, BB: 100, StartStack: 1000}, Holdem.VerifiableDeck(DeckKeyFromSeed(5), 1))
+	for _, tc := range []struct {
+		name    string
+		actions []Action
+		street  string
+		toAct   Seat
+	}{
+		{"limp", []Action{{Kind: Call}}, "preflop", BB},
+		{"limp, BB raises", []Action{{Kind: Call}, {Kind: Raise, Amount: 300}}, "preflop", SB},
+		{"limp, raise, call", []Action{{Kind: Call}, {Kind: Raise, Amount: 300}, {Kind: Call}}, "flop", BB},
+		{"raise, re-raise", []Action{{Kind: Raise, Amount: 300}, {Kind: Raise, Amount: 900}}, "preflop", SB},
+		{"raise, re-raise, call", []Action{{Kind: Raise, Amount: 300}, {Kind: Raise, Amount: 900}, {Kind: Call}}, "flop", BB},
+		{"flop bet, raise", []Action{{Kind: Call}, {Kind: Check}, {Kind: Raise, Amount: 100}, {Kind: Raise, Amount: 300}}, "flop", BB},
+		{"flop bet, raise, call", []Action{{Kind: Call}, {Kind: Check}, {Kind: Raise, Amount: 100}, {Kind: Raise, Amount: 300}, {Kind: Call}}, "turn", BB},
+		{"flop check, check", []Action{{Kind: Call}, {Kind: Check}, {Kind: Check}, {Kind: Check}}, "turn", BB},
+	} {
+		b, err := h.Branch(tc.actions...)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if b.Street != tc.street || b.ToAct != tc.toAct || b.branchOver() {
+			t.Errorf("%s: %s, %s to act, over=%v; want %s, %s to act", tc.name, b.Street, b.ToAct, b.branchOver(), tc.street, tc.toAct)
+		}
+	}
+
+	// BB is short: calling the shove puts BB all-in for less, and the
+	// board runs out.
+	short := h.Clone()
+	short.BB.Stack = 300
+	b, err := short.Branch(Action{Kind: Raise, Amount: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Street != "preflop" || b.branchOver() || b.ToAct != BB {
+		t.Fatalf("shove vs short stack: %s, over=%v, %s to act", b.Street, b.branchOver(), b.ToAct)
+	}
+	if b, err = b.Branch(Action{Kind: Call}); err != nil {
+		t.Fatal(err)
+	}
+	if !b.BB.AllIn || b.BB.Stack != 0 || b.Street != "river" || !b.branchOver() {
+		t.Fatalf("short call: BB all-in=%v stack %d, %s, over=%v", b.BB.AllIn, b.BB.Stack, b.Street, b.branchOver())
+	}
+
+	// A flop shove into a short stack leaves the call or fold to come.
+	b, err = short.Branch(Action{Kind: Call}, Action{Kind: Check}, Action{Kind: Raise, Amount: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.BB.AllIn || b.Street != "flop" || b.ToAct != SB || b.branchOver() {
+		t.Fatalf("BB flop shove: %s, %s to act, over=%v", b.Street, b.ToAct, b.branchOver())
+	}
+	if b, err = b.Branch(Action{Kind: Call}); err != nil || b.Street != "river" || !b.branchOver() {
+		t.Fatalf("call of flop shove: %v", err)
+	}
+
+	// Engine semantics are unchanged: Done still treats any all-in as over.
+	if shove, _ := h.Branch(Action{Kind: Raise, Amount: 1000}); !shove.Done() {
+		t.Fatal("Done changed for an all-in")
+	}
+}
@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Variant is the poker game a Hand is dealt and scored under. The zero
+// value plays as Holdem.
+type Variant string
+
+const (
+	Holdem    Variant = "holdem"     // no-limit Texas Hold'em
+	PLO       Variant = "plo"        // pot-limit Omaha: four hole cards, exactly two play
+	ShortDeck Variant = "short_deck" // hold'em with sixes and up; a flush beats a full house
+)
+
+// ParseVariant accepts a variant name in any case; "" is Holdem.
+func ParseVariant(s string) (Variant, error) {
+	switch v := Variant(strings.ToLower(strings.TrimSpace(s))); v {
+	case "", Holdem:
+		return Holdem, nil
+	case PLO, "omaha":
+		return PLO, nil
+	case ShortDeck, "shortdeck", "short", "6+":
+		return ShortDeck, nil
+	default:
+		return "", fmt.Errorf("unknown game variant %q (want holdem, plo or short_deck)", s)
+	}
+}
+
+// HoleCards is how many cards each seat is dealt.
+func (v Variant) HoleCards() int {
+	if v == PLO {
+		return 4
+	}
+	return 2
+}
+
+// NewDeck is a shuffled deck for the variant; see engine.NewDeck.
+func (v Variant) NewDeck(seed int64) []Card {
+	if v == ShortDeck {
+		return NewShortDeck(seed)
+	}
+	return NewDeck(seed)
+}
+
+// cards is the variant's unshuffled deck; read only.
+func (v Variant) cards() []Card {
+	if v == ShortDeck {
+		return deck36
+	}
+	return deck52
+}
+
+// Eval ranks a seat's best hand under the variant's rules.
+func (v Variant) Eval(hole, board []Card) HandRank {
+	switch v {
+	case PLO:
+		return EvalOmaha(hole, board)
+	case ShortDeck:
+		return evalShortHole(hole, board)
+	}
+	return evalHole(hole, board)
+}
+
+// Equity is HeadsUpEquity under the variant's deck and hand rankings.
+func (v Variant) Equity(a, b, board []Card, samples int, rng *rand.Rand) float64 {
+	if v == "" || v == Holdem {
+		return HeadsUpEquity(a, b, board, samples, rng)
+	}
+	return variantEquity(v, a, b, board, samples, rng)
+}
+
+// String is the variant's display name.
+func (v Variant) String() string {
+	switch v {
+	case PLO:
+		return "Pot-Limit Omaha"
+	case ShortDeck:
+		return "Short-Deck Hold'em"
+	case "", Holdem:
+		return "No-Limit Hold'em"
+	}
+	return string(v)
+}
+
+// Betting is a betting structure.
+type Betting string
+
+const (
+	NoLimit    Betting = "no_limit"    // any raise up to all-in
+	PotLimit   Betting = "pot_limit"   // raises capped at the pot after calling
+	FixedLimit Betting = "fixed_limit" // fixed bet sizes and a raise cap per street
+)
+
+// ParseBetting accepts a betting structure name; "" means the variant's
+// default and parses as "".
+func ParseBetting(s string) (Betting, error) {
+	switch b := Betting(strings.ToLower(strings.TrimSpace(s))); b {
+	case "", NoLimit, PotLimit, FixedLimit:
+		return b, nil
+	case "nl":
+		return NoLimit, nil
+	case "pl":
+		return PotLimit, nil
+	case "fl", "limit":
+		return FixedLimit, nil
+	default:
+		return "", fmt.Errorf("unknown betting structure %q (want no_limit, pot_limit or fixed_limit)", s)
+	}
+}
+
+// Limit is the betting structure in force: Betting when set, otherwise pot
+// limit for PLO and no limit for hold'em.
+func (c Config) Limit() Betting {
+	switch {
+	case c.Betting != "":
+		return c.Betting
+	case c.Variant == PLO:
+		return PotLimit
+	}
+	return NoLimit
+}
+
+// DefaultRaiseCap is the fixed-limit bets per street (a bet and three
+// raises; preflop the big blind is the first bet) when Config.RaiseCap is 0.
+const DefaultRaiseCap = 4
+
+// BetSizes are the fixed-limit small bet (preflop and flop) and big bet
+// (turn and river), one and two big blinds unless configured.
+func (c Config) BetSizes() (small, big int) {
+	small, big = c.SmallBet, c.BigBet
+	if small <= 0 {
+		small = c.BB
+	}
+	if big <= 0 {
+		big = 2 * small
+	}
+	return small, big
+}
+
+// Cap is the fixed-limit bets allowed per street.
+func (c Config) Cap() int {
+	if c.RaiseCap > 0 {
+		return c.RaiseCap
+	}
+	return DefaultRaiseCap
+}
+
+// BetSize is the fixed-limit bet on the current street.
+func (h *Hand) BetSize() int {
+	small, big := h.Cfg.BetSizes()
+	if h.Street == "turn" || h.Street == "river" {
+		return big
+	}
+	return small
+}
+
+// Capped reports whether fixed-limit betting has reached the raise cap on
+// this street.
+func (h *Hand) Capped() bool {
+	return h.Cfg.Limit() == FixedLimit && h.Bets >= h.Cfg.Cap()
+}
+
+// MaxRaiseTo is the largest raise-to seat may make, capped at all-in: the
+// whole stack under no limit, the current bet plus the pot after calling
+// under pot limit, and the current bet plus one bet under fixed limit.
+func (h *Hand) MaxRaiseTo(seat Seat) int {
+	p := h.SB
+	if seat == BB {
+		p = h.BB
+	}
+	all := p.Stack + p.Committed
+	switch h.Cfg.Limit() {
+	case PotLimit:
+		toCall := max(h.CurBet-p.Committed, 0)
+		return min(h.CurBet+h.Pot+toCall, all)
+	case FixedLimit:
+		return min(h.CurBet+h.BetSize(), all)
+	}
+	return all
+}
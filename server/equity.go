@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"ai-thunderdome/server/engine"
+)
+
+// maxEquityIters caps Monte-Carlo samples for /api/equity and `equity`.
+const maxEquityIters = 2_000_000
+
+// equityReport is the result of /api/equity and the `equity` subcommand,
+// from side A's point of view unless a field says otherwise.
+type equityReport struct {
+	A       string   `json:"a"`
+	B       string   `json:"b"`
+	Board   []string `json:"board"`
+	WinA    float64  `json:"win_a"`
+	Tie     float64  `json:"tie"`
+	WinB    float64  `json:"win_b"`
+	EquityA float64  `json:"equity_a"`
+	EquityB float64  `json:"equity_b"`
+	StdErr  float64  `json:"std_err"` // 0 when exact
+	CombosA int      `json:"combos_a"`
+	CombosB int      `json:"combos_b"`
+	Runouts int      `json:"runouts"`
+	Exact   bool     `json:"exact"`
+	Seed    uint64   `json:"seed,omitempty"` // Monte-Carlo seed; pass it back to repeat the result
+	// Categories is the share of showdowns each side finishes with each
+	// hand class, weakest first.
+	Categories []equityCategory `json:"categories"`
+}
+
+type equityCategory struct {
+	Category string  `json:"category"`
+	A        float64 `json:"a"`
+	B        float64 `json:"b"`
+}
+
+// computeEquity parses two ranges (or hole cards such as "AhKh") and a
+// partial board and runs engine.RangeEquity, or engine.RangeEquitySeed when
+// seed is not 0.
+func computeEquity(a, b, board string, iters int, seed uint64) (equityReport, error) {
+	ra, err := engine.ParseRange(a)
+	if err != nil {
+		return equityReport{}, fmt.Errorf("a: %w", err)
+	}
+	rb, err := engine.ParseRange(b)
+	if err != nil {
+		return equityReport{}, fmt.Errorf("b: %w", err)
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return equityReport{}, fmt.Errorf("both a and b are required")
+	}
+	bd, err := engine.ParseCards(board)
+	if err != nil {
+		return equityReport{}, fmt.Errorf("board: %w", err)
+	}
+	if len(bd) > 5 {
+		return equityReport{}, fmt.Errorf("board has %d cards, at most 5", len(bd))
+	}
+	if iters > maxEquityIters {
+		iters = maxEquityIters
+	}
+	var res engine.EquityResult
+	if seed != 0 {
+		res = engine.RangeEquitySeed(ra, rb, bd, iters, seed)
+	} else {
+		res = engine.RangeEquity(ra, rb, bd, iters)
+	}
+	if res.Runouts == 0 {
+		return equityReport{}, fmt.Errorf("no hands in a and b can meet on this board")
+	}
+	rep := equityReport{
+		A: ra.String(), B: rb.String(), Board: make([]string, len(bd)),
+		WinA: res.Win, Tie: res.Tie, WinB: res.Lose,
+		EquityA: res.Equity, EquityB: 1 - res.Equity, StdErr: res.StdErr,
+		CombosA: res.HeroCombos, CombosB: res.Combos, Runouts: res.Runouts, Exact: res.Exact, Seed: res.Seed,
+	}
+	for i, c := range bd {
+		rep.Board[i] = c.String()
+	}
+	for c := range res.HeroHands {
+		rep.Categories = append(rep.Categories, equityCategory{
+			Category: engine.Category(c).String(),
+			A:        res.HeroHands[c],
+			B:        res.VillainHands[c],
+		})
+	}
+	return rep, nil
+}
+
+// runEquityCmd is `ai-thunderdome equity`: range-vs-range equity offline.
+func runEquityCmd(args []string) {
+	fs := flag.NewFlagSet("equity", flag.ExitOnError)
+	a := fs.String("a", "", `side A: hole cards ("AhKh") or a range ("TT+, AKs")`)
+	b := fs.String("b", "", "side B: hole cards or a range")
+	board := fs.String("board", "", `board cards so far, e.g. "Qs7d2c"`)
+	iters := fs.Int("iters", engine.DefaultIterations, "Monte-Carlo samples when exact enumeration is too large")
+	seed := fs.Uint64("seed", 0, "Monte-Carlo seed to repeat a result (0 draws a fresh one)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, `usage: ai-thunderdome equity -a AhKh -b "QQ+, AKs" [-board Qs7d2c] [-iters N] [-seed N] [-json]`)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	rep, err := computeEquity(*a, *b, *board, *iters, *seed)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "equity:", err)
+		os.Exit(2)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(rep)
+		return
+	}
+	how := "exact"
+	if !rep.Exact {
+		how = fmt.Sprintf("Monte-Carlo, ±%.2f%%, seed %d", 100*rep.StdErr, rep.Seed)
+	}
+	fmt.Printf("Board   %s\n", strings.Join(rep.Board, " "))
+	fmt.Printf("A       %s (%d combos)\n", rep.A, rep.CombosA)
+	fmt.Printf("B       %s (%d combos)\n", rep.B, rep.CombosB)
+	fmt.Printf("Equity  A %.2f%%  B %.2f%%  (%d runouts, %s)\n", 100*rep.EquityA, 100*rep.EquityB, rep.Runouts, how)
+	fmt.Printf("Win     A %.2f%%  tie %.2f%%  B %.2f%%\n\n", 100*rep.WinA, 100*rep.Tie, 100*rep.WinB)
+	fmt.Printf("%-16s %7s %7s\n", "Hand", "A", "B")
+	for i := len(rep.Categories) - 1; i >= 0; i-- {
+		c := rep.Categories[i]
+		fmt.Printf("%-16s %6.2f%% %6.2f%%\n", c.Category, 100*c.A, 100*c.B)
+	}
+}
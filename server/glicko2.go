@@ -143,6 +143,23 @@ func (a *Glicko2) UpdateBatch(results []OpponentResult, tau float64) {
 	a.Games++
 }
 
+// ExpectedRD is a's RD after `games` more single-opponent periods against b,
+// using UpdateBatch's variance step with volatility held fixed. Outcomes do
+// not enter the variance update, so this is known before playing.
+func (a *Glicko2) ExpectedRD(b *Glicko2, games int) float64 {
+	muA, phiA := toMuPhi(a.Rating, a.RD)
+	muB, phiB := toMuPhi(b.Rating, b.RD)
+	gB := g(phiB)
+	E := gExp(muA, muB, phiB)
+	v := 1.0 / (q * q * gB * gB * E * (1.0 - E))
+	for i := 0; i < games; i++ {
+		phiStar := math.Sqrt(phiA*phiA + a.Volatility*a.Volatility)
+		phiA = 1.0 / math.Sqrt(1.0/(phiStar*phiStar)+1.0/v)
+	}
+	_, rd := fromMuPhi(muA, phiA)
+	return rd
+}
+
 // UpdatePair is a convenience wrapper for a single-opponent period.
 // (Effectively a special case of UpdateBatch with len=1.)
 func (a *Glicko2) UpdatePair(b *Glicko2, S float64, tau float64) {
@@ -8,7 +8,6 @@ import (
 
 	"ai-thunderdome/server/engine"
 	"ai-thunderdome/server/store"
-	poker "github.com/paulhankin/poker"
 	"math"
 )
 
@@ -39,11 +38,16 @@ func EvaluateMatchMC(ctx context.Context, db *store.DB, matchID int64) error {
 		defer conn.Release()
 	}
 
-	// Fetch big blind size for epsilon scaling
+	// Fetch big blind size for epsilon scaling. Equity here is full-deck
+	// hold'em only, so other variants are not judged.
 	var bb int
-	if err := conn.QueryRow(ctx, `SELECT bb FROM matches WHERE id = $1`, matchID).Scan(&bb); err != nil {
+	var variant string
+	if err := conn.QueryRow(ctx, `SELECT bb, game_variant FROM matches WHERE id = $1`, matchID).Scan(&bb, &variant); err != nil {
 		return err
 	}
+	if variant != string(engine.Holdem) {
+		return nil
+	}
 	if bb <= 0 {
 		bb = 100
 	}
@@ -149,86 +153,12 @@ func EvaluateMatchMC(ctx context.Context, db *store.DB, matchID int64) error {
 			continue
 		}
 
-		// Build deck and enumerate villain combos (exact equity)
-		deck := make([]engine.Card, 0, 52)
-		suits := []byte{'c', 'd', 'h', 's'}
-		for _, su := range suits {
-			for rnk := 2; rnk <= 14; rnk++ {
-				deck = append(deck, engine.Card{Rank: rnk, Suit: su})
-			}
-		}
-		used := map[engine.Card]bool{}
-		for _, c := range board {
-			used[c] = true
-		}
-		for _, c := range h1 {
-			used[c] = true
-		}
-
-		// Build poker lib cards
-		toPH := func(c engine.Card) poker.Card {
-			var s poker.Suit
-			switch c.Suit {
-			case 'c':
-				s = poker.Club
-			case 'd':
-				s = poker.Diamond
-			case 'h':
-				s = poker.Heart
-			default:
-				s = poker.Spade
-			}
-			var rnk poker.Rank
-			if c.Rank == 14 {
-				rnk = poker.Rank(1)
-			} else {
-				rnk = poker.Rank(c.Rank)
-			}
-			pc, _ := poker.MakeCard(s, rnk)
-			return pc
-		}
-		heroAllPH := make([]poker.Card, 0, 7)
-		for _, c := range h1 {
-			heroAllPH = append(heroAllPH, toPH(c))
-		}
-		for _, c := range board {
-			heroAllPH = append(heroAllPH, toPH(c))
-		}
-		var a7 [7]poker.Card
-		copy(a7[:], heroAllPH)
-		heroScore := poker.Eval7(&a7)
-
-		var total int64
-		var win, tie int64
-		// enumerate pairs
-		avail := make([]engine.Card, 0, len(deck))
-		for _, c := range deck {
-			if !used[c] {
-				avail = append(avail, c)
-			}
-		}
-		for i := 0; i < len(avail); i++ {
-			for j := i + 1; j < len(avail); j++ {
-				total++
-				vAllPH := make([]poker.Card, 0, 7)
-				vAllPH = append(vAllPH, toPH(avail[i]), toPH(avail[j]))
-				for _, c := range board {
-					vAllPH = append(vAllPH, toPH(c))
-				}
-				var b7 [7]poker.Card
-				copy(b7[:], vAllPH)
-				vScore := poker.Eval7(&b7)
-				if heroScore > vScore {
-					win++
-				} else if heroScore == vScore {
-					tie++
-				}
-			}
-		}
-		if total == 0 {
+		// Exact equity against every villain holding on the river
+		res := engine.Equity(h1, engine.AnyTwo(), board, 0)
+		if res.Combos == 0 {
 			continue
 		}
-		eq := (float64(win) + 0.5*float64(tie)) / float64(total)
+		eq := res.Equity
 
 		P := float64(r.Pot)
 
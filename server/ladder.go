@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"ai-thunderdome/server/llm"
+	"ai-thunderdome/server/store"
+)
+
+// runLadder is ladder mode (--ladder): instead of a fixed plan it keeps
+// playing whichever pairing is expected to tell us the most about the
+// ratings, until runSpec.Ladder's hand or dollar budget is spent. Ratings
+// come from bot_ratings when a DB is configured (so a ladder continues from
+// the career state) and start fresh in memory otherwise.
+func runLadder(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
+	entrants := matrixPlayers()
+	if len(entrants) < 2 {
+		log.Println("Need at least two models in OPENAI_MODELS (or players in --config) for --ladder.")
+		return
+	}
+	mustPlayerKeys(entrants...)
+	lad := runSpec.Ladder
+	if lad.BudgetHands <= 0 && lad.BudgetUSD <= 0 {
+		log.Println("Ladder mode needs LADDER_BUDGET_HANDS or ladder.budget_usd.")
+		return
+	}
+	pairsPerMatch := runSpec.Seeds.Pairs
+	handsPerMatch := 2 * pairsPerMatch
+
+	n := len(entrants)
+	ratings := make([]Glicko2, n)
+	for i := range ratings {
+		ratings[i] = *NewGlicko2()
+	}
+	played := map[[2]int]int{}
+	botIDs := make([]int64, n)
+	if db != nil {
+		for i, p := range entrants {
+			id, err := db.UpsertBot(context.Background(), botVariant(p), companyForModel(p.Model))
+			if err != nil {
+				log.Printf("UpsertBot(%s) failed: %v (ladder continues without DB)", p.Model, err)
+				db = nil
+				break
+			}
+			botIDs[i] = id
+		}
+	}
+	// refresh reloads ratings and pair counts from the DB, which runDuel has
+	// just updated; without a DB the in-memory state is kept current instead.
+	refresh := func() {
+		if db == nil {
+			return
+		}
+		for i, id := range botIDs {
+			if _, r, rd, sigma, _, _, err := db.GetOrInitRatings(context.Background(), id); err != nil {
+				log.Printf("GetOrInitRatings(%d) failed: %v", id, err)
+			} else {
+				ratings[i] = Glicko2{Rating: r, RD: rd, Volatility: sigma}
+			}
+		}
+		counts, err := db.PairMatchCounts(context.Background(), botIDs)
+		if err != nil {
+			log.Printf("PairMatchCounts failed: %v", err)
+			return
+		}
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				lo, hi := min(botIDs[i], botIDs[j]), max(botIDs[i], botIDs[j])
+				played[[2]int{i, j}] = counts[[2]int64{lo, hi}]
+			}
+		}
+	}
+
+	startSpend := ladderSpend(entrants)
+	base := runSeedBase()
+	sm := newSeedStream(base)
+	log.Printf("Ladder: %d entrants, %d pairs per match, budget hands=%d usd=%.2f (seed base %d)",
+		n, pairsPerMatch, lad.BudgetHands, lad.BudgetUSD, base)
+
+	var matches, hands int
+	for {
+		if stopFlag.Load() && gracefulOnly {
+			log.Println("Stop requested; ending ladder.")
+			break
+		}
+		spent := ladderSpend(entrants) - startSpend
+		if lad.BudgetHands > 0 && hands+handsPerMatch > lad.BudgetHands {
+			log.Printf("Hand budget reached (%d/%d).", hands, lad.BudgetHands)
+			break
+		}
+		if lad.BudgetUSD > 0 {
+			// Stop before a match that would likely overshoot the budget.
+			next := 0.0
+			if matches > 0 {
+				next = spent / float64(matches)
+			}
+			if spent+next > lad.BudgetUSD {
+				log.Printf("Dollar budget reached ($%.2f spent of $%.2f).", spent, lad.BudgetUSD)
+				break
+			}
+		}
+
+		refresh()
+		i, j, gain := pickLadderPair(ratings, played, botIDs, pairsPerMatch)
+		if i < 0 {
+			log.Println("No eligible pairing (all entrants share one bot variant).")
+			break
+		}
+		a, b := entrants[i], entrants[j]
+		a.Label, a.Name = "A", "A"
+		b.Label, b.Name = "B", "B"
+		log.Printf("Ladder match %d: A=%s (r=%.0f RD=%.0f) vs B=%s (r=%.0f RD=%.0f), played %d, gain %.0f",
+			matches+1, a.Model, ratings[i].Rating, ratings[i].RD, b.Model, ratings[j].Rating, ratings[j].RD,
+			played[[2]int{i, j}], gain)
+
+		res := runDuel(checkStop, gracefulOnly, db, a, b, duelOpts{SeedBase: sm.next()})
+		matches++
+		hands += res.Hands
+		if db == nil {
+			ratings[i], ratings[j] = res.GA, res.GB
+			played[[2]int{i, j}]++
+		}
+		if !res.Finished {
+			log.Println("Match stopped early; ending ladder.")
+			break
+		}
+	}
+
+	refresh()
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(x, y int) bool { return ratings[order[x]].Rating > ratings[order[y]].Rating })
+	fmt.Printf("\n%s matches=%d hands=%d spent=$%.2f\n", bold("LADDER →"), matches, hands, ladderSpend(entrants)-startSpend)
+	for rank, i := range order {
+		fmt.Printf("  %2d. %-40s r=%.1f RD=%.0f\n", rank+1, botVariant(entrants[i]).Label(), ratings[i].Rating, ratings[i].RD)
+	}
+}
+
+// pickLadderPair returns the pairing with the highest ladderGain, or -1
+// when no two entrants are distinct bots.
+func pickLadderPair(ratings []Glicko2, played map[[2]int]int, botIDs []int64, games int) (bi, bj int, best float64) {
+	bi, bj, best = -1, -1, math.Inf(-1)
+	for i := range ratings {
+		for j := i + 1; j < len(ratings); j++ {
+			if botIDs[i] != 0 && botIDs[i] == botIDs[j] {
+				continue
+			}
+			if s := ladderGain(&ratings[i], &ratings[j], games, played[[2]int{i, j}]); s > best {
+				bi, bj, best = i, j, s
+			}
+		}
+	}
+	return
+}
+
+// ladderGain scores a match of `games` mirrored pairs between a and b: the
+// expected drop in both players' rating variance (RD²), largest for
+// uncertain players in close matchups, discounted by 1/sqrt(1+n) when the
+// pair has already met n times so coverage spreads across the field.
+func ladderGain(a, b *Glicko2, games, played int) float64 {
+	ra, rb := a.ExpectedRD(b, games), b.ExpectedRD(a, games)
+	gain := (a.RD*a.RD - ra*ra) + (b.RD*b.RD - rb*rb)
+	return gain / math.Sqrt(1+float64(played))
+}
+
+// ladderSpend is the dollar cost of all provider usage so far for the
+// entrants' models, at runSpec.Ladder.Prices. Models without a price count
+// as free.
+func ladderSpend(entrants []Player) float64 {
+	seen := map[string]bool{}
+	total := 0.0
+	for _, p := range entrants {
+		if seen[p.Model] {
+			continue
+		}
+		seen[p.Model] = true
+		if price, ok := runSpec.Ladder.Prices[p.Model]; ok {
+			total += llm.UsageFor(p.Model).Cost(price.Input, price.Output)
+		}
+	}
+	return total
+}
@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// anthropicVersion pins the Messages API revision sent with every request.
+const anthropicVersion = "2023-06-01"
+
+// defaultAnthropicMaxTokens is used when no output cap is configured; the
+// Messages API requires max_tokens on every request.
+const defaultAnthropicMaxTokens = 1024
+
+// pingAnthropic sends a Messages API request. A structured schema becomes a
+// single tool the model is forced to call, so the answer arrives as the tool
+// input rather than free text.
+func pingAnthropic(ctx context.Context, cfg apiConfig, system, user string, opts PingOptions) (Reply, error) {
+	maxTok := defaultAnthropicMaxTokens
+	if opts.MaxOutputTokens != nil && *opts.MaxOutputTokens > 0 {
+		maxTok = *opts.MaxOutputTokens
+	}
+	payload := map[string]any{
+		"model":  cfg.Model,
+		"system": system,
+		"messages": []map[string]any{
+			{"role": "user", "content": user},
+		},
+	}
+
+	budget := thinkingBudget(opts.ReasoningEffort)
+	if budget > 0 {
+		// The thinking budget counts against max_tokens, so leave room for the answer.
+		payload["thinking"] = map[string]any{"type": "enabled", "budget_tokens": budget}
+		maxTok += budget
+	} else {
+		tuning := map[string]any{}
+		applyTuningFromEnv(tuning, false)
+		cfg.Endpoint.overrideTuning(tuning)
+		for _, k := range []string{"temperature", "top_p", "top_k"} {
+			if v, ok := tuning[k]; ok {
+				payload[k] = v
+			}
+		}
+	}
+	payload["max_tokens"] = maxTok
+
+	toolName := ""
+	if opts.StructuredSchema != nil {
+		toolName = coalesce(opts.StructuredSchemaName, "structured")
+		payload["tools"] = []map[string]any{{
+			"name":         toolName,
+			"description":  "Submit the answer. Always call this tool exactly once.",
+			"input_schema": opts.StructuredSchema,
+		}}
+		// Extended thinking only allows auto tool choice.
+		if budget > 0 {
+			payload["tool_choice"] = map[string]any{"type": "auto"}
+		} else {
+			payload["tool_choice"] = map[string]any{"type": "tool", "name": toolName}
+		}
+	}
+
+	body, err := postJSON(ctx, cfg, cfg.BaseURL+"/messages", payload, map[string]string{
+		"anthropic-version": anthropicVersion,
+	})
+	if err != nil {
+		return Reply{}, fmt.Errorf("anthropic %w", err)
+	}
+
+	var mr struct {
+		Content []struct {
+			Type     string          `json:"type"`
+			Text     string          `json:"text"`
+			Thinking string          `json:"thinking"`
+			Name     string          `json:"name"`
+			Input    json.RawMessage `json:"input"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(body, &mr); err != nil {
+		return Reply{}, err
+	}
+	if len(mr.Content) == 0 {
+		return Reply{}, errors.New("no content returned")
+	}
+
+	var text, thinking []string
+	var toolInput string
+	for _, blk := range mr.Content {
+		switch blk.Type {
+		case "text":
+			text = append(text, blk.Text)
+		case "thinking":
+			thinking = append(thinking, blk.Thinking)
+		case "tool_use":
+			if toolInput == "" && (toolName == "" || blk.Name == toolName) {
+				toolInput = string(blk.Input)
+			}
+		}
+	}
+	rep := Reply{Reasoning: strings.TrimSpace(strings.Join(thinking, "\n"))}
+	if toolInput != "" {
+		rep.Text = toolInput
+	} else {
+		rep.Text = strings.Join(text, "")
+	}
+	return rep, nil
+}
+
+// thinkingBudget maps an OpenAI-style reasoning effort onto a token budget
+// for providers that take one (Anthropic extended thinking, Gemini). A bare
+// integer is used as-is; empty, "none" and "minimal" disable thinking.
+func thinkingBudget(effort string) int {
+	switch e := strings.ToLower(strings.TrimSpace(effort)); e {
+	case "", "none", "minimal", "off":
+		return 0
+	case "low":
+		return 1024
+	case "medium":
+		return 4096
+	case "high":
+		return 16384
+	default:
+		var n int
+		if _, err := fmt.Sscanf(e, "%d", &n); err == nil && n > 0 {
+			return n
+		}
+		return 0
+	}
+}
@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Endpoint overrides provider settings for a single caller, typically one
+// seat of a duel. Zero-valued fields fall back to the process-wide env
+// configuration, so a zero Endpoint behaves exactly like the env setup.
+type Endpoint struct {
+	Provider        string // openai | openrouter | anthropic | gemini
+	BaseURL         string
+	APIKeyEnv       string // name of the env var holding the key
+	APIKeyFile      string // path to a file holding the key
+	Temperature     *float64
+	TopP            *float64
+	ReasoningEffort string
+	MaxOutputTokens *int
+}
+
+// IsZero reports whether e overrides nothing.
+func (e Endpoint) IsZero() bool {
+	return e.Provider == "" && e.BaseURL == "" && e.APIKeyEnv == "" && e.APIKeyFile == "" &&
+		e.Temperature == nil && e.TopP == nil && e.ReasoningEffort == "" && e.MaxOutputTokens == nil
+}
+
+// String summarises the overrides for logs, e.g. "provider=openai base=http://localhost:8000/v1 effort=high".
+func (e Endpoint) String() string {
+	var parts []string
+	add := func(k, v string) {
+		if v != "" {
+			parts = append(parts, k+"="+v)
+		}
+	}
+	add("provider", e.Provider)
+	add("base", e.BaseURL)
+	add("key_env", e.APIKeyEnv)
+	add("key_file", e.APIKeyFile)
+	if e.Temperature != nil {
+		add("temp", fmt.Sprintf("%g", *e.Temperature))
+	}
+	if e.TopP != nil {
+		add("top_p", fmt.Sprintf("%g", *e.TopP))
+	}
+	add("effort", e.ReasoningEffort)
+	if e.MaxOutputTokens != nil {
+		add("max_tokens", fmt.Sprintf("%d", *e.MaxOutputTokens))
+	}
+	if len(parts) == 0 {
+		return "env defaults"
+	}
+	return strings.Join(parts, " ")
+}
+
+// providerKind parses Provider; ok is false when it is empty.
+func (e Endpoint) providerKind() (providerKind, bool, error) {
+	switch strings.ToLower(strings.TrimSpace(e.Provider)) {
+	case "":
+		return providerOpenAI, false, nil
+	case "openai":
+		return providerOpenAI, true, nil
+	case "openrouter":
+		return providerOpenRouter, true, nil
+	case "anthropic":
+		return providerAnthropic, true, nil
+	case "gemini", "google":
+		return providerGemini, true, nil
+	}
+	return providerOpenAI, false, fmt.Errorf("unknown provider %q", e.Provider)
+}
+
+// apiKey reads the key from APIKeyEnv or APIKeyFile. ok is false when the
+// endpoint does not name a key source.
+func (e Endpoint) apiKey() (key string, ok bool, err error) {
+	if name := strings.TrimSpace(e.APIKeyEnv); name != "" {
+		if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+			return v, true, nil
+		}
+		return "", true, fmt.Errorf("API key missing: %s is empty", name)
+	}
+	if path := strings.TrimSpace(e.APIKeyFile); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", true, fmt.Errorf("API key file: %w", err)
+		}
+		if v := strings.TrimSpace(string(b)); v != "" {
+			return v, true, nil
+		}
+		return "", true, fmt.Errorf("API key file %s is empty", path)
+	}
+	return "", false, nil
+}
+
+// withDefaults fills unset request knobs from the endpoint.
+func (o PingOptions) withDefaults() PingOptions {
+	if strings.TrimSpace(o.ReasoningEffort) == "" {
+		o.ReasoningEffort = o.Endpoint.ReasoningEffort
+	}
+	if o.MaxOutputTokens == nil {
+		o.MaxOutputTokens = o.Endpoint.MaxOutputTokens
+	}
+	return o
+}
+
+// overrideTuning applies endpoint sampling settings on top of env tuning.
+func (e Endpoint) overrideTuning(m map[string]any) {
+	if e.Temperature != nil {
+		m["temperature"] = *e.Temperature
+	}
+	if e.TopP != nil {
+		m["top_p"] = *e.TopP
+	}
+}
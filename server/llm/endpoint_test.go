@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPingReplyEndpointOverrides(t *testing.T) {
+	var got map[string]any
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{}"}}]}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("OPENAI_API_BASE", "https://api.openai.com/v1")
+	t.Setenv("OPENAI_API_KEY", "global")
+	t.Setenv("OPENAI_TEMPERATURE", "0.2")
+	t.Setenv("SEAT_B_KEY", "local")
+	temp := 0.9
+	n := 64
+	ep := Endpoint{Provider: "openai", BaseURL: srv.URL, APIKeyEnv: "SEAT_B_KEY", Temperature: &temp, ReasoningEffort: "high", MaxOutputTokens: &n}
+
+	if _, err := PingReply(context.Background(), "local-model", "sys", "usr", PingOptions{Endpoint: ep}); err != nil {
+		t.Fatalf("PingReply: %v", err)
+	}
+	if auth != "Bearer local" {
+		t.Fatalf("Authorization = %q", auth)
+	}
+	if got["temperature"] != 0.9 || got["max_tokens"] != float64(64) {
+		t.Fatalf("payload = %v", got)
+	}
+	if re, _ := got["reasoning"].(map[string]any); re["effort"] != "high" {
+		t.Fatalf("reasoning = %v", got["reasoning"])
+	}
+}
+
+func TestCheckKeyPerProvider(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENROUTER_API_KEY", "")
+	t.Setenv("LLM_PROVIDER", "")
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant")
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("GOOGLE_API_KEY", "")
+	t.Setenv("SEAT_B_KEY", "sk-local")
+	if err := CheckKey("anthropic:claude-sonnet-4-5", Endpoint{}); err != nil {
+		t.Fatalf("anthropic seat: %v", err)
+	}
+	if err := CheckKey("gpt-4o-mini", Endpoint{Provider: "openai", APIKeyEnv: "SEAT_B_KEY"}); err != nil {
+		t.Fatalf("seat with its own key: %v", err)
+	}
+	if err := CheckKey("gpt-4o-mini", Endpoint{}); err == nil {
+		t.Fatalf("expected error for an openai seat without OPENAI_API_KEY")
+	}
+	if err := CheckKey("gemini:gemini-2.5-flash", Endpoint{}); err == nil {
+		t.Fatalf("expected error for a gemini seat without GEMINI_API_KEY")
+	}
+}
+
+func TestResolveAPIConfigEndpointKeyMissing(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "global")
+	t.Setenv("SEAT_A_KEY", "")
+	if _, err := resolveAPIConfig("gpt-4o-mini", Endpoint{APIKeyEnv: "SEAT_A_KEY"}); err == nil {
+		t.Fatalf("expected error when the endpoint key source is empty")
+	}
+	if _, err := resolveAPIConfig("gpt-4o-mini", Endpoint{Provider: "bogus"}); err == nil {
+		t.Fatalf("expected error for unknown provider")
+	}
+}
@@ -0,0 +1,121 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// pingGemini sends a generateContent request. A structured schema becomes a
+// single function declaration the model is forced to call (mode ANY), as
+// with pingAnthropic's forced tool, and the call's args are the answer.
+// JSON output is requested only with opts.JSONObject; other calls get free
+// text. Reasoning effort maps to a thinking budget and thought summaries
+// are returned as Reply.Reasoning.
+func pingGemini(ctx context.Context, cfg apiConfig, system, user string, opts PingOptions) (Reply, error) {
+	gen := map[string]any{}
+	if opts.MaxOutputTokens != nil && *opts.MaxOutputTokens > 0 {
+		gen["maxOutputTokens"] = *opts.MaxOutputTokens
+	}
+	tuning := map[string]any{}
+	applyTuningFromEnv(tuning, false)
+	cfg.Endpoint.overrideTuning(tuning)
+	for from, to := range map[string]string{"temperature": "temperature", "top_p": "topP", "top_k": "topK"} {
+		if v, ok := tuning[from]; ok {
+			gen[to] = v
+		}
+	}
+	if opts.StructuredSchema == nil && opts.JSONObject {
+		gen["responseMimeType"] = "application/json"
+	}
+	if budget := thinkingBudget(opts.ReasoningEffort); budget > 0 || opts.IncludeReasoning {
+		tc := map[string]any{"includeThoughts": opts.IncludeReasoning}
+		if budget > 0 {
+			tc["thinkingBudget"] = budget
+		}
+		gen["thinkingConfig"] = tc
+	}
+
+	payload := map[string]any{
+		"systemInstruction": map[string]any{
+			"parts": []map[string]string{{"text": system}},
+		},
+		"contents": []map[string]any{
+			{"role": "user", "parts": []map[string]string{{"text": user}}},
+		},
+		"generationConfig": gen,
+	}
+	toolName := ""
+	if opts.StructuredSchema != nil {
+		toolName = coalesce(opts.StructuredSchemaName, "structured")
+		payload["tools"] = []map[string]any{{
+			"functionDeclarations": []map[string]any{{
+				"name":                 toolName,
+				"description":          "Submit the answer. Always call this function exactly once.",
+				"parametersJsonSchema": opts.StructuredSchema,
+			}},
+		}}
+		payload["toolConfig"] = map[string]any{
+			"functionCallingConfig": map[string]any{"mode": "ANY", "allowedFunctionNames": []string{toolName}},
+		}
+	}
+
+	endpoint := fmt.Sprintf("%s/models/%s:generateContent", cfg.BaseURL, url.PathEscape(cfg.Model))
+	body, err := postJSON(ctx, cfg, endpoint, payload, nil)
+	if err != nil {
+		return Reply{}, fmt.Errorf("gemini %w", err)
+	}
+
+	var gr struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text         string `json:"text"`
+					Thought      bool   `json:"thought"`
+					FunctionCall *struct {
+						Name string          `json:"name"`
+						Args json.RawMessage `json:"args"`
+					} `json:"functionCall"`
+				} `json:"parts"`
+			} `json:"content"`
+			FinishReason string `json:"finishReason"`
+		} `json:"candidates"`
+		PromptFeedback struct {
+			BlockReason string `json:"blockReason"`
+		} `json:"promptFeedback"`
+	}
+	if err := json.Unmarshal(body, &gr); err != nil {
+		return Reply{}, err
+	}
+	if len(gr.Candidates) == 0 {
+		if gr.PromptFeedback.BlockReason != "" {
+			return Reply{}, fmt.Errorf("prompt blocked: %s", gr.PromptFeedback.BlockReason)
+		}
+		return Reply{}, errors.New("no candidates returned")
+	}
+
+	var text, thoughts []string
+	var callArgs string
+	for _, p := range gr.Candidates[0].Content.Parts {
+		switch {
+		case p.FunctionCall != nil:
+			if callArgs == "" && (toolName == "" || p.FunctionCall.Name == toolName) {
+				callArgs = string(p.FunctionCall.Args)
+			}
+		case p.Thought:
+			thoughts = append(thoughts, p.Text)
+		default:
+			text = append(text, p.Text)
+		}
+	}
+	rep := Reply{Reasoning: strings.TrimSpace(strings.Join(thoughts, "\n"))}
+	if callArgs != "" {
+		rep.Text = callArgs
+	} else {
+		rep.Text = strings.Join(text, "")
+	}
+	return rep, nil
+}
@@ -0,0 +1,324 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit caps traffic to one provider or one model. Zero fields are unlimited.
+type Limit struct {
+	Concurrency int // requests in flight
+	RPM         int // requests per minute
+	TPM         int // tokens per minute (request size estimate + output cap)
+}
+
+// RetryPolicy is the backoff shared by every request for rate-limited (429)
+// and overloaded (5xx) responses. A 429 also pauses the provider and model
+// for everyone, so parallel matches back off together instead of piling on.
+type RetryPolicy struct {
+	MaxAttempts int
+	Base        time.Duration
+	Max         time.Duration
+}
+
+// DefaultRetry applies until SetLimits installs another policy.
+var DefaultRetry = RetryPolicy{MaxAttempts: 4, Base: time.Second, Max: 30 * time.Second}
+
+var (
+	limitsMu  sync.Mutex
+	limitCfg  = map[string]Limit{} // "provider:<name>" / "model:<id>"
+	limiters  = map[string]*limiter{}
+	retryPlan = DefaultRetry
+)
+
+// SetLimits installs process-wide limits. Provider keys are provider names
+// (openai, openrouter, anthropic, gemini); model keys are model ids, with or
+// without a native "anthropic:" / "gemini:" prefix. Call before the first
+// request.
+func SetLimits(providers, models map[string]Limit, retry RetryPolicy) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	limitCfg = map[string]Limit{}
+	limiters = map[string]*limiter{}
+	for name, l := range providers {
+		limitCfg["provider:"+strings.ToLower(strings.TrimSpace(name))] = l
+	}
+	for model, l := range models {
+		model = strings.TrimSpace(model)
+		if _, m, ok := splitProviderPrefix(model); ok {
+			model = m
+		}
+		limitCfg["model:"+model] = l
+	}
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = 1
+	}
+	retryPlan = retry
+}
+
+// limitersFor returns the provider and model limiters that apply to cfg.
+func limitersFor(cfg apiConfig) []*limiter {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	var out []*limiter
+	for _, key := range []string{"provider:" + cfg.Kind.String(), "model:" + cfg.Model} {
+		l, ok := limitCfg[key]
+		if !ok {
+			continue
+		}
+		lim := limiters[key]
+		if lim == nil {
+			lim = newLimiter(l)
+			limiters[key] = lim
+		}
+		out = append(out, lim)
+	}
+	return out
+}
+
+type limiter struct {
+	sem chan struct{} // nil → no concurrency cap
+
+	mu          sync.Mutex
+	req, tok    *bucket
+	pausedUntil time.Time
+}
+
+func newLimiter(l Limit) *limiter {
+	lim := &limiter{}
+	if l.Concurrency > 0 {
+		lim.sem = make(chan struct{}, l.Concurrency)
+	}
+	if l.RPM > 0 {
+		lim.req = newBucket(l.RPM)
+	}
+	if l.TPM > 0 {
+		lim.tok = newBucket(l.TPM)
+	}
+	return lim
+}
+
+// acquire waits out any shared pause, takes a concurrency slot and reserves
+// one request plus tokens from the per-minute budgets.
+func (l *limiter) acquire(ctx context.Context, tokens int) error {
+	for {
+		l.mu.Lock()
+		d := time.Until(l.pausedUntil)
+		l.mu.Unlock()
+		if d <= 0 {
+			break
+		}
+		if err := sleepCtx(ctx, d); err != nil {
+			return err
+		}
+	}
+	if l.sem != nil {
+		select {
+		case l.sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	l.mu.Lock()
+	now := time.Now()
+	wait := max(l.req.take(1, now), l.tok.take(float64(tokens), now))
+	l.mu.Unlock()
+	if err := sleepCtx(ctx, wait); err != nil {
+		l.release()
+		return err
+	}
+	return nil
+}
+
+func (l *limiter) release() {
+	if l.sem != nil {
+		<-l.sem
+	}
+}
+
+// settle refunds (or charges) the difference between the token estimate
+// and the usage the provider reported.
+func (l *limiter) settle(estimated, actual int) {
+	if actual <= 0 {
+		return
+	}
+	l.mu.Lock()
+	l.tok.refund(float64(estimated - actual))
+	l.mu.Unlock()
+}
+
+func (l *limiter) pause(d time.Duration) {
+	l.mu.Lock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	l.mu.Unlock()
+}
+
+// bucket is a token bucket refilled continuously at perMin per minute. It
+// starts full and may go into debt; take reports how long to wait.
+type bucket struct {
+	perMin float64
+	level  float64
+	last   time.Time
+}
+
+func newBucket(perMin int) *bucket {
+	return &bucket{perMin: float64(perMin), level: float64(perMin), last: time.Now()}
+}
+
+func (b *bucket) take(n float64, now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+	rate := b.perMin / 60
+	b.level = min(b.perMin, b.level+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	b.level -= n
+	if b.level >= 0 {
+		return 0
+	}
+	return time.Duration(-b.level / rate * float64(time.Second))
+}
+
+func (b *bucket) refund(n float64) {
+	if b != nil {
+		b.level = min(b.perMin, b.level+n)
+	}
+}
+
+// doLimited sends the request built by newReq under cfg's limits, retrying
+// 429/5xx per the shared policy. It returns the final response body and
+// status; transport errors are returned as is.
+func doLimited(ctx context.Context, cfg apiConfig, body []byte, newReq func() (*http.Request, error)) (int, []byte, error) {
+	lims := limitersFor(cfg)
+	limitsMu.Lock()
+	policy := retryPlan
+	limitsMu.Unlock()
+	est := estimateTokens(body)
+	client := &http.Client{Timeout: 45 * time.Second}
+
+	for attempt := 1; ; attempt++ {
+		var held []*limiter
+		release := func() {
+			for _, l := range held {
+				l.release()
+			}
+		}
+		for _, l := range lims {
+			if err := l.acquire(ctx, est); err != nil {
+				release()
+				return 0, nil, err
+			}
+			held = append(held, l)
+		}
+
+		req, err := newReq()
+		if err != nil {
+			release()
+			return 0, nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			release()
+			return 0, nil, err
+		}
+		out, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		release()
+
+		if in, outTok := reportedUsage(out); in+outTok > 0 {
+			recordUsage(cfg.Model, in, outTok)
+			for _, l := range lims {
+				l.settle(est, in+outTok)
+			}
+		}
+		if !retryable(resp.StatusCode) || attempt >= policy.MaxAttempts {
+			return resp.StatusCode, out, nil
+		}
+		delay := retryAfter(resp.Header.Get("Retry-After"))
+		if delay <= 0 {
+			delay = backoff(policy, attempt)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			for _, l := range lims {
+				l.pause(delay)
+			}
+		}
+		if err := sleepCtx(ctx, delay); err != nil {
+			return resp.StatusCode, out, nil
+		}
+	}
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout, 529: // 529: Anthropic overloaded
+		return true
+	}
+	return false
+}
+
+// backoff doubles from policy.Base per attempt (capped at policy.Max) and
+// jitters by ±50% so parallel workers do not retry in lockstep.
+func backoff(p RetryPolicy, attempt int) time.Duration {
+	d := p.Base << (attempt - 1)
+	if d <= 0 || (p.Max > 0 && d > p.Max) {
+		d = p.Max
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d))) + d/2
+}
+
+// retryAfter parses a Retry-After header (seconds or HTTP date).
+func retryAfter(v string) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// estimateTokens guesses the token cost of a request before it is sent:
+// about four bytes per prompt token plus the output cap when one is set.
+func estimateTokens(body []byte) int {
+	var p struct {
+		MaxTokens       int `json:"max_tokens"`
+		MaxOutputTokens int `json:"max_output_tokens"`
+		Gen             struct {
+			MaxOutputTokens int `json:"maxOutputTokens"`
+		} `json:"generationConfig"`
+	}
+	_ = json.Unmarshal(body, &p)
+	return len(body)/4 + max(p.MaxTokens, p.MaxOutputTokens, p.Gen.MaxOutputTokens)
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
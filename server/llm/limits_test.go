@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPostJSONRetries429(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0.01")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+	SetLimits(nil, nil, RetryPolicy{MaxAttempts: 3, Base: time.Millisecond, Max: 10 * time.Millisecond})
+	defer SetLimits(nil, nil, DefaultRetry)
+
+	cfg := apiConfig{HeaderName: "Authorization", APIKey: "k"}
+	body, err := postJSON(context.Background(), cfg, srv.URL, map[string]any{}, nil)
+	if err != nil || string(body) != `{"ok":true}` || calls.Load() != 3 {
+		t.Fatalf("body=%q err=%v calls=%d", body, err, calls.Load())
+	}
+
+	calls.Store(-10)
+	if _, err := postJSON(context.Background(), cfg, srv.URL, map[string]any{}, nil); err == nil {
+		t.Fatal("expected an error once attempts are exhausted")
+	}
+}
+
+func TestLimitsCapConcurrencyPerModel(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		inFlight.Add(-1)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	SetLimits(map[string]Limit{"openai": {Concurrency: 4}}, map[string]Limit{"anthropic:m": {Concurrency: 2}}, DefaultRetry)
+	defer SetLimits(nil, nil, DefaultRetry)
+
+	cfg := apiConfig{Model: "m", HeaderName: "Authorization", APIKey: "k"}
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := postJSON(context.Background(), cfg, srv.URL, map[string]any{}, nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if peak.Load() != 2 {
+		t.Fatalf("peak concurrency = %d, want 2", peak.Load())
+	}
+}
+
+func TestBucketWaitsWhenOverBudget(t *testing.T) {
+	now := time.Now()
+	b := &bucket{perMin: 60, level: 1, last: now}
+	if d := b.take(1, now); d != 0 {
+		t.Fatalf("first take waited %v", d)
+	}
+	if d := b.take(1, now); d != time.Second {
+		t.Fatalf("second take waited %v, want 1s at 60/min", d)
+	}
+}
+
+func TestReportedUsage(t *testing.T) {
+	for body, want := range map[string][2]int{
+		`{"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`:                   {10, 5},
+		`{"usage":{"input_tokens":7,"output_tokens":3}}`:                                           {7, 3},
+		`{"usageMetadata":{"promptTokenCount":4,"candidatesTokenCount":2,"thoughtsTokenCount":6}}`: {4, 8},
+		`{"choices":[]}`: {0, 0},
+	} {
+		if in, out := reportedUsage([]byte(body)); in != want[0] || out != want[1] {
+			t.Errorf("%s → %d/%d, want %v", body, in, out, want)
+		}
+	}
+	if c := (Usage{InputTokens: 2_000_000, OutputTokens: 500_000}).Cost(0.4, 1.6); c != 1.6 {
+		t.Errorf("cost = %v", c)
+	}
+}
@@ -0,0 +1,180 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPingChooseActionAnthropicForcesTool(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/messages" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if k := r.Header.Get("x-api-key"); k != "ak" {
+			t.Errorf("x-api-key = %q", k)
+		}
+		if v := r.Header.Get("anthropic-version"); v != anthropicVersion {
+			t.Errorf("anthropic-version = %q", v)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"content":[
+			{"type":"text","text":"ignored"},
+			{"type":"tool_use","name":"poker_action","input":{"action":"raise","amount":300}}
+		]}`))
+	}))
+	defer srv.Close()
+	t.Setenv("ANTHROPIC_API_BASE", srv.URL)
+	t.Setenv("ANTHROPIC_API_KEY", "ak")
+
+	act, amt, _, err := PingChooseAction(context.Background(), "anthropic:claude-test", "sys", "usr",
+		[]string{"fold", "call", "raise"}, 200, 1000, PingOptions{})
+	if err != nil {
+		t.Fatalf("PingChooseAction: %v", err)
+	}
+	if act != "raise" || amt == nil || *amt != 300 {
+		t.Fatalf("got %s %v", act, amt)
+	}
+	if got["model"] != "claude-test" {
+		t.Fatalf("model prefix not stripped: %v", got["model"])
+	}
+	tc, _ := got["tool_choice"].(map[string]any)
+	if tc["type"] != "tool" || tc["name"] != "poker_action" {
+		t.Fatalf("tool_choice = %v", got["tool_choice"])
+	}
+	if got["max_tokens"] != float64(defaultAnthropicMaxTokens) {
+		t.Fatalf("max_tokens = %v", got["max_tokens"])
+	}
+}
+
+func TestPingReplyAnthropicThinking(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"content":[
+			{"type":"thinking","thinking":"pot odds are fine"},
+			{"type":"text","text":"{\"action\":\"call\"}"}
+		]}`))
+	}))
+	defer srv.Close()
+	t.Setenv("ANTHROPIC_API_BASE", srv.URL)
+	t.Setenv("ANTHROPIC_API_KEY", "ak")
+
+	n := 200
+	rep, err := PingReply(context.Background(), "anthropic:claude-test", "sys", "usr",
+		PingOptions{ReasoningEffort: "low", MaxOutputTokens: &n})
+	if err != nil {
+		t.Fatalf("PingReply: %v", err)
+	}
+	if rep.Text != `{"action":"call"}` || rep.Reasoning != "pot odds are fine" {
+		t.Fatalf("reply = %+v", rep)
+	}
+	th, _ := got["thinking"].(map[string]any)
+	if th["budget_tokens"] != float64(1024) {
+		t.Fatalf("thinking = %v", got["thinking"])
+	}
+	if got["max_tokens"] != float64(1224) {
+		t.Fatalf("max_tokens = %v, want budget+answer", got["max_tokens"])
+	}
+}
+
+func TestPingChooseActionGemini(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-test:generateContent" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if k := r.Header.Get("x-goog-api-key"); k != "gk" {
+			t.Errorf("x-goog-api-key = %q", k)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"parts":[
+			{"text":"fold is too weak","thought":true},
+			{"text":"ignored"},
+			{"functionCall":{"name":"poker_action","args":{"action":"call","amount":null}}}
+		]}}]}`))
+	}))
+	defer srv.Close()
+	t.Setenv("GEMINI_API_BASE", srv.URL)
+	t.Setenv("GEMINI_API_KEY", "gk")
+
+	act, amt, rep, err := PingChooseAction(context.Background(), "gemini:gemini-test", "sys", "usr",
+		[]string{"fold", "call"}, 0, 0, PingOptions{IncludeReasoning: true, ReasoningEffort: "medium"})
+	if err != nil {
+		t.Fatalf("PingChooseAction: %v", err)
+	}
+	if act != "call" || amt != nil {
+		t.Fatalf("got %s %v", act, amt)
+	}
+	if rep.Reasoning != "fold is too weak" {
+		t.Fatalf("reasoning = %q", rep.Reasoning)
+	}
+	gen, _ := got["generationConfig"].(map[string]any)
+	if gen["responseMimeType"] != nil || gen["responseJsonSchema"] != nil {
+		t.Fatalf("JSON output requested alongside the forced function: %v", gen)
+	}
+	tools, _ := got["tools"].([]any)
+	if len(tools) != 1 {
+		t.Fatalf("tools = %v", got["tools"])
+	}
+	decls, _ := tools[0].(map[string]any)["functionDeclarations"].([]any)
+	if len(decls) != 1 || decls[0].(map[string]any)["name"] != "poker_action" || decls[0].(map[string]any)["parametersJsonSchema"] == nil {
+		t.Fatalf("functionDeclarations = %v", decls)
+	}
+	fcc, _ := got["toolConfig"].(map[string]any)["functionCallingConfig"].(map[string]any)
+	if fcc["mode"] != "ANY" || len(fcc["allowedFunctionNames"].([]any)) != 1 {
+		t.Fatalf("toolConfig = %v", got["toolConfig"])
+	}
+	tc, _ := gen["thinkingConfig"].(map[string]any)
+	if tc["thinkingBudget"] != float64(4096) || tc["includeThoughts"] != true {
+		t.Fatalf("thinkingConfig = %v", tc)
+	}
+}
+
+func TestPingReplyGeminiJSONOnlyWhenAsked(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"call"}]}}]}`))
+	}))
+	defer srv.Close()
+	t.Setenv("GEMINI_API_BASE", srv.URL)
+	t.Setenv("GEMINI_API_KEY", "gk")
+
+	for _, jsonObject := range []bool{false, true} {
+		rep, err := PingReply(context.Background(), "gemini:gemini-test", "sys", "usr", PingOptions{JSONObject: jsonObject})
+		if err != nil || rep.Text != "call" {
+			t.Fatalf("PingReply: %q, %v", rep.Text, err)
+		}
+		gen, _ := got["generationConfig"].(map[string]any)
+		if want := map[bool]any{false: nil, true: "application/json"}[jsonObject]; gen["responseMimeType"] != want {
+			t.Errorf("JSONObject=%v: responseMimeType = %v", jsonObject, gen["responseMimeType"])
+		}
+		if got["tools"] != nil {
+			t.Errorf("tools sent without a schema: %v", got["tools"])
+		}
+	}
+}
+
+func TestResolveAPIConfigNativeProvider(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "oa")
+	t.Setenv("LLM_PROVIDER", "gemini")
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("GOOGLE_API_KEY", "gk")
+	cfg, err := resolveAPIConfig("gemini-2.5-flash", Endpoint{})
+	if err != nil {
+		t.Fatalf("resolveAPIConfig: %v", err)
+	}
+	if cfg.Kind != providerGemini || cfg.APIKey != "gk" || cfg.HeaderName != "x-goog-api-key" {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+
+	// OpenRouter-style slugs with a colon suffix are not native prefixes.
+	if _, _, ok := splitProviderPrefix("google/gemini-2.5-pro:free"); ok {
+		t.Fatalf("slug with variant suffix treated as native prefix")
+	}
+}
@@ -11,7 +11,6 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"time"
 )
 
 // PingOptions controls JSON mode + reasoning + tokens.
@@ -21,6 +20,23 @@ type PingOptions struct {
 	StructuredSchemaName string
 	StructuredSchema     map[string]any
 	StructuredStrict     bool
+	// IncludeReasoning asks providers that support it (OpenRouter) to return
+	// the model's reasoning alongside the answer.
+	IncludeReasoning bool
+	// WithComment adds a required short "comment" field to the action schema.
+	WithComment bool
+	// Endpoint carries per-player provider overrides; zero means env only.
+	Endpoint Endpoint
+	// JSONObject asks for a bare JSON object when there is no schema. The
+	// chat/completions path always uses JSON mode; Gemini only when set.
+	JSONObject bool
+}
+
+// Reply is a chat completion answer plus any reasoning text the provider
+// returned (message.reasoning or message.reasoning_content).
+type Reply struct {
+	Text      string
+	Reasoning string
 }
 
 // PingText sends a minimal request to the chat/completions API and returns text.
@@ -30,9 +46,22 @@ func PingText(ctx context.Context, model, system, user string) (string, error) {
 
 // PingTextWithOpts lets you pass custom knobs (used by PingText via env).
 func PingTextWithOpts(ctx context.Context, model, system, user string, opts PingOptions) (string, error) {
-	cfg, err := resolveAPIConfig(model)
+	rep, err := PingReply(ctx, model, system, user, opts)
+	return rep.Text, err
+}
+
+// PingReply is PingTextWithOpts but also returns provider reasoning text.
+func PingReply(ctx context.Context, model, system, user string, opts PingOptions) (Reply, error) {
+	opts = opts.withDefaults()
+	cfg, err := resolveAPIConfig(model, opts.Endpoint)
 	if err != nil {
-		return "", err
+		return Reply{}, err
+	}
+	switch cfg.Kind {
+	case providerAnthropic:
+		return pingAnthropic(ctx, cfg, system, user, opts)
+	case providerGemini:
+		return pingGemini(ctx, cfg, system, user, opts)
 	}
 
 	payload := map[string]any{
@@ -60,56 +89,43 @@ func PingTextWithOpts(ctx context.Context, model, system, user string, opts Ping
 	} else {
 		payload["response_format"] = map[string]any{"type": "json_object"}
 	}
+	if opts.IncludeReasoning && cfg.Kind == providerOpenRouter {
+		payload["include_reasoning"] = true
+	}
 	applyTuningFromEnv(payload, cfg.Kind == providerOpenRouter)
+	cfg.Endpoint.overrideTuning(payload)
 
-	b, _ := json.Marshal(payload)
-	url := cfg.BaseURL + "/chat/completions"
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set(cfg.HeaderName, cfg.HeaderPrefix+cfg.APIKey)
+	var headers map[string]string
 	if cfg.Organization != "" {
-		req.Header.Set("OpenAI-Organization", cfg.Organization)
+		headers = map[string]string{"OpenAI-Organization": cfg.Organization}
 	}
-	for k, v := range cfg.ExtraHeaders {
-		setHeaderPreserveCase(req.Header, k, v)
-	}
-
-	client := &http.Client{Timeout: 45 * time.Second}
-	resp, err := client.Do(req)
+	body, err := postJSON(ctx, cfg, cfg.BaseURL+"/chat/completions", payload, headers)
 	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	var buf bytes.Buffer
-	_, _ = buf.ReadFrom(resp.Body)
-	body := buf.Bytes()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", fmt.Errorf("openai http %d: %s", resp.StatusCode, truncate(string(body), 800))
+		return Reply{}, fmt.Errorf("openai %w", err)
 	}
 
 	var cc struct {
 		Choices []struct {
 			Message struct {
-				Content string `json:"content"`
+				Content          string `json:"content"`
+				Reasoning        string `json:"reasoning"`
+				ReasoningContent string `json:"reasoning_content"`
 			} `json:"message"`
 		} `json:"choices"`
 	}
 	if err := json.Unmarshal(body, &cc); err != nil {
-		return "", err
+		return Reply{}, err
 	}
 	if len(cc.Choices) == 0 {
-		return "", errors.New("no choices returned")
+		return Reply{}, errors.New("no choices returned")
 	}
-	return cc.Choices[0].Message.Content, nil
+	msg := cc.Choices[0].Message
+	return Reply{Text: msg.Content, Reasoning: coalesce(msg.Reasoning, msg.ReasoningContent)}, nil
 }
 
-// PingChooseAction requests a structured JSON action from the model.
-func PingChooseAction(ctx context.Context, model, system, user string, legal []string, minTo, maxTo int, opts PingOptions) (string, *int, string, error) {
+// PingChooseAction requests a structured JSON action from the model. The
+// returned Reply carries the raw text and any provider reasoning.
+func PingChooseAction(ctx context.Context, model, system, user string, legal []string, minTo, maxTo int, opts PingOptions) (string, *int, Reply, error) {
 	schema := map[string]any{
 		"type":                 "object",
 		"additionalProperties": false,
@@ -128,37 +144,61 @@ func PingChooseAction(ctx context.Context, model, system, user string, legal []s
 		},
 		"required": []string{"action"},
 	}
+	if opts.WithComment {
+		AddCommentField(schema)
+	}
 	opts.StructuredSchema = schema
 	opts.StructuredSchemaName = coalesce(opts.StructuredSchemaName, "poker_action")
 	opts.StructuredStrict = true
 
-	text, err := PingTextWithOpts(ctx, model, system, user, opts)
+	rep, err := PingReply(ctx, model, system, user, opts)
 	if err != nil {
-		return "", nil, text, err
+		return "", nil, rep, err
 	}
 
-	raw := strings.TrimSpace(text)
+	raw := strings.TrimSpace(rep.Text)
+	rep.Text = raw
 	if raw == "" {
-		return "", nil, raw, errors.New("empty response")
+		return "", nil, rep, errors.New("empty response")
 	}
 
 	var parsed map[string]any
 	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
 		if cleaned := extractJSONObject(raw); cleaned != "" {
 			if err2 := json.Unmarshal([]byte(cleaned), &parsed); err2 != nil {
-				return "", nil, raw, err
+				return "", nil, rep, err
 			}
 		} else {
-			return "", nil, raw, err
+			return "", nil, rep, err
 		}
 	}
 	act, amt, ok := coerceActionMap(parsed, legal, minTo, maxTo)
 	if !ok {
-		return "", nil, raw, errors.New("no valid action in response")
+		return "", nil, rep, errors.New("no valid action in response")
+	}
+	return act, amt, rep, nil
+}
+
+// AddCommentField extends an action schema with a required short rationale.
+// Strict structured outputs do not support maxLength, so the length limit is
+// left to the prompt and to the truncation applied after parsing.
+func AddCommentField(schema map[string]any) {
+	props, _ := schema["properties"].(map[string]any)
+	if props == nil {
+		return
+	}
+	props["comment"] = map[string]any{
+		"type":        "string",
+		"description": "One-sentence rationale for the action",
+	}
+	if req, ok := schema["required"].([]string); ok {
+		schema["required"] = append(req, "comment")
 	}
-	return act, amt, raw, nil
 }
 
+// CommentMaxLen bounds stored rationales (matches agent.ActionOut.Comment).
+const CommentMaxLen = 120
+
 func applyTuningFromEnv(m map[string]any, preferOpenRouter bool) {
 	if v := envWithFallback(preferOpenRouter, "OPENAI_TEMPERATURE", "OPENROUTER_TEMPERATURE"); v != "" {
 		if f, err := strconv.ParseFloat(v, 64); err == nil {
@@ -284,6 +324,39 @@ func envWithFallback(preferOpenRouter bool, openAIKey, openRouterKey string) str
 	return ""
 }
 
+// postJSON sends payload to url with cfg's auth and extra headers and
+// returns the body of a 2xx response. Requests go through the provider and
+// model limits and the shared 429/5xx retry policy (see SetLimits).
+func postJSON(ctx context.Context, cfg apiConfig, url string, payload any, headers map[string]string) ([]byte, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	status, body, err := doLimited(ctx, cfg, b, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set(cfg.HeaderName, cfg.HeaderPrefix+cfg.APIKey)
+		for k, v := range cfg.ExtraHeaders {
+			setHeaderPreserveCase(req.Header, k, v)
+		}
+		for k, v := range headers {
+			setHeaderPreserveCase(req.Header, k, v)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("http %d: %s", status, truncate(string(body), 800))
+	}
+	return body, nil
+}
+
 func setHeaderPreserveCase(h http.Header, key, value string) {
 	k := strings.TrimSpace(key)
 	v := strings.TrimSpace(value)
@@ -30,3 +30,23 @@ func TestSetHeaderPreserveCase(t *testing.T) {
 		t.Fatalf("expected blank header values to be skipped, got %q", got)
 	}
 }
+
+func TestAddCommentFieldStrict(t *testing.T) {
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           map[string]any{"action": map[string]any{"type": "string"}},
+		"required":             []string{"action"},
+		"additionalProperties": false,
+	}
+	AddCommentField(schema)
+	c, _ := schema["properties"].(map[string]any)["comment"].(map[string]any)
+	if c["type"] != "string" {
+		t.Fatalf("comment property = %v", c)
+	}
+	if _, ok := c["maxLength"]; ok {
+		t.Fatalf("strict schema carries maxLength: %v", c)
+	}
+	if req := schema["required"].([]string); len(req) != 2 || req[1] != "comment" {
+		t.Fatalf("required = %v", req)
+	}
+}
@@ -2,6 +2,7 @@ package llm
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"strings"
 )
@@ -11,6 +12,8 @@ type providerKind int
 const (
 	providerOpenAI providerKind = iota
 	providerOpenRouter
+	providerAnthropic
+	providerGemini
 )
 
 type apiConfig struct {
@@ -22,12 +25,20 @@ type apiConfig struct {
 	HeaderPrefix string
 	Organization string
 	ExtraHeaders map[string]string
+	Endpoint     Endpoint
 }
 
-func resolveAPIConfig(model string) (apiConfig, error) {
+// resolveAPIConfig derives provider, base URL and credentials for model from
+// the env, then applies any per-caller overrides in ep.
+func resolveAPIConfig(model string, ep Endpoint) (apiConfig, error) {
 	cfg := apiConfig{
 		Model:        strings.TrimSpace(model),
 		ExtraHeaders: map[string]string{},
+		Endpoint:     ep,
+	}
+	epKind, epHasKind, err := ep.providerKind()
+	if err != nil {
+		return apiConfig{}, err
 	}
 
 	preferOpenRouter := preferOpenRouterEnv()
@@ -50,8 +61,18 @@ func resolveAPIConfig(model string) (apiConfig, error) {
 		case "openai":
 			cfg.Kind = providerOpenAI
 			manualOverride = true
+		case "anthropic":
+			cfg.Kind = providerAnthropic
+			manualOverride = true
+		case "gemini", "google":
+			cfg.Kind = providerGemini
+			manualOverride = true
 		}
 	}
+	if epHasKind {
+		cfg.Kind = epKind
+		manualOverride = true
+	}
 
 	if cfg.Model == "" {
 		if cfg.Kind == providerOpenRouter {
@@ -65,6 +86,16 @@ func resolveAPIConfig(model string) (apiConfig, error) {
 		return apiConfig{}, errors.New("model missing: set OPENAI_MODEL/OPENROUTER_MODEL or pass a value")
 	}
 
+	// "anthropic:<model>" / "gemini:<model>" select a native adapter for this
+	// model only, regardless of LLM_PROVIDER.
+	if provider, name, ok := splitProviderPrefix(cfg.Model); ok {
+		cfg.Kind, cfg.Model = provider, name
+		return resolveNativeConfig(cfg)
+	}
+	if cfg.Kind == providerAnthropic || cfg.Kind == providerGemini {
+		return resolveNativeConfig(cfg)
+	}
+
 	if !manualOverride {
 		if provider, ok := detectProviderFromModel(cfg.Model); ok {
 			cfg.Kind = provider
@@ -72,6 +103,7 @@ func resolveAPIConfig(model string) (apiConfig, error) {
 	}
 
 	base := firstNonEmpty(
+		ep.BaseURL,
 		os.Getenv("OPENAI_API_BASE"),
 		os.Getenv("OPENAI_BASE_URL"),
 		os.Getenv("OPENROUTER_API_BASE"),
@@ -106,6 +138,12 @@ func resolveAPIConfig(model string) (apiConfig, error) {
 			cfg.APIKey = openRouterKey
 		}
 	}
+	if key, ok, err := ep.apiKey(); ok {
+		if err != nil {
+			return apiConfig{}, err
+		}
+		cfg.APIKey = key
+	}
 	if cfg.APIKey == "" {
 		return apiConfig{}, errors.New("API key missing: set OPENAI_API_KEY or OPENROUTER_API_KEY")
 	}
@@ -156,6 +194,53 @@ func resolveAPIConfig(model string) (apiConfig, error) {
 	return cfg, nil
 }
 
+// resolveNativeConfig fills base URL and credentials for the Anthropic and
+// Gemini adapters. These never fall back to OpenAI/OpenRouter keys.
+func resolveNativeConfig(cfg apiConfig) (apiConfig, error) {
+	var missing string
+	switch cfg.Kind {
+	case providerAnthropic:
+		cfg.BaseURL = firstNonEmpty(cfg.Endpoint.BaseURL, os.Getenv("ANTHROPIC_API_BASE"), os.Getenv("ANTHROPIC_BASE_URL"), "https://api.anthropic.com/v1")
+		cfg.APIKey = firstNonEmpty(os.Getenv("ANTHROPIC_API_KEY"))
+		cfg.HeaderName = "x-api-key"
+		missing = "API key missing: set ANTHROPIC_API_KEY"
+	case providerGemini:
+		cfg.BaseURL = firstNonEmpty(cfg.Endpoint.BaseURL, os.Getenv("GEMINI_API_BASE"), os.Getenv("GEMINI_BASE_URL"), "https://generativelanguage.googleapis.com/v1beta")
+		cfg.APIKey = firstNonEmpty(os.Getenv("GEMINI_API_KEY"), os.Getenv("GOOGLE_API_KEY"))
+		cfg.HeaderName = "x-goog-api-key"
+		missing = "API key missing: set GEMINI_API_KEY or GOOGLE_API_KEY"
+	default:
+		return apiConfig{}, fmt.Errorf("provider %d has no native adapter", cfg.Kind)
+	}
+	if key, ok, err := cfg.Endpoint.apiKey(); ok {
+		if err != nil {
+			return apiConfig{}, err
+		}
+		cfg.APIKey = key
+	}
+	if cfg.APIKey == "" {
+		return apiConfig{}, errors.New(missing)
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	cfg.HeaderPrefix = ""
+	return cfg, nil
+}
+
+// splitProviderPrefix recognises "anthropic:" and "gemini:" model prefixes.
+func splitProviderPrefix(model string) (providerKind, string, bool) {
+	prefix, name, ok := strings.Cut(strings.TrimSpace(model), ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return providerOpenAI, model, false
+	}
+	switch strings.ToLower(prefix) {
+	case "anthropic", "claude":
+		return providerAnthropic, strings.TrimSpace(name), true
+	case "gemini", "google":
+		return providerGemini, strings.TrimSpace(name), true
+	}
+	return providerOpenAI, model, false
+}
+
 func firstNonEmpty(values ...string) string {
 	for _, v := range values {
 		if strings.TrimSpace(v) != "" {
@@ -169,6 +254,62 @@ func PreferOpenRouter() bool {
 	return preferOpenRouterEnv()
 }
 
+func (k providerKind) String() string {
+	switch k {
+	case providerOpenRouter:
+		return "openrouter"
+	case providerAnthropic:
+		return "anthropic"
+	case providerGemini:
+		return "gemini"
+	}
+	return "openai"
+}
+
+// CheckKey reports whether model can be reached with ep: it resolves the
+// provider the same way a request would and fails when that provider's key
+// (or the endpoint's own key source) is missing.
+func CheckKey(model string, ep Endpoint) error {
+	_, err := resolveAPIConfig(model, ep)
+	return err
+}
+
+// ProviderName reports which provider model would be routed to with ep.
+// It falls back to env precedence when credentials are not configured.
+func ProviderName(model string, ep Endpoint) string {
+	if k, _, ok := splitProviderPrefix(model); ok {
+		return k.String()
+	}
+	if cfg, err := resolveAPIConfig(model, ep); err == nil {
+		return cfg.Kind.String()
+	}
+	if k, ok, _ := ep.providerKind(); ok {
+		return k.String()
+	}
+	if preferOpenRouterEnv() {
+		return providerOpenRouter.String()
+	}
+	return providerOpenAI.String()
+}
+
+// ModelFamily strips routing details from a model identifier so the same
+// model reached through different providers groups together:
+// "openrouter/openai/gpt-5-mini", "openai/gpt-5-mini:free" and "gpt-5-mini"
+// all map to "gpt-5-mini".
+func ModelFamily(model string) string {
+	m := strings.TrimSpace(model)
+	if _, name, ok := splitProviderPrefix(m); ok {
+		m = name
+	}
+	if i := strings.LastIndex(m, "/"); i >= 0 {
+		m = m[i+1:]
+	}
+	if i := strings.Index(m, ":"); i > 0 {
+		m = m[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(m))
+}
+
 func detectProviderFromModel(model string) (providerKind, bool) {
 	normalized := strings.ToLower(strings.TrimSpace(model))
 	if normalized == "" {
@@ -5,7 +5,7 @@ import "testing"
 func TestResolveAPIConfigOpenRouterDefaults(t *testing.T) {
 	t.Setenv("OPENAI_API_BASE", "https://openrouter.ai/api/v1")
 	t.Setenv("OPENAI_API_KEY", "test-key")
-	cfg, err := resolveAPIConfig("meta-llama/llama-3.1-70b-instruct")
+	cfg, err := resolveAPIConfig("meta-llama/llama-3.1-70b-instruct", Endpoint{})
 	if err != nil {
 		t.Fatalf("resolveAPIConfig returned error: %v", err)
 	}
@@ -28,7 +28,7 @@ func TestResolveAPIConfigOpenRouterOverrides(t *testing.T) {
 	t.Setenv("OPENAI_API_KEY", "test-key")
 	t.Setenv("OPENROUTER_SITE_URL", "https://example.com/app")
 	t.Setenv("OPENROUTER_TITLE", "Custom Title")
-	cfg, err := resolveAPIConfig("meta-llama/llama-3.1-70b-instruct")
+	cfg, err := resolveAPIConfig("meta-llama/llama-3.1-70b-instruct", Endpoint{})
 	if err != nil {
 		t.Fatalf("resolveAPIConfig returned error: %v", err)
 	}
@@ -45,3 +45,18 @@ func TestResolveAPIConfigOpenRouterOverrides(t *testing.T) {
 		t.Fatalf("unexpected X-Title: %q", got)
 	}
 }
+
+func TestModelFamily(t *testing.T) {
+	cases := map[string]string{
+		"gpt-5-mini":                   "gpt-5-mini",
+		"openai/gpt-5-mini:free":       "gpt-5-mini",
+		"openrouter/openai/GPT-5-mini": "gpt-5-mini",
+		"anthropic:claude-sonnet-4-5":  "claude-sonnet-4-5",
+		"gemini:gemini-2.5-pro":        "gemini-2.5-pro",
+	}
+	for in, want := range cases {
+		if got := ModelFamily(in); got != want {
+			t.Errorf("ModelFamily(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// Usage is the token usage providers reported for one model since start.
+type Usage struct {
+	Requests     int64
+	InputTokens  int64
+	OutputTokens int64
+}
+
+// Cost prices u at USD per million input and output tokens.
+func (u Usage) Cost(inputPerM, outputPerM float64) float64 {
+	return (float64(u.InputTokens)*inputPerM + float64(u.OutputTokens)*outputPerM) / 1e6
+}
+
+var (
+	usageMu      sync.Mutex
+	usageByModel = map[string]Usage{}
+)
+
+func recordUsage(model string, in, out int) {
+	usageMu.Lock()
+	u := usageByModel[model]
+	u.Requests++
+	u.InputTokens += int64(in)
+	u.OutputTokens += int64(out)
+	usageByModel[model] = u
+	usageMu.Unlock()
+}
+
+// UsageFor returns the usage recorded for model; a native "anthropic:" /
+// "gemini:" prefix is ignored.
+func UsageFor(model string) Usage {
+	model = strings.TrimSpace(model)
+	if _, m, ok := splitProviderPrefix(model); ok {
+		model = m
+	}
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	return usageByModel[model]
+}
+
+// reportedUsage reads input and output token counts from an OpenAI,
+// Anthropic or Gemini response body; zeros when absent. Reasoning tokens
+// count as output.
+func reportedUsage(body []byte) (in, out int) {
+	var r struct {
+		Usage struct {
+			Prompt     int `json:"prompt_tokens"`
+			Completion int `json:"completion_tokens"`
+			Input      int `json:"input_tokens"`
+			Output     int `json:"output_tokens"`
+		} `json:"usage"`
+		UsageMetadata struct {
+			Prompt     int `json:"promptTokenCount"`
+			Candidates int `json:"candidatesTokenCount"`
+			Thoughts   int `json:"thoughtsTokenCount"`
+		} `json:"usageMetadata"`
+	}
+	if json.Unmarshal(body, &r) != nil {
+		return 0, 0
+	}
+	u, m := r.Usage, r.UsageMetadata
+	switch {
+	case u.Prompt+u.Completion > 0:
+		return u.Prompt, u.Completion
+	case u.Input+u.Output > 0:
+		return u.Input, u.Output
+	}
+	return m.Prompt, m.Candidates + m.Thoughts
+}
@@ -2,13 +2,18 @@ package main
 
 import (
 	"ai-thunderdome/server/agent"
+	"ai-thunderdome/server/aivat"
+	"ai-thunderdome/server/config"
 	"ai-thunderdome/server/engine"
 	"ai-thunderdome/server/judge"
 	"ai-thunderdome/server/llm"
+	"ai-thunderdome/server/schedule"
 	"ai-thunderdome/server/store"
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -65,6 +70,68 @@ Output format:
 - Do not add commentary or explanations.
 `
 
+// Rule blocks appended to benchSystem for other games. Pot-Limit Omaha
+// gets omahaRules then potLimitRule, exactly as before betting structures
+// were configurable, so its prompt hash is unchanged.
+const (
+	omahaRules = `- You hold four hole cards. Every showdown hand uses exactly two of them and exactly three board cards.
+- Four suited hole cards make no flush without three board cards of that suit; a four-card straight or flush on board needs two matching hole cards.
+`
+	shortDeckRules = `- The deck has 36 cards: every two, three, four and five is removed, so each rank has four cards from six to ace.
+- A flush beats a full house; all other hand rankings are the usual ones.
+- The ace plays low only in A-6-7-8-9, the lowest straight.
+- Count outs and combinations against 36 cards: pairs, sets and straights come far more often than in a full deck.
+`
+	potLimitRule = `- Betting is pot-limit: max_to is the pot-sized raise (call, then raise by the pot), capped by your stack.
+`
+	fixedLimitRules = `- Betting is fixed-limit: every bet and raise is exactly %d preflop and on the flop and %d on the turn and river, so min_to equals max_to.
+- Each street allows at most %d bets and raises (preflop the big blind is the first); raise leaves legal_actions once the cap is reached.
+- There is no size to choose: for a raise, send amount null or min_to. The sizing policy above does not apply.
+`
+)
+
+// systemPrompt is benchSystem for the configured game variant and betting
+// structure; heads-up no-limit hold'em gets benchSystem unchanged.
+func systemPrompt() string {
+	g := gameConfig()
+	limit := g.Limit()
+	if g.Variant == engine.Holdem && limit == engine.NoLimit {
+		return benchSystem
+	}
+	label := map[engine.Betting]string{engine.NoLimit: "No-Limit", engine.PotLimit: "Pot-Limit", engine.FixedLimit: "Fixed-Limit"}[limit]
+	game, rules := strings.ToLower(label)+" Texas Hold'em.", "\n"+label+" Hold'em rules:\n"
+	switch g.Variant {
+	case engine.ShortDeck:
+		game, rules = strings.ToLower(label)+" short-deck (6+) Hold'em.", "\n"+label+" Short-Deck Hold'em rules:\n"+shortDeckRules
+	case engine.PLO:
+		game, rules = label+" Omaha.", "\n"+label+" Omaha rules:\n"+omahaRules
+		if limit == engine.PotLimit {
+			game = "Pot-Limit Omaha (PLO)."
+		}
+	}
+	switch limit {
+	case engine.PotLimit:
+		rules += potLimitRule
+	case engine.FixedLimit:
+		small, big := g.BetSizes()
+		rules += fmt.Sprintf(fixedLimitRules, small, big, g.Cap())
+	}
+	return strings.Replace(benchSystem, "no-limit Texas Hold'em.", game, 1) + rules
+}
+
+// gameConfig is the engine table configuration for runSpec.Game. The
+// variant and betting names are checked at startup.
+func gameConfig() engine.Config {
+	g := runSpec.Game
+	variant, _ := engine.ParseVariant(g.Variant)
+	betting, _ := engine.ParseBetting(g.Betting)
+	return engine.Config{
+		SB: g.SB, BB: g.BB, StartStack: g.StartStack,
+		Variant: variant, Betting: betting,
+		SmallBet: g.SmallBet, BigBet: g.BigBet, RaiseCap: g.RaiseCap,
+	}
+}
+
 func c(code, s string) string {
 	if !useColor {
 		return s
@@ -93,8 +160,6 @@ func modelShort(m string) string {
 	return m[:28]
 }
 func potTag(pot int) string { return dim(fmt.Sprintf("Pot=%d", pot)) }
-func section(title string)  { fmt.Printf("\n%s %s %s\n", dim("──"), bold(title), dim("──")) }
-func sub(title string)      { fmt.Printf("%s %s\n", dim("•"), bold(title)) }
 
 //
 // ===== bootstrap =====
@@ -253,29 +318,86 @@ func main() {
 	log.SetFlags(log.LstdFlags | log.Lmicroseconds)
 	_ = godotenv.Load()
 
-	// Load API key from a file if present (before mustEnv)
+	// Load API key from a file if present (before the per-player key checks)
 	loadAPIKeyFromSecret()
 
 	useColor = (os.Getenv("NO_COLOR") == "") && (strings.TrimSpace(os.Getenv("USE_COLOR")) != "0")
 	debugState = asBool(os.Getenv("DEBUG"))
 
+	// Offline subcommands (no API key needed).
+	if len(os.Args) > 1 && os.Args[1] == "ratings" {
+		runRatingsCmd(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "equity" {
+		runEquityCmd(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		runVerifyCmd(os.Args[2:])
+		return
+	}
+
 	var migrate, duel bool
-	var duelMatrix bool
-	for _, a := range os.Args[1:] {
-		switch a {
-		case "--migrate":
+	var duelMatrix, ladder bool
+	var configPath string
+	var resumeID, resumeMatchID int64
+	args := os.Args[1:]
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; {
+		case a == "--migrate":
 			migrate = true
-		case "--duel":
+		case a == "--duel":
 			duel = true
-		case "--duel-matrix":
+		case a == "--duel-matrix":
+			duelMatrix = true
+		case a == "--ladder":
+			ladder = true
+		case a == "--config" && i+1 < len(args):
+			i++
+			configPath = args[i]
+		case strings.HasPrefix(a, "--config="):
+			configPath = strings.TrimPrefix(a, "--config=")
+		case a == "--resume-schedule" && i+1 < len(args):
+			i++
+			resumeID = parseRunID("--resume-schedule", args[i])
+		case strings.HasPrefix(a, "--resume-schedule="):
+			resumeID = parseRunID("--resume-schedule", strings.TrimPrefix(a, "--resume-schedule="))
+		case a == "--resume" && i+1 < len(args):
+			i++
+			resumeMatchID = parseRunID("--resume", args[i])
+		case strings.HasPrefix(a, "--resume="):
+			resumeMatchID = parseRunID("--resume", strings.TrimPrefix(a, "--resume="))
+		}
+	}
+
+	// Run settings: a --config file pins everything; otherwise legacy env vars.
+	runSpec = specFromEnv()
+	if configPath != "" {
+		spec, err := config.Load(configPath)
+		if err != nil {
+			log.Fatalf("config: %v", err)
+		}
+		runSpec = *spec
+		duel, duelMatrix, ladder = false, false, false
+		switch spec.Mode {
+		case config.ModeMatrix:
 			duelMatrix = true
+		case config.ModeLadder:
+			ladder = true
+		default:
+			duel = true
 		}
+		log.Printf("Loaded run config %s (mode=%s, players=%d, pairs=%d)", configPath, spec.Mode, len(spec.Players), spec.Seeds.Pairs)
 	}
 
-	// Only require the key when not doing a pure DB migrate
-	if !migrate {
-		mustEnv("OPENAI_API_KEY")
+	if _, err := engine.ParseVariant(runSpec.Game.Variant); err != nil {
+		log.Fatalf("GAME_VARIANT: %v", err)
 	}
+	if _, err := engine.ParseBetting(runSpec.Game.Betting); err != nil {
+		log.Fatalf("BETTING: %v", err)
+	}
+	applyLimits(runSpec)
 
 	gracefulOnly := !asBool(os.Getenv("STOP_IMMEDIATE"))
 	maxSeconds := atoiDef(os.Getenv("MAX_SECONDS"), 0)
@@ -311,7 +433,7 @@ func main() {
 		return false
 	}
 
-	if duel || duelMatrix {
+	if duel || duelMatrix || ladder || resumeID != 0 || resumeMatchID != 0 {
 		var db *store.DB
 		if dsn := getenv("DATABASE_URL", ""); dsn != "" {
 			p, err := store.Open(dsn)
@@ -328,10 +450,19 @@ func main() {
 				}
 			}
 		}
-		if duelMatrix {
+		switch {
+		case resumeMatchID != 0:
+			resumeMatch(checkStop, gracefulOnly, db, resumeMatchID)
+		case resumeID != 0:
+			resumeSchedule(checkStop, gracefulOnly, db, resumeID)
+		case duelMatrix:
 			runDuelMatrix(checkStop, gracefulOnly, db)
-		} else {
-			runDuel(checkStop, gracefulOnly, db)
+		case ladder:
+			runLadder(checkStop, gracefulOnly, db)
+		default:
+			a, b := loadPlayers()
+			mustPlayerKeys(a, b)
+			runDuel(checkStop, gracefulOnly, db, a, b, duelOpts{SeedBase: runSeedBase()})
 		}
 		return
 	}
@@ -363,12 +494,25 @@ func main() {
 		return
 	}
 
+	if runSpec.Rating.GlickoPeriod == config.PeriodDay {
+		go closeRatingPeriodsEvery(db, time.Hour)
+	}
+
 	r := Router(db)
 	srv := &http.Server{Addr: ":" + port, Handler: r, ReadTimeout: 15 * time.Second, WriteTimeout: 15 * time.Second}
 	log.Printf("listening on http://localhost:%s (Ctrl+C to stop)", port)
 	log.Fatal(srv.ListenAndServe())
 }
 
+// parseRunID parses the id argument of a --resume* flag.
+func parseRunID(flag, s string) int64 {
+	id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || id <= 0 {
+		log.Fatalf("%s: want a positive id, got %q", flag, s)
+	}
+	return id
+}
+
 func watchSignals(cancel context.CancelFunc) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
@@ -385,11 +529,17 @@ type Player struct {
 	Label string
 	Name  string
 	Model string
+	LLM   llm.Endpoint // per-seat provider overrides (LLM_*_A / LLM_*_B)
 	Bank  int
 	Wins  int
 }
 
-func loadPlayers(startStack int) (a, b Player) {
+// loadPlayers returns the two duel seats: from the run config when it lists
+// players, otherwise from the OPENAI_MODEL_* / OPENROUTER_MODEL_* env vars.
+func loadPlayers() (a, b Player) {
+	if len(runSpec.Players) == 2 {
+		return playerFromSpec("A", runSpec.Players[0]), playerFromSpec("B", runSpec.Players[1])
+	}
 	useOpenRouter := llm.PreferOpenRouter()
 	var ma, mb string
 	if useOpenRouter {
@@ -423,11 +573,63 @@ func loadPlayers(startStack int) (a, b Player) {
 	if ma == "" || mb == "" {
 		log.Fatal("Provide model identifiers for both seats via OPENAI_MODEL_* or OPENROUTER_MODEL_*")
 	}
-	a = Player{Label: "A", Name: "A", Model: ma, Bank: startStack}
-	b = Player{Label: "B", Name: "B", Model: mb, Bank: startStack}
+	a = Player{Label: "A", Name: "A", Model: ma, LLM: endpointFromEnv("A")}
+	b = Player{Label: "B", Name: "B", Model: mb, LLM: endpointFromEnv("B")}
 	return
 }
 
+// mustPlayerKeys exits when a seat's provider has no API key, checking each
+// player's own endpoint (api_key_env / api_key_file, native provider keys)
+// rather than assuming every seat goes through OpenAI.
+func mustPlayerKeys(ps ...Player) {
+	for _, p := range ps {
+		if err := llm.CheckKey(p.Model, p.LLM); err != nil {
+			log.Fatalf("Player %s: %v. Put it in .env (dev) or set it on the host (prod).", firstNonEmpty(p.Name, p.Model), err)
+		}
+	}
+}
+
+// endpointFromEnv reads per-seat provider overrides, e.g. for seat "B":
+// LLM_PROVIDER_B, LLM_API_BASE_B, LLM_API_KEY_ENV_B, LLM_API_KEY_FILE_B,
+// LLM_TEMPERATURE_B, LLM_TOP_P_B, LLM_REASONING_EFFORT_B, LLM_MAX_OUTPUT_TOKENS_B.
+// Unset values fall back to the global env configuration.
+func endpointFromEnv(seat string) llm.Endpoint {
+	get := func(name string) string { return strings.TrimSpace(os.Getenv(name + "_" + seat)) }
+	ep := llm.Endpoint{
+		Provider:        strings.ToLower(get("LLM_PROVIDER")),
+		BaseURL:         get("LLM_API_BASE"),
+		APIKeyEnv:       get("LLM_API_KEY_ENV"),
+		APIKeyFile:      get("LLM_API_KEY_FILE"),
+		ReasoningEffort: strings.ToLower(get("LLM_REASONING_EFFORT")),
+	}
+	switch ep.Provider {
+	case "", "openai", "openrouter", "anthropic", "gemini", "google":
+	default:
+		log.Fatalf("LLM_PROVIDER_%s=%q: want openai, openrouter, anthropic or gemini", seat, ep.Provider)
+	}
+	parseFloat := func(name string) *float64 {
+		v := get(name)
+		if v == "" {
+			return nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			log.Fatalf("%s_%s=%q: %v", name, seat, v, err)
+		}
+		return &f
+	}
+	ep.Temperature = parseFloat("LLM_TEMPERATURE")
+	ep.TopP = parseFloat("LLM_TOP_P")
+	if v := get("LLM_MAX_OUTPUT_TOKENS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			log.Fatalf("LLM_MAX_OUTPUT_TOKENS_%s=%q: want a positive integer", seat, v)
+		}
+		ep.MaxOutputTokens = &n
+	}
+	return ep
+}
+
 //
 // ===== randomness =====
 //
@@ -445,6 +647,21 @@ func (s *seedStream) next() uint64 {
 	z ^= z >> 31
 	return z
 }
+
+// matchDeckKey is the secret behind a match's shuffles: derived from base
+// when the run pins its seed, so the decks replay, and fresh otherwise.
+func matchDeckKey(base uint64) engine.DeckKey {
+	if runSpec.Seeds.Base != nil {
+		return engine.DeckKeyFromSeed(base)
+	}
+	k, err := engine.NewDeckKey()
+	if err != nil {
+		log.Printf("deck key: %v; deriving one from the seed base", err)
+		return engine.DeckKeyFromSeed(base)
+	}
+	return k
+}
+
 func secureBaseSeed() uint64 {
 	var b [8]byte
 	if _, err := rand.Read(b[:]); err == nil {
@@ -452,72 +669,130 @@ func secureBaseSeed() uint64 {
 	}
 	return uint64(time.Now().UnixNano()) ^ 0xA5A5A5A5A5A5A5A5
 }
-func deckSeedFromEnvOrCrypto() uint64 {
-	if s := os.Getenv("DECK_SEED"); s != "" {
-		if v, err := strconv.ParseInt(s, 10, 64); err == nil {
-			return uint64(v)
-		}
-	}
-	return secureBaseSeed()
-}
 
 //
 // ===== LLM call =====
 //
 
-func askAction(ctx context.Context, model string, legal []string, minRaiseTo, maxRaiseTo int, obs agent.Observation) (string, *int, error) {
+// Parse paths that can produce a decision. Every action row records one of
+// these in action_logs.parse_source so format compliance can be ranked.
+const (
+	srcTool     = "tool"     // tool/function call
+	srcSchema   = "schema"   // structured output (json_schema)
+	srcPolicy   = "policy"   // MIXED_POLICY: structured distribution, action sampled by the harness
+	srcJSON     = "json"     // legacy JSON mode, raw or code-fenced object
+	srcYAML     = "yaml"     // YAML-ish key/value lines
+	srcNL       = "nl"       // keyword heuristics over prose
+	srcFallback = "fallback" // harness substituted an action (error or illegal move)
+	srcForced   = "forced"   // harness overrode a valid model action (probe policy, FORCE_NONCHECK)
+)
+
+var parseSources = []string{srcTool, srcSchema, srcPolicy, srcJSON, srcYAML, srcNL, srcFallback, srcForced}
+
+// settleAction applies the zero-to-call probe policy and re-tags the decision
+// as forced when the policy changed what the model asked for.
+func settleAction(act string, amt *int, src string, legal []string, minRaiseTo, toCall int, rng *mrand.Rand) (agent.ActionOut, string) {
+	act2, amt2 := applyZeroProbePolicy(act, amt, legal, minRaiseTo, toCall, rng)
+	if act2 != act {
+		src = srcForced
+	}
+	return agent.ActionOut{Action: act2, Amount: amt2}, src
+}
+
+// askAction returns the chosen action (raise-to in Amount, sampled policy in
+// Policy) and the parse path (src* constant) that produced it. A non-nil
+// rng.policy enables mixed-strategy mode; curBet is the bet the actor faces.
+func askAction(ctx context.Context, model string, ep llm.Endpoint, legal []string, minRaiseTo, maxRaiseTo, curBet int, obs agent.Observation, rng handRNG) (agent.ActionOut, string, error) {
 	obsRaw, _ := json.Marshal(obs)
 	// Probe hint line (toggle with ENCOURAGE_PROBE_ZERO=1). Default is to encourage mixing checks.
-	probeEnv := strings.TrimSpace(os.Getenv("ENCOURAGE_PROBE_ZERO"))
 	probeLine := "- Mix checks with occasional probe raises; do not always raise when to_call is 0."
-	if probeEnv == "1" || strings.EqualFold(probeEnv, "true") || strings.EqualFold(probeEnv, "yes") {
+	if runSpec.Behavior.EncourageProbeZero {
 		probeLine = "- If to_call is 0 and raise is legal, you may consider a small probe (min raise) sometimes, but do not overuse it."
 	}
+	// Rationale mode (CAPTURE_RATIONALE=1) adds a short "comment" key.
+	rationale := runSpec.Behavior.CaptureRationale
+	commentKey, proseRule := "", "- No extra keys. No prose. No markdown."
+	if rationale {
+		commentKey = `,"comment":"<one sentence>"`
+		proseRule = fmt.Sprintf(`- Put a single sentence (max %d chars) explaining the decision in "comment". No other keys. No prose outside the JSON. No markdown.`, llm.CommentMaxLen)
+	}
+	amountRule := fmt.Sprintf(`- If action is "raise" or "bet", set "amount" to an integer between %d and %d (inclusive).`, minRaiseTo, maxRaiseTo)
+	sizingRule := fmt.Sprintf("- Size raises intentionally: lean larger when extracting strong value and mix in smaller sizes for probes or thin value; keep amounts inside [%d, %d].", minRaiseTo, maxRaiseTo)
+	fixed := obs.Betting == string(engine.FixedLimit)
+	if fixed {
+		amountRule = fmt.Sprintf(`- Fixed limit: a raise is always to %d; use null or %d for "amount".`, minRaiseTo, minRaiseTo)
+		sizingRule = "- There is no raise size to choose; decide only whether to fold, check, call or raise."
+	}
 	user := fmt.Sprintf(
 		`Given this observation JSON:
 %s
 
 Respond ONLY with a single compact JSON object:
-{"action":"%s","amount":null|<integer>}
+{"action":"%s","amount":null|<integer>%s}
 Rules:
 - Allowed actions are exactly %v (nothing else).
-- If action is "raise" or "bet", set "amount" to an integer between %d and %d (inclusive).
+%s
 - If action is "fold", "call", or "check", use null for "amount".
-- No extra keys. No prose. No markdown.
-- Size raises intentionally: lean larger when extracting strong value and mix in smaller sizes for probes or thin value; keep amounts inside [%d, %d].
+%s
+%s
 %s
 - Do not be afraid to raise or fold; avoid extreme passivity or aggression.`,
 		string(obsRaw),
-		strings.Join(legal, `"|"`),
-		legal, minRaiseTo, maxRaiseTo, minRaiseTo, maxRaiseTo,
+		strings.Join(legal, `"|"`), commentKey,
+		legal,
+		amountRule,
+		proseRule,
+		sizingRule,
 		probeLine,
 	)
+	rationaleSystem := ""
+	if rationale {
+		rationaleSystem = fmt.Sprintf("\n\nException to the no-commentary rule: include a one-sentence rationale (max %d characters) in the \"comment\" field.", llm.CommentMaxLen)
+	}
 	ctx2, cancel := context.WithTimeout(ctx, 40*time.Second)
 	defer cancel()
 
+	// finish attaches whatever rationale the successful path captured.
+	var comment, reasoning string
+	finish := func(out agent.ActionOut, src string) (agent.ActionOut, string, error) {
+		out.Comment = clipComment(comment)
+		out.Reasoning = strings.TrimSpace(reasoning)
+		return out, src, nil
+	}
+
+	maxTok := runSpec.Behavior.MaxOutputTokens
+	re := runSpec.Behavior.ReasoningEffort
+	// per-seat overrides win over the process-wide knobs
+	if ep.MaxOutputTokens != nil {
+		maxTok = ep.MaxOutputTokens
+	}
+	if ep.ReasoningEffort != "" {
+		re = ep.ReasoningEffort
+	}
+
+	// 0) Mixed-strategy mode: ask for a distribution and sample from it
+	if rng.policy != nil {
+		out, err := askPolicy(ctx2, model, ep, obs, curBet, rng.policy, re, maxTok, rationale)
+		if err == nil {
+			return out, srcPolicy, nil
+		}
+		if debugState {
+			log.Printf("policy fallback due to: %v", err)
+		}
+	}
+
 	// 1) Prefer tool/function calling first to force enum
-	var maxTok *int
-	if v := strings.TrimSpace(os.Getenv("OPENAI_MAX_OUTPUT_TOKENS")); v != "" {
-		lc := strings.ToLower(v)
-		if lc == "omit" || lc == "auto" {
-			maxTok = nil
-		} else if n, err := strconv.Atoi(v); err == nil && n > 0 {
-			maxTok = &n
-		}
-	}
-	useToolsEnv := strings.TrimSpace(os.Getenv("USE_TOOLS"))
-	useTools := true
-	if useToolsEnv != "" && !(useToolsEnv == "1" || strings.EqualFold(useToolsEnv, "true") || strings.EqualFold(useToolsEnv, "yes")) {
-		useTools = false
-	}
-	if useTools {
-		toolSystem := benchSystem + "\n\nYou are a poker agent. Your only job is to call the function \"pick_action\". Do not output anything else. Never explain or justify your choice. Always pick exactly one action from the provided list."
-		act, amt, raw, err := llm.PingChooseAction(ctx2, model, toolSystem, user, legal, minRaiseTo, maxRaiseTo, llm.PingOptions{MaxOutputTokens: maxTok})
+	if runSpec.Behavior.UseTools {
+		toolSystem := systemPrompt() + "\n\nYou are a poker agent. Your only job is to call the function \"pick_action\". Do not output anything else. Never explain or justify your choice. Always pick exactly one action from the provided list." + rationaleSystem
+		act, amt, rep, err := llm.PingChooseAction(ctx2, model, toolSystem, user, legal, minRaiseTo, maxRaiseTo, llm.PingOptions{MaxOutputTokens: maxTok, WithComment: rationale, IncludeReasoning: rationale, Endpoint: ep})
 		if debugState {
-			if raw != "" {
-				log.Printf("tool args raw: %s", raw)
+			if rep.Text != "" {
+				log.Printf("tool args raw: %s", rep.Text)
 			}
 		}
+		if rationale {
+			comment, reasoning = commentFromText(rep.Text), rep.Reasoning
+		}
 		if err == nil {
 			// Normalize and validate
 			act = strings.ToLower(strings.TrimSpace(act))
@@ -532,21 +807,24 @@ Rules:
 				}
 			}
 			if !valid {
-				return "", nil, fmt.Errorf("illegal action %q not in %v", act, legal)
+				return agent.ActionOut{}, "", fmt.Errorf("illegal action %q not in %v", act, legal)
+			}
+			if act == "raise" && fixed {
+				v := minRaiseTo // the one fixed-limit size, whatever the model sent
+				amt = &v
 			}
 			if act == "raise" {
 				if amt == nil {
-					return "", nil, fmt.Errorf("raise requires amount")
+					return agent.ActionOut{}, "", fmt.Errorf("raise requires amount")
 				}
 				if *amt < minRaiseTo || *amt > maxRaiseTo {
-					return "", nil, fmt.Errorf("amount %d outside [%d,%d]", *amt, minRaiseTo, maxRaiseTo)
+					return agent.ActionOut{}, "", fmt.Errorf("amount %d outside [%d,%d]", *amt, minRaiseTo, maxRaiseTo)
 				}
 			} else {
 				amt = nil
 			}
 			// Optional probe policy: flip check→min-raise with probability when to_call==0
-			act, amt = applyZeroProbePolicy(act, amt, legal, minRaiseTo, obs.ToCall)
-			return act, amt, nil
+			return finish(settleAction(act, amt, srcTool, legal, minRaiseTo, obs.ToCall, rng.probe))
 		}
 		if debugState {
 			log.Printf("tool-call fallback due to: %v", err)
@@ -564,15 +842,12 @@ Rules:
 		"required":             []string{"action"},
 		"additionalProperties": false,
 	}
-	// Reasoning effort env (validate)
-	re := strings.ToLower(strings.TrimSpace(os.Getenv("OPENAI_REASONING_EFFORT")))
-	switch re {
-	case "", "low", "medium", "high":
-	default:
-		re = ""
+	if rationale {
+		llm.AddCommentField(schema)
 	}
-	jsonSystem := benchSystem + "\n\nRespond ONLY with a minimal JSON object as specified. No prose, no markdown."
-	text, err := llm.PingTextWithOpts(ctx2, model, jsonSystem, user, llm.PingOptions{ReasoningEffort: re, MaxOutputTokens: maxTok, StructuredSchemaName: "poker_action", StructuredSchema: schema, StructuredStrict: true})
+	jsonSystem := systemPrompt() + "\n\nRespond ONLY with a minimal JSON object as specified. No prose, no markdown." + rationaleSystem
+	rep, err := llm.PingReply(ctx2, model, jsonSystem, user, llm.PingOptions{ReasoningEffort: re, MaxOutputTokens: maxTok, StructuredSchemaName: "poker_action", StructuredSchema: schema, StructuredStrict: true, IncludeReasoning: rationale, Endpoint: ep})
+	text := rep.Text
 	if debugState && text != "" {
 		log.Printf("json raw: %s", text)
 	}
@@ -582,12 +857,16 @@ Rules:
 		if e := json.Unmarshal([]byte(text), &parsed); e != nil {
 			if cleaned := extractJSONObject(text); cleaned != "" {
 				if e2 := json.Unmarshal([]byte(cleaned), &parsed); e2 != nil {
-					return "", nil, fmt.Errorf("bad JSON from model: %v\nraw=%s", e, text)
+					return agent.ActionOut{}, "", fmt.Errorf("bad JSON from model: %v\nraw=%s", e, text)
 				}
 			} else {
-				return "", nil, fmt.Errorf("bad JSON from model: %v\nraw=%s", e, text)
+				return agent.ActionOut{}, "", fmt.Errorf("bad JSON from model: %v\nraw=%s", e, text)
 			}
 		}
+		if rationale {
+			comment, _ = parsed["comment"].(string)
+			reasoning = rep.Reasoning
+		}
 		// coerce
 		var act string
 		if v, ok := parsed["action"].(string); ok {
@@ -604,7 +883,7 @@ Rules:
 			}
 		}
 		if !valid {
-			return "", nil, fmt.Errorf("illegal action %q not in %v", act, legal)
+			return agent.ActionOut{}, "", fmt.Errorf("illegal action %q not in %v", act, legal)
 		}
 		var amount *int
 		if rawAmt, ok := parsed["amount"]; ok && rawAmt != nil {
@@ -624,33 +903,38 @@ Rules:
 				}
 			}
 		}
+		if act == "raise" && fixed {
+			v := minRaiseTo
+			amount = &v
+		}
 		if act == "raise" {
 			if amount == nil {
-				return "", nil, fmt.Errorf("raise requires amount")
+				return agent.ActionOut{}, "", fmt.Errorf("raise requires amount")
 			}
 			if *amount < minRaiseTo || *amount > maxRaiseTo {
-				return "", nil, fmt.Errorf("amount %d outside [%d,%d]", *amount, minRaiseTo, maxRaiseTo)
+				return agent.ActionOut{}, "", fmt.Errorf("amount %d outside [%d,%d]", *amount, minRaiseTo, maxRaiseTo)
 			}
 		} else {
 			amount = nil
 		}
-		act, amount = applyZeroProbePolicy(act, amount, legal, minRaiseTo, obs.ToCall)
-		return act, amount, nil
+		return finish(settleAction(act, amount, srcSchema, legal, minRaiseTo, obs.ToCall, rng.probe))
 	}
 
 	// 3) Fallback to legacy JSON mode (no schema)
-	text2, err2 := llm.PingText(ctx2, model, jsonSystem, user)
+	text2, err2 := llm.PingTextWithOpts(ctx2, model, jsonSystem, user, llm.PingOptions{ReasoningEffort: re, MaxOutputTokens: maxTok, Endpoint: ep, JSONObject: true})
 	if debugState && text2 != "" {
 		log.Printf("json(raw-object) raw: %s", text2)
 	}
 	if err2 == nil {
+		if rationale {
+			comment, reasoning = commentFromText(text2), ""
+		}
 		// Try: JSON -> code-fence JSON -> YAML-ish -> NL heuristics
 		// 3a) JSON
 		parsed := map[string]any{}
 		if e := json.Unmarshal([]byte(text2), &parsed); e == nil {
 			if act, amount, ok := coerceActionMap(parsed, legal, minRaiseTo, maxRaiseTo, obs.ToCall); ok {
-				act, amount = applyZeroProbePolicy(act, amount, legal, minRaiseTo, obs.ToCall)
-				return act, amount, nil
+				return finish(settleAction(act, amount, srcJSON, legal, minRaiseTo, obs.ToCall, rng.probe))
 			}
 		}
 		// 3b) code-fence JSON
@@ -658,34 +942,189 @@ Rules:
 			parsed := map[string]any{}
 			if e2 := json.Unmarshal([]byte(cleaned), &parsed); e2 == nil {
 				if act, amount, ok := coerceActionMap(parsed, legal, minRaiseTo, maxRaiseTo, obs.ToCall); ok {
-					act, amount = applyZeroProbePolicy(act, amount, legal, minRaiseTo, obs.ToCall)
-					return act, amount, nil
+					return finish(settleAction(act, amount, srcJSON, legal, minRaiseTo, obs.ToCall, rng.probe))
 				}
 			}
 		}
 		// 3c) YAML fallback
 		if act, amount, ok := parseYAMLish(text2, legal, minRaiseTo, maxRaiseTo, obs.ToCall); ok {
-			act, amount = applyZeroProbePolicy(act, amount, legal, minRaiseTo, obs.ToCall)
-			return act, amount, nil
+			return finish(settleAction(act, amount, srcYAML, legal, minRaiseTo, obs.ToCall, rng.probe))
 		}
 		// 3d) Natural language fallback
 		if act, amount, ok := parseNLAction(text2, legal, minRaiseTo, maxRaiseTo, obs.ToCall); ok {
-			act, amount = applyZeroProbePolicy(act, amount, legal, minRaiseTo, obs.ToCall)
-			return act, amount, nil
+			return finish(settleAction(act, amount, srcNL, legal, minRaiseTo, obs.ToCall, rng.probe))
 		}
 		// 3e) Last-ditch safe default
 		if obs.ToCall == 0 && contains(legal, "check") {
-			return "check", nil, nil
+			return agent.ActionOut{Action: "check"}, srcFallback, nil
 		}
 		if contains(legal, "fold") {
-			return "fold", nil, nil
+			return agent.ActionOut{Action: "fold"}, srcFallback, nil
 		}
 	}
 	// If we couldn't salvage anything, propagate the earlier error if present
 	if err2 != nil {
-		return "", nil, err2
+		return agent.ActionOut{}, "", err2
+	}
+	return agent.ActionOut{}, "", fmt.Errorf("could not derive a legal action from model output")
+}
+
+// commentFromText pulls the "comment" key out of a JSON (or fenced JSON) reply.
+func commentFromText(text string) string {
+	parsed := map[string]any{}
+	if err := json.Unmarshal([]byte(text), &parsed); err != nil {
+		cleaned := extractJSONObject(text)
+		if cleaned == "" || json.Unmarshal([]byte(cleaned), &parsed) != nil {
+			return ""
+		}
+	}
+	c, _ := parsed["comment"].(string)
+	return c
+}
+
+// clipComment trims a rationale to the contract's soft limit.
+func clipComment(c string) string {
+	c = strings.Join(strings.Fields(c), " ")
+	if r := []rune(c); len(r) > llm.CommentMaxLen {
+		c = string(r[:llm.CommentMaxLen])
+	}
+	return c
+}
+
+// reasoningEffortFromEnv returns OPENAI_REASONING_EFFORT when it is a known level.
+func reasoningEffortFromEnv() string {
+	re := strings.ToLower(strings.TrimSpace(os.Getenv("OPENAI_REASONING_EFFORT")))
+	switch re {
+	case "", "low", "medium", "high":
+		return re
+	default:
+		return ""
 	}
-	return "", nil, fmt.Errorf("could not derive a legal action from model output")
+}
+
+// raiseKeysRule explains the raise keys askPolicy offers for obs.
+func raiseKeysRule(obs agent.Observation, keys []string) string {
+	switch {
+	case contains(keys, agent.RaiseFixed):
+		return fmt.Sprintf("- raise raises to %d, the fixed-limit bet.", obs.MinRaiseTo)
+	case contains(keys, agent.RaiseAllIn):
+		return fmt.Sprintf("- raise_min raises to %d; raise_half_pot and raise_pot size the raise relative to the pot after calling; raise_all_in raises to %d.", obs.MinRaiseTo, obs.MaxRaiseTo)
+	case contains(keys, agent.RaiseMin):
+		return fmt.Sprintf("- raise_min raises to %d; raise_half_pot and raise_pot size the raise relative to the pot after calling, up to %d.", obs.MinRaiseTo, obs.MaxRaiseTo)
+	}
+	return "- Raising is not legal here."
+}
+
+// askPolicy requests a distribution over agent.PolicyKeys and samples the
+// action with rng. Enabled by MIXED_POLICY=1.
+func askPolicy(ctx context.Context, model string, ep llm.Endpoint, obs agent.Observation, curBet int, rng *mrand.Rand, re string, maxTok *int, rationale bool) (agent.ActionOut, error) {
+	keys := agent.PolicyKeys(obs)
+	obsRaw, _ := json.Marshal(obs)
+	user := fmt.Sprintf(
+		`Given this observation JSON:
+%s
+
+Respond ONLY with a single compact JSON object:
+{"policy":{"<key>":<probability>,...}}
+Rules:
+- Assign a probability to every key in %v (nothing else).
+- Probabilities are numbers in [0, 1] and should sum to 1.
+%s
+- Spread weight across actions where your strategy mixes; put all weight on one key only when it clearly dominates.
+- No extra keys. No prose. No markdown.`,
+		string(obsRaw), keys, raiseKeysRule(obs, keys),
+	)
+	if rationale {
+		user += fmt.Sprintf("\n- Also include a top-level \"comment\": one sentence (max %d chars) explaining the strategy.", llm.CommentMaxLen)
+	}
+	props := map[string]any{}
+	for _, k := range keys {
+		props[k] = map[string]any{"type": "number", "minimum": 0, "maximum": 1}
+	}
+	schema := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"policy"},
+		"properties": map[string]any{
+			"policy": map[string]any{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             keys,
+				"properties":           props,
+			},
+		},
+	}
+	if rationale {
+		llm.AddCommentField(schema)
+	}
+	system := systemPrompt() + "\n\nInstead of a single action, return a mixed strategy: a probability for each listed key. The harness samples your action from it."
+	rep, err := llm.PingReply(ctx, model, system, user, llm.PingOptions{ReasoningEffort: re, MaxOutputTokens: maxTok, StructuredSchemaName: "poker_policy", StructuredSchema: schema, StructuredStrict: true, IncludeReasoning: rationale, Endpoint: ep})
+	text := rep.Text
+	if debugState && text != "" {
+		log.Printf("policy raw: %s", text)
+	}
+	if err != nil {
+		return agent.ActionOut{}, err
+	}
+	var parsed struct {
+		Policy  map[string]float64 `json:"policy"`
+		Comment string             `json:"comment"`
+	}
+	if e := json.Unmarshal([]byte(text), &parsed); e != nil {
+		cleaned := extractJSONObject(text)
+		if cleaned == "" {
+			return agent.ActionOut{}, fmt.Errorf("bad policy JSON from model: %v", e)
+		}
+		if e2 := json.Unmarshal([]byte(cleaned), &parsed); e2 != nil {
+			return agent.ActionOut{}, fmt.Errorf("bad policy JSON from model: %v", e)
+		}
+	}
+	out, bucket, err := agent.SamplePolicy(obs, curBet, parsed.Policy, rng)
+	if err != nil {
+		return agent.ActionOut{}, err
+	}
+	if debugState {
+		log.Printf("policy sample: %s from %v", bucket, out.Policy)
+	}
+	if rationale {
+		out.Comment = clipComment(parsed.Comment)
+		out.Reasoning = strings.TrimSpace(rep.Reasoning)
+	}
+	return out, nil
+}
+
+// handRNG is the harness randomness for one hand. Both streams derive from
+// the deck seed, never from shared state, so a hand plays out the same way
+// whether its match runs alone or next to others in the worker pool. Both
+// hands of a mirrored pair get identical streams, so an identical policy at
+// the same decision index samples the same action.
+type handRNG struct {
+	policy *mrand.Rand // mixed-strategy sampling; nil unless MIXED_POLICY=1
+	probe  *mrand.Rand // zero-to-call probe flips (RAISE_ZERO_CALL_PROB)
+	equity *mrand.Rand // preflop equity sampling for the AIVAT estimate
+}
+
+func newHandRNG(deckSeed int64, mixedPolicy bool) handRNG {
+	var r handRNG
+	if mixedPolicy {
+		st := newSeedStream(uint64(deckSeed) ^ 0x243F6A8885A308D3)
+		r.policy = mrand.New(mrand.NewSource(int64(st.next())))
+	}
+	st := newSeedStream(uint64(deckSeed) ^ 0x13198A2E03707344)
+	r.probe = mrand.New(mrand.NewSource(int64(st.next())))
+	st = newSeedStream(uint64(deckSeed) ^ 0xA4093822299F31D0)
+	r.equity = mrand.New(mrand.NewSource(int64(st.next())))
+	return r
+}
+
+// maxStoredReasoning caps provider reasoning text persisted per action.
+const maxStoredReasoning = 8000
+
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
 }
 
 func contains(ss []string, s string) bool {
@@ -697,8 +1136,8 @@ func contains(ss []string, s string) bool {
 	return false
 }
 
-func applyZeroProbePolicy(act string, amt *int, legal []string, minRaiseTo int, toCall int) (string, *int) {
-	prob := probeProbFromEnv()
+func applyZeroProbePolicy(act string, amt *int, legal []string, minRaiseTo int, toCall int, rng *mrand.Rand) (string, *int) {
+	prob := runSpec.Behavior.RaiseZeroCallProb
 	if toCall != 0 {
 		return act, amt
 	}
@@ -706,7 +1145,7 @@ func applyZeroProbePolicy(act string, amt *int, legal []string, minRaiseTo int,
 		if prob <= 0 {
 			return "check", nil
 		}
-		if mrand.Float64() >= prob {
+		if rng.Float64() >= prob {
 			return "check", nil
 		}
 		if amt == nil {
@@ -715,7 +1154,7 @@ func applyZeroProbePolicy(act string, amt *int, legal []string, minRaiseTo int,
 		}
 	}
 	if contains(legal, "raise") && act == "check" {
-		if prob > 0 && mrand.Float64() < prob {
+		if prob > 0 && rng.Float64() < prob {
 			if amt == nil {
 				v := minRaiseTo
 				amt = &v
@@ -748,6 +1187,28 @@ func probeProbFromEnv() float64 {
 // ===== lightweight hand description =====
 //
 
+// describeVariant names a holding's best hand under a variant's own rules:
+// Omaha's two-plus-three, or the short deck's straights and rankings.
+func describeVariant(v engine.Variant, hole, board []engine.Card) string {
+	if len(board) < 3 {
+		if v == engine.PLO {
+			return "four cards, no flop yet"
+		}
+		return describe([2]string{hole[0].String(), hole[1].String()}, nil)
+	}
+	d := v.Eval(hole, board).String()
+	return strings.ToLower(d[:1]) + d[1:]
+}
+
+// cardsStr joins cards with spaces, e.g. "As Kd".
+func cardsStr(cs []engine.Card) string {
+	parts := make([]string, len(cs))
+	for i, c := range cs {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, " ")
+}
+
 func describe(hole [2]string, board []string) string {
 	all := append([]string{}, hole[0], hole[1])
 	all = append(all, board...)
@@ -937,15 +1398,19 @@ func topRankOnBoard(board []string) int {
 //
 
 type ActionTally struct {
-	Check int
-	Call  int
-	Raise int
-	Fold  int
+	Check   int
+	Call    int
+	Raise   int
+	Fold    int
+	Sources map[string]int // decisions per parse path (src* constants)
 }
 
-func addAction(t map[string]*ActionTally, label, act string) {
+func addAction(t map[string]*ActionTally, label, act, src string) {
 	if t[label] == nil {
-		t[label] = &ActionTally{}
+		t[label] = &ActionTally{Sources: map[string]int{}}
+	}
+	if src != "" {
+		t[label].Sources[src]++
 	}
 	switch act {
 	case "check":
@@ -995,8 +1460,7 @@ func actionStrings(h *engine.Hand) []string {
 		}
 		toCall := h.CurBet - committed
 		if toCall == 0 {
-			rf := strings.TrimSpace(os.Getenv("RAISE_FIRST_ZERO_CALL"))
-			if rf == "1" || strings.EqualFold(rf, "true") || strings.EqualFold(rf, "yes") {
+			if runSpec.Behavior.RaiseFirstZeroCall {
 				idxCheck, idxRaise := -1, -1
 				for i, a := range out {
 					if a == "check" {
@@ -1024,23 +1488,26 @@ func playHandMatch(
 	gracefulOnly bool,
 	tallies map[string]*ActionTally, // keyed by "A" / "B"
 	db *store.DB, matchID int64, pairIndex int,
-) (engine.Seat, int, int, int, bool) {
-	section(fmt.Sprintf("Hand %s", blue(h.ID)))
+	rng handRNG,
+	con *console,
+) (engine.Seat, int, int, int, float64, bool) {
+	con.section(fmt.Sprintf("Hand %s", blue(h.ID)))
+	av := aivat.NewHand(h.Cfg.Variant, h.SB.Hole, h.BB.Hole, h.SB.Committed, h.BB.Committed, rng.equity)
 
 	// Header
-	fmt.Printf("%s %s  %s %s  %s\n",
+	con.Printf("%s %s  %s %s  %s\n",
 		bold("Seats:"),
 		fmt.Sprintf("%s(%s)", seatTag(engine.SB), dim(modelShort(sbP.Model))),
 		bold("vs"),
 		fmt.Sprintf("%s(%s)", seatTag(engine.BB), dim(modelShort(bbP.Model))),
 		fmt.Sprintf(" | %s %d  %s %d", cyan("SB"), sbP.Bank, warn("BB"), bbP.Bank),
 	)
-	fmt.Printf("%s %s %s  | %s %s\n",
+	con.Printf("%s %s %s  | %s %s\n",
 		bold("Holes:"),
-		seatTag(engine.SB), fmt.Sprintf("%s %s", h.SB.Hole[0], h.SB.Hole[1]),
-		seatTag(engine.BB), fmt.Sprintf("%s %s", h.BB.Hole[0], h.BB.Hole[1]),
+		seatTag(engine.SB), cardsStr(h.SB.Hole),
+		seatTag(engine.BB), cardsStr(h.BB.Hole),
 	)
-	fmt.Printf("%s %s:%d %s:%d  | %s\n\n",
+	con.Printf("%s %s:%d %s:%d  | %s\n\n",
 		bold("Blinds:"),
 		"SB", h.Cfg.SB, "BB", h.Cfg.BB, potTag(h.Pot),
 	)
@@ -1057,19 +1524,20 @@ func playHandMatch(
 	var winner engine.Seat
 
 	for i, s := range streets {
-		sub(strings.ToUpper(s))
+		con.sub(strings.ToUpper(s))
 
 		// deal street & reset street contributions
 		if i > 0 {
 			h.NextStreet()
+			av.Deal(h.Board)
 			sbC.stre, bbC.stre = 0, 0
 			switch s {
 			case "flop":
-				fmt.Printf("%s %s %s %s\n", bold("Board:"), h.Board[0], h.Board[1], h.Board[2])
+				con.Printf("%s %s %s %s\n", bold("Board:"), h.Board[0], h.Board[1], h.Board[2])
 			case "turn":
-				fmt.Printf("%s %s %s %s %s\n", bold("Board:"), h.Board[0], h.Board[1], h.Board[2], h.Board[3])
+				con.Printf("%s %s %s %s %s\n", bold("Board:"), h.Board[0], h.Board[1], h.Board[2], h.Board[3])
 			case "river":
-				fmt.Printf("%s %s %s %s %s %s\n", bold("Board:"), h.Board[0], h.Board[1], h.Board[2], h.Board[3], h.Board[4])
+				con.Printf("%s %s %s %s %s %s\n", bold("Board:"), h.Board[0], h.Board[1], h.Board[2], h.Board[3], h.Board[4])
 			}
 		}
 
@@ -1085,8 +1553,8 @@ func playHandMatch(
 		for j := 0; j < maxActionsPerStreet; j++ {
 			// termination between actions
 			if checkStop(false) && !gracefulOnly {
-				fmt.Println(bad("** Termination requested (immediate). Aborting hand without payout. **"))
-				return engine.Seat(""), sbC.total + bbC.total, 0, 0, true
+				con.Println(bad("** Termination requested (immediate). Aborting hand without payout. **"))
+				return engine.Seat(""), sbC.total + bbC.total, 0, 0, 0, true
 			}
 
 			// observation + legal
@@ -1096,17 +1564,17 @@ func playHandMatch(
 			// bounds for raises
 			actor := h.SB
 			curLabel := sbP.Label
-			curModel := sbP.Model
+			curModel, curLLM := sbP.Model, sbP.LLM
 			if seat == engine.BB {
 				actor = h.BB
 				curLabel = bbP.Label
-				curModel = bbP.Model
+				curModel, curLLM = bbP.Model, bbP.LLM
 			}
 			minTo := h.CurBet + h.MinRaise
 			if minTo < h.Cfg.BB { // preflop guard
 				minTo = h.Cfg.BB
 			}
-			maxTo := actor.Committed + actor.Stack
+			maxTo := h.MaxRaiseTo(seat)
 
 			// cancel model call if hard stop flips during wait
 			textCtx, cancel := context.WithCancel(context.Background())
@@ -1125,18 +1593,23 @@ func playHandMatch(
 				}
 			}()
 
-			act, amtPtr, err := askAction(textCtx, curModel, legal, minTo, maxTo, obs)
+			out, src, err := askAction(textCtx, curModel, curLLM, legal, minTo, maxTo, h.CurBet, obs, rng)
 			cancel()
+			act, amtPtr := out.Action, out.Amount
+			if out.Comment != "" {
+				con.Printf("  %s %s\n", dim(fmt.Sprintf("%s says:", curLabel)), dim(out.Comment))
+			}
 			if err != nil {
+				src = srcFallback
 				toCallFB := h.CurBet - actor.Committed
 				if toCallFB < 0 {
 					toCallFB = 0
 				}
 				if !errors.Is(err, context.Canceled) {
-					log.Printf("LLM fallback for %s (%s): %v (legal=%v to_call=%d)", curLabel, curModel, err, legal, toCallFB)
+					con.Logf("LLM fallback for %s (%s): %v (legal=%v to_call=%d)", curLabel, curModel, err, legal, toCallFB)
 				}
 				if debugState {
-					fmt.Println(warn("model action error fallback"), err)
+					con.Println(warn("model action error fallback"), err)
 				}
 				choose := func(want string) bool {
 					for _, a := range legal {
@@ -1192,7 +1665,7 @@ func playHandMatch(
 			}
 
 			// Testing hook: force non-check actions if requested
-			if pref := strings.ToLower(strings.TrimSpace(os.Getenv("FORCE_NONCHECK"))); pref != "" && act == "check" {
+			if pref := runSpec.Behavior.ForceNonCheck; pref != "" && act == "check" {
 				contains := func(s string) bool {
 					for _, a := range legal {
 						if a == s {
@@ -1236,8 +1709,11 @@ func playHandMatch(
 						switched = true
 					}
 				}
-				if switched && debugState {
-					fmt.Println(warn("forced non-check (test)"))
+				if switched {
+					src = srcForced
+					if debugState {
+						con.Println(warn("forced non-check (test)"))
+					}
 				}
 			}
 
@@ -1261,30 +1737,33 @@ func playHandMatch(
 				}
 				sbStack, bbStack := h.SB.Stack, h.BB.Stack
 				sbCom, bbCom := h.SB.Committed, h.BB.Committed
-				sbHole := []string{}
-				bbHole := []string{}
-				if len(h.SB.Hole) == 2 {
-					sbHole = []string{h.SB.Hole[0].String(), h.SB.Hole[1].String()}
+				sbHole := make([]string, len(h.SB.Hole))
+				for i, c := range h.SB.Hole {
+					sbHole[i] = c.String()
 				}
-				if len(h.BB.Hole) == 2 {
-					bbHole = []string{h.BB.Hole[0].String(), h.BB.Hole[1].String()}
+				bbHole := make([]string, len(h.BB.Hole))
+				for i, c := range h.BB.Hole {
+					bbHole[i] = c.String()
 				}
 				_ = db.InsertActionLog(context.Background(), matchID, pairIndex, h.ID, s, curLabel, action, amount,
-					h.Pot, h.CurBet, toCall, minTo, maxTo, sbStack, bbStack, sbCom, bbCom, boardNow, sbHole, bbHole)
+					h.Pot, h.CurBet, toCall, minTo, maxTo, sbStack, bbStack, sbCom, bbCom, boardNow, sbHole, bbHole, src, out.Policy,
+					out.Comment, truncateRunes(out.Reasoning, maxStoredReasoning))
 			}
 
 			// logging adornments
-			hole := [2]string{h.SB.Hole[0].String(), h.SB.Hole[1].String()}
-			if seat == engine.BB {
-				hole = [2]string{h.BB.Hole[0].String(), h.BB.Hole[1].String()}
-			}
-			boardNow := make([]string, 0, len(h.Board))
-			for _, c := range h.Board {
-				if c.String() != "" {
-					boardNow = append(boardNow, c.String())
+			var desc string
+			if h.Cfg.Variant != engine.Holdem {
+				desc = describeVariant(h.Cfg.Variant, actor.Hole, h.Board)
+			} else {
+				hole := [2]string{actor.Hole[0].String(), actor.Hole[1].String()}
+				boardNow := make([]string, 0, len(h.Board))
+				for _, c := range h.Board {
+					if c.String() != "" {
+						boardNow = append(boardNow, c.String())
+					}
 				}
+				desc = describe(hole, boardNow)
 			}
-			desc := describe(hole, boardNow)
 			tag := fmt.Sprintf("%s(%s)", seatTag(seat), dim(modelShort(curModel)))
 
 			rem := func() int {
@@ -1294,9 +1773,18 @@ func playHandMatch(
 				return h.BB.Stack
 			}
 			apply := func(kind engine.ActionKind, amount int) error {
+				// the policy only counts if this is the action sampled from it
+				var policy []aivat.Choice
+				if src == srcPolicy && out.Policy != nil {
+					policy = policyChoices(out.Policy, obs, h.CurBet, actor)
+				}
+				before := actor.Committed
 				err := h.Apply(kind, amount)
+				if err == nil {
+					av.Act(seat, aivat.Choice{Fold: kind == engine.Fold, Chips: actor.Committed - before}, policy)
+				}
 				if debugState {
-					fmt.Printf("%s DBG: kind=%v amount=%d | CurBet=%d SBCom=%d BBCom=%d SBStack=%d BBStack=%d %s\n",
+					con.Printf("%s DBG: kind=%v amount=%d | CurBet=%d SBCom=%d BBCom=%d SBStack=%d BBStack=%d %s\n",
 						dim("["), kind, amount, h.CurBet, h.SB.Committed, h.BB.Committed, h.SB.Stack, h.BB.Stack, dim("]"))
 				}
 				return err
@@ -1307,8 +1795,8 @@ func playHandMatch(
 			case "fold":
 				if err := apply(engine.Fold, 0); err == nil {
 					logStep("fold", nil)
-					fmt.Printf("  %s %s — %s. %s\n", tag, bold("folds"), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
-					addAction(tallies, curLabel, "fold")
+					con.Printf("  %s %s — %s. %s\n", tag, bold("folds"), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
+					addAction(tallies, curLabel, "fold", src)
 					if seat == engine.SB {
 						winner = engine.BB
 					} else {
@@ -1319,8 +1807,8 @@ func playHandMatch(
 			case "check":
 				if err := apply(engine.Check, 0); err == nil {
 					logStep("check", nil)
-					fmt.Printf("  %s %s — %s. %s\n", tag, bold("checks"), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
-					addAction(tallies, curLabel, "check")
+					con.Printf("  %s %s — %s. %s\n", tag, bold("checks"), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
+					addAction(tallies, curLabel, "check", src)
 					if prevWasCheck {
 						goto NEXT_STREET
 					}
@@ -1338,8 +1826,8 @@ func playHandMatch(
 						bbC.total += toCall
 						bbC.stre += toCall
 					}
-					fmt.Printf("  %s %s %s — %s. %s\n", tag, bold("calls"), good(fmt.Sprintf("%d", toCall)), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
-					addAction(tallies, curLabel, "call")
+					con.Printf("  %s %s %s — %s. %s\n", tag, bold("calls"), good(fmt.Sprintf("%d", toCall)), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
+					addAction(tallies, curLabel, "call", src)
 					goto NEXT_STREET
 				}
 			case "raise":
@@ -1367,8 +1855,8 @@ func playHandMatch(
 						bbC.total += needed
 						bbC.stre += needed
 					}
-					fmt.Printf("  %s %s %s — %s. %s\n", tag, bold("raises to"), good(fmt.Sprintf("%d", raiseTo)), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
-					addAction(tallies, curLabel, "raise")
+					con.Printf("  %s %s %s — %s. %s\n", tag, bold("raises to"), good(fmt.Sprintf("%d", raiseTo)), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
+					addAction(tallies, curLabel, "raise", src)
 					prevWasCheck = false
 					applied = true
 				}
@@ -1377,8 +1865,9 @@ func playHandMatch(
 			// one-shot fallback if illegal
 			if !applied {
 				if debugState {
-					fmt.Println(warn("illegal model action; falling back"))
+					con.Println(warn("illegal model action; falling back"))
 				}
+				src = srcFallback
 				fallback := actionStrings(h)
 				tried := false
 				for _, a := range []string{"check", "call", "fold"} {
@@ -1388,8 +1877,8 @@ func playHandMatch(
 							case "check":
 								if apply(engine.Check, 0) == nil {
 									logStep("check", nil)
-									fmt.Printf("  %s %s — %s. %s\n", tag, bold("checks"), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
-									addAction(tallies, curLabel, "check")
+									con.Printf("  %s %s — %s. %s\n", tag, bold("checks"), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
+									addAction(tallies, curLabel, "check", src)
 									if prevWasCheck {
 										goto NEXT_STREET
 									}
@@ -1407,16 +1896,16 @@ func playHandMatch(
 										bbC.total += toCall
 										bbC.stre += toCall
 									}
-									fmt.Printf("  %s %s %s — %s. %s\n", tag, bold("calls"), good(fmt.Sprintf("%d", toCall)), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
-									addAction(tallies, curLabel, "call")
+									con.Printf("  %s %s %s — %s. %s\n", tag, bold("calls"), good(fmt.Sprintf("%d", toCall)), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
+									addAction(tallies, curLabel, "call", src)
 									tried = true
 									goto NEXT_STREET
 								}
 							case "fold":
 								if apply(engine.Fold, 0) == nil {
 									logStep("fold", nil)
-									fmt.Printf("  %s %s — %s. %s\n", tag, bold("folds"), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
-									addAction(tallies, curLabel, "fold")
+									con.Printf("  %s %s — %s. %s\n", tag, bold("folds"), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
+									addAction(tallies, curLabel, "fold", src)
 									if seat == engine.SB {
 										winner = engine.BB
 									} else {
@@ -1449,8 +1938,8 @@ func playHandMatch(
 								bbC.total += needed
 								bbC.stre += needed
 							}
-							fmt.Printf("  %s %s %s — %s. %s\n", tag, bold("raises to"), good(fmt.Sprintf("%d", rt)), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
-							addAction(tallies, curLabel, "raise")
+							con.Printf("  %s %s %s — %s. %s\n", tag, bold("raises to"), good(fmt.Sprintf("%d", rt)), desc, dim(fmt.Sprintf("Remaining: %d", rem())))
+							addAction(tallies, curLabel, "raise", src)
 							break
 						}
 					}
@@ -1487,9 +1976,9 @@ PAYOUT:
 			exp = engine.BB
 		}
 		sbD, bbD := h.EvalDebug()
-		fmt.Printf("%s SB[%d]: %s  |  BB[%d]: %s\n", dim("Eval check →"), sbScore, sbD, bbScore, bbD)
+		con.Printf("%s SB[%d]: %s  |  BB[%d]: %s\n", dim("Eval check →"), sbScore, sbD, bbScore, bbD)
 		if exp != "" && exp != winner {
-			fmt.Printf("%s winner disagrees with raw scores; overriding to %s\n", bad("EVAL MISMATCH:"), seatLabel(exp))
+			con.Printf("%s winner disagrees with raw scores; overriding to %s\n", bad("EVAL MISMATCH:"), seatLabel(exp))
 			winner = exp
 		}
 	}
@@ -1516,29 +2005,102 @@ PAYOUT:
 		winModel = bbP.Model
 	}
 	if len(h.Board) >= 5 && h.Board[4].String() != "" && winner != "" {
-		fmt.Printf("%s %s %s | %s %s %s %s %s %s | %s\n",
+		con.Printf("%s %s %s | %s %s %s %s %s %s | %s\n",
 			good("Showdown →"), seatTag(winner), good(fmt.Sprintf("(%s)", winModel)),
 			bold("Board:"), h.Board[0], h.Board[1], h.Board[2], h.Board[3], h.Board[4],
 			potTag(pot),
 		)
 	} else if winner == "" {
-		fmt.Printf("%s %s %s %s %s\n", good("Showdown →"), bold("Tie."), bold("Board:"), h.Board[0], h.Board[1])
+		con.Printf("%s %s %s %s %s\n", good("Showdown →"), bold("Tie."), bold("Board:"), h.Board[0], h.Board[1])
 	} else {
-		fmt.Printf("%s %s %s | %s\n", good("Winner by fold →"), seatTag(winner), good(fmt.Sprintf("(%s)", winModel)), potTag(pot))
+		con.Printf("%s %s %s | %s\n", good("Winner by fold →"), seatTag(winner), good(fmt.Sprintf("(%s)", winModel)), potTag(pot))
 	}
-	fmt.Printf("%s %s:%d  %s:%d\n\n", bold("Seat banks →"), cyan("SB"), sbP.Bank, warn("BB"), bbP.Bank)
+	con.Printf("%s %s:%d  %s:%d\n\n", bold("Seat banks →"), cyan("SB"), sbP.Bank, warn("BB"), bbP.Bank)
 
 	// deltas (this is what your callers use)
 	deltaSB := sbP.Bank - startSB
 	deltaBB := bbP.Bank - startBB
 
-	return winner, pot, deltaSB, deltaBB, false
+	return winner, pot, deltaSB, deltaBB, av.Estimate(deltaSB), false
+}
+
+// policyChoices maps a sampled policy onto the chips each key would have
+// put in for actor, for the AIVAT policy correction.
+func policyChoices(policy map[string]float64, obs agent.Observation, curBet int, actor *engine.Player) []aivat.Choice {
+	out := make([]aivat.Choice, 0, len(policy))
+	for _, k := range agent.PolicyKeys(obs) {
+		p := policy[k]
+		if p <= 0 {
+			continue
+		}
+		c := aivat.Choice{Prob: p}
+		switch k {
+		case "fold":
+			c.Fold = true
+		case "check":
+		case "call":
+			c.Chips = min(curBet-actor.Committed, actor.Stack)
+		default:
+			to, ok := agent.BucketRaiseTo(obs, curBet, k)
+			if !ok {
+				continue
+			}
+			c.Chips = min(to-actor.Committed, actor.Stack)
+		}
+		out = append(out, c)
+	}
+	return out
 }
 
 //
 // ===== small helpers for duel =====
 //
 
+// promptRevision must be bumped whenever the prompt templates in askAction
+// or askPolicy change, so the new prompts get their own rating rows.
+const promptRevision = 1
+
+// promptHash fingerprints the prompt setup a bot is evaluated under: the
+// system prompt, template revision and the env toggles that alter prompts.
+func promptHash() string {
+	return promptHashOf(systemPrompt(), runSpec.Behavior)
+}
+
+// defaultPromptHash is promptHash for no-limit hold'em with default behavior.
+func defaultPromptHash() string {
+	return promptHashOf(benchSystem, config.Default().Behavior)
+}
+
+func promptHashOf(system string, b config.Behavior) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\nrev=%d\nmixed=%t\nrationale=%t\nprobe=%t",
+		system, promptRevision,
+		b.MixedPolicy,
+		b.CaptureRationale,
+		b.EncourageProbeZero,
+	)
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// botVariant derives the rated identity of p from its model and settings.
+func botVariant(p Player) store.BotVariant {
+	v := store.BotVariant{
+		Model:           p.Model,
+		Family:          llm.ModelFamily(p.Model),
+		Provider:        llm.ProviderName(p.Model, p.LLM),
+		ReasoningEffort: firstNonEmpty(p.LLM.ReasoningEffort, runSpec.Behavior.ReasoningEffort),
+		Temperature:     p.LLM.Temperature,
+		PromptHash:      promptHash(),
+		DefaultPrompt:   defaultPromptHash(),
+	}
+	if v.Temperature == nil {
+		if t, err := strconv.ParseFloat(firstNonEmpty(os.Getenv("OPENAI_TEMPERATURE"), os.Getenv("OPENROUTER_TEMPERATURE")), 64); err == nil {
+			v.Temperature = &t
+		}
+	}
+	return v
+}
+
 func strptr(s string) *string {
 	if strings.TrimSpace(s) == "" {
 		return nil
@@ -1829,50 +2391,141 @@ func tallyCounts(x *ActionTally) (chk, call, raise, fold int) {
 }
 
 // ===== duel runner =====
-func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
-	section("DUEL")
+// duelResult reports how a runDuel call ended. Finished is false when a stop
+// request cut the match short; the match is then left open at its last
+// checkpoint so --resume can finish it.
+type duelResult struct {
+	MatchID  int64
+	Finished bool
+	Hands    int
+	GA, GB   Glicko2 // final Glicko-2 state of a and b
+}
 
-	sb := atoiDef(os.Getenv("SB"), 50)
-	bb := atoiDef(os.Getenv("BB"), 100)
-	startStack := atoiDef(os.Getenv("START_STACK"), 10000)
-	cfg := engine.Config{SB: sb, BB: bb, StartStack: startStack}
+// duelOpts are runDuel's per-match inputs besides the two players.
+type duelOpts struct {
+	SeedBase uint64
+	Console  *console            // nil → stdout
+	Resume   *duelCheckpoint     // continue this match instead of starting one
+	OnMatch  func(matchID int64) // called once the match row exists
+}
 
-	// mirrored seeds: N pairs → 2N hands
-	seeds := atoiDef(os.Getenv("DUEL_SEEDS"), 5)
-	if seeds <= 0 {
-		seeds = (atoiDef(os.Getenv("DUEL_HANDS"), 10) + 1) / 2
+// runDuel plays one mirrored match between a and b with decks drawn from
+// opts.SeedBase; every other setting comes from runSpec. Output goes to
+// opts.Console, flushed after every pair. With a DB the runner state is
+// checkpointed after each pair; opts.Resume picks a match up from there.
+func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB, a, b Player, opts duelOpts) duelResult {
+	con := opts.Console
+	if con == nil {
+		con = stdoutConsole
 	}
+	cp := opts.Resume
+	defer con.Flush()
+	con.section("DUEL")
+
+	sb := runSpec.Game.SB
+	bb := runSpec.Game.BB
+	startStack := runSpec.Game.StartStack
+	cfg := gameConfig()
+	effStack := float64(startStack) // pair margins are in starting stacks
+	if effStack <= 0 {
+		effStack = float64(100 * bb)
+	}
+
+	// mirrored seeds: N pairs → 2N hands
+	seeds := runSpec.Seeds.Pairs
 
 	// players
-	a, b := loadPlayers(startStack)
+	a.Bank, b.Bank = startStack, startStack
 	var statsA, statsB ModelStats
 	tallies := map[string]*ActionTally{} // keyed by "A"/"B"
 
 	// Elo/Glicko defaults
-	eloStart := float64(atoiDef(os.Getenv("ELO_START"), 1500))
-	eloK := float64(atoiDef(os.Getenv("ELO_K"), 24))
+	eloStart := runSpec.Rating.EloStart
+	eloK := runSpec.Rating.EloK
 	perHandEnv := asBool(os.Getenv("ELO_PER_HAND"))
 	if perHandEnv {
-		fmt.Println(dim("ELO_PER_HAND requested, but Elo now updates after mirrored pairs using aggregated signals."))
+		con.Println(dim("ELO_PER_HAND requested, but Elo now updates after mirrored pairs using aggregated signals."))
 	}
 	eloPerHand := false
-	eloWeightPot := asBool(os.Getenv("ELO_WEIGHT_BY_POT"))
+	eloWeightPot := runSpec.Rating.WeightByPot
 	elo := NewElo(eloStart, eloK)
 
+	// Glicko-2 is updated per pair in-match; unless rating.glicko_period is
+	// "pair" that is only a provisional view and career Glicko-2 ratings
+	// move when a rating period closes.
 	gA := NewGlicko2()
 	gB := NewGlicko2()
-	tau := 0.5
+	perPairGlicko := runSpec.Rating.GlickoPeriod == config.PeriodPair
+
+	// mixed-strategy mode: models return a policy, the harness samples it
+	mixedPolicy := runSpec.Behavior.MixedPolicy
+	if mixedPolicy {
+		con.Println(dim("MIXED_POLICY=1 → actions sampled from model policies (seeded per deck)."))
+	}
 
 	// CI bookkeeping across pairs
 	var pairWinsA, pairTies, pairTotal int
 	var margins []float64
+	var aivatPairs []float64 // A's AIVAT chips per pair
+
+	// adaptive mode: a sequential test may end the match before `seeds` pairs
+	var seq *seqTest
+	var verdict string
+	switch runSpec.Stopping.Rule {
+	case config.StopSPRT, config.StopCI:
+		seq = &seqTest{cfg: runSpec.Stopping}
+		con.Println(dim(fmt.Sprintf("Sequential stopping: %s after each pair (min %d, max %d pairs, alpha=%.3g).",
+			seq.cfg.Rule, seq.cfg.MinPairs, seeds, seq.cfg.Alpha)))
+	case "", config.StopNone:
+	default:
+		con.Logf("Unknown stopping rule %q; playing all %d pairs.", runSpec.Stopping.Rule, seeds)
+	}
 
 	// seed stream
-	base := deckSeedFromEnvOrCrypto()
+	base := opts.SeedBase
 	sm := newSeedStream(base)
+	deckKey := matchDeckKey(base)
+
+	// resume: restore the state after the last checkpointed pair
+	startPair := 0
+	if cp != nil {
+		base, sm.state, startPair = cp.SeedBase, cp.SeedState, cp.Pairs
+		if k, err := engine.ParseDeckKey(cp.DeckKey); err == nil {
+			deckKey = k
+		} else {
+			// Checkpoints from before verifiable shuffles carry no key.
+			con.Logf("Checkpoint has no deck key (%v); the remaining pairs use a new one.", err)
+		}
+		a.Bank, b.Bank, a.Wins, b.Wins = cp.BankA, cp.BankB, cp.WinsA, cp.WinsB
+		statsA, statsB = cp.StatsA, cp.StatsB
+		if cp.Tallies != nil {
+			tallies = cp.Tallies
+		}
+		elo = cp.Elo
+		*gA, *gB = cp.GA, cp.GB
+		pairWinsA, pairTies, pairTotal, margins = cp.PairWinsA, cp.PairTies, cp.Pairs, cp.Margins
+		aivatPairs = cp.Aivat
+		if seq != nil {
+			seq.winsA, seq.winsB, seq.ties = cp.SeqWinsA, cp.SeqWinsB, cp.SeqTies
+		}
+		con.Logf("Resuming match %d after pair %d/%d (A bank %d, B bank %d)", cp.MatchID, cp.Pairs, seeds, a.Bank, b.Bank)
+		// The checkpointed pair may already have ended the match.
+		if seq != nil && startPair > 0 {
+			verdict = seq.check()
+		}
+		if verdict != "" || a.Bank <= 0 || b.Bank <= 0 {
+			startPair = seeds
+		}
+	}
 
-	log.Printf("Match seed base: %d (mirrored pairs=%d)", base, seeds)
-	fmt.Println(dim("Ctrl+C → graceful stop by default. Set STOP_IMMEDIATE=1 for hard stop."))
+	con.Logf("Match seed base: %d (mirrored pairs=%d)", base, seeds)
+	con.Logf("Deck commitment: %s (SHA-256 of the deck key, revealed when the match completes)", deckKey.Commitment())
+	for _, p := range []*Player{&a, &b} {
+		if !p.LLM.IsZero() {
+			con.Logf("Player %s (%s): %s", p.Label, p.Model, p.LLM)
+		}
+	}
+	con.Println(dim("Ctrl+C → graceful stop by default. Set STOP_IMMEDIATE=1 for hard stop."))
 
 	boardStr := func(bd []engine.Card) string {
 		if len(bd) < 5 {
@@ -1885,22 +2538,27 @@ func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 	var matchID int64
 	var botAID, botBID int64
 	accA, accB := 0.5, 0.5
-	if db != nil {
+	if db != nil && cp != nil {
+		matchID, botAID, botBID = cp.MatchID, cp.BotA, cp.BotB
+		accA, accB = elo.AccA, elo.AccB
+		if err := db.RewindMatch(context.Background(), matchID, cp.Pairs); err != nil {
+			con.Logf("RewindMatch failed: %v", err)
+		}
+	} else if db != nil {
 		companyA, companyB := companyForModel(a.Model), companyForModel(b.Model)
-		rePtr := strptr(os.Getenv("OPENAI_REASONING_EFFORT"))
 
-		// upsert bots
-		idA, err := db.UpsertBot(context.Background(), a.Model, companyA, rePtr)
+		// upsert bots (one row per model/provider/effort/temperature/prompt variant)
+		idA, err := db.UpsertBot(context.Background(), botVariant(a), companyA)
 		if err != nil {
-			log.Printf("UpsertBot(A) failed: %v (disabling DB this run)", err)
+			con.Logf("UpsertBot(A) failed: %v (disabling DB this run)", err)
 			db = nil
 		} else {
 			botAID = idA
 		}
 		if db != nil {
-			idB, err := db.UpsertBot(context.Background(), b.Model, companyB, rePtr)
+			idB, err := db.UpsertBot(context.Background(), botVariant(b), companyB)
 			if err != nil {
-				log.Printf("UpsertBot(B) failed: %v (disabling DB this run)", err)
+				con.Logf("UpsertBot(B) failed: %v (disabling DB this run)", err)
 				db = nil
 			} else {
 				botBID = idB
@@ -1909,14 +2567,14 @@ func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 
 		if db != nil && botAID != 0 {
 			if good, total, err := db.GetJudgeAccuracy(context.Background(), botAID); err != nil {
-				log.Printf("GetJudgeAccuracy(A) failed: %v", err)
+				con.Logf("GetJudgeAccuracy(A) failed: %v", err)
 			} else if total > 0 {
 				accA = float64(good) / float64(total)
 			}
 		}
 		if db != nil && botBID != 0 {
 			if good, total, err := db.GetJudgeAccuracy(context.Background(), botBID); err != nil {
-				log.Printf("GetJudgeAccuracy(B) failed: %v", err)
+				con.Logf("GetJudgeAccuracy(B) failed: %v", err)
 			} else if total > 0 {
 				accB = float64(good) / float64(total)
 			}
@@ -1929,7 +2587,7 @@ func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 					elo.A, elo.B = eA, eB
 					gA.Rating, gA.RD, gA.Volatility = grA, rdA, sgA
 					gB.Rating, gB.RD, gB.Volatility = grB, rdB, sgB
-					log.Printf("Seeding ratings → A: Elo=%.1f Glicko=%.1f/%.0f σ=%.3f | B: Elo=%.1f Glicko=%.1f/%.0f σ=%.3f",
+					con.Logf("Seeding ratings → A: Elo=%.1f Glicko=%.1f/%.0f σ=%.3f | B: Elo=%.1f Glicko=%.1f/%.0f σ=%.3f",
 						elo.A, gA.Rating, gA.RD, gA.Volatility, elo.B, gB.Rating, gB.RD, gB.Volatility)
 				}
 			}
@@ -1937,9 +2595,9 @@ func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 
 		// create match + start rating point
 		if db != nil {
-			id, err := db.CreateMatch(context.Background(), sb, bb, startStack, seeds, int64(base), eloStart, eloK, eloPerHand, eloWeightPot)
+			id, err := db.CreateMatch(context.Background(), string(cfg.Variant), string(cfg.Limit()), deckKey.Commitment(), sb, bb, startStack, seeds, int64(base), eloStart, eloK, eloPerHand, eloWeightPot, runSpec.Raw)
 			if err != nil {
-				log.Printf("CreateMatch failed: %v (disabling DB this run)", err)
+				con.Logf("CreateMatch failed: %v (disabling DB this run)", err)
 				db = nil
 			} else {
 				matchID = id
@@ -1948,7 +2606,7 @@ func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 					gA.Rating, gA.RD, gA.Volatility,
 					gB.Rating, gB.RD, gB.Volatility,
 				); err != nil {
-					log.Printf("InsertRatingPoint(start) failed: %v", err)
+					con.Logf("InsertRatingPoint(start) failed: %v", err)
 				}
 			}
 		}
@@ -1956,24 +2614,64 @@ func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 
 	elo.SetAccuracy(accA, accB)
 
+	// checkpoint saves the state after `pairs` completed pairs. Failures are
+	// logged and the match goes on; it just cannot resume past that point.
+	spec, specErr := duelSpec(a, b)
+	checkpoint := func(pairs int) {
+		if db == nil || matchID == 0 {
+			return
+		}
+		if specErr != nil {
+			con.Logf("Checkpoint skipped: %v (match will not be resumable)", specErr)
+			return
+		}
+		st := duelCheckpoint{
+			MatchID: matchID, Spec: spec, SeedBase: base, SeedState: sm.state, DeckKey: deckKey.String(), Pairs: pairs,
+			BotA: botAID, BotB: botBID, BankA: a.Bank, BankB: b.Bank, WinsA: a.Wins, WinsB: b.Wins,
+			Elo: elo, GA: *gA, GB: *gB, StatsA: statsA, StatsB: statsB, Tallies: tallies,
+			PairWinsA: pairWinsA, PairTies: pairTies, Margins: margins, Aivat: aivatPairs,
+		}
+		if seq != nil {
+			st.SeqWinsA, st.SeqWinsB, st.SeqTies = seq.winsA, seq.winsB, seq.ties
+		}
+		raw, err := json.Marshal(st)
+		if err == nil {
+			err = db.SaveMatchCheckpoint(context.Background(), matchID, pairs, raw)
+		}
+		if err != nil {
+			con.Logf("SaveMatchCheckpoint(pair %d) failed: %v", pairs, err)
+		}
+	}
+	if cp == nil {
+		checkpoint(0)
+	}
+	if matchID != 0 && opts.OnMatch != nil {
+		opts.OnMatch(matchID)
+	}
+
 	// ---- loop pairs
-	for i := 0; i < seeds; i++ {
+	finished := true
+	for i := startPair; i < seeds; i++ {
+		con.Flush()
 		if stopFlag.Load() && gracefulOnly {
-			fmt.Println(warn("Termination requested (graceful). Ending match after previous hand."))
+			con.Println(warn("Termination requested (graceful). Ending match after previous hand."))
+			finished = false
 			break
 		}
 
 		seed := int64(sm.next())
-		fmt.Printf("%s starting pair %d/%d (seed=%d)\n", dim("▶"), i+1, seeds, seed)
+		con.Printf("%s starting pair %d/%d (seed=%d)\n", dim("▶"), i+1, seeds, seed)
 
 		// Hand 1: A=SB, B=BB
-		deck1 := engine.NewDeck(seed)
+		deck1 := cfg.Variant.VerifiableDeck(deckKey, i+1)
 		h1 := engine.NewHand(fmt.Sprintf("duel-%dA", i+1), cfg, deck1)
 		statsA.addHand(engine.SB)
 		statsB.addHand(engine.BB)
-		w1, pot1, dSB1, dBB1, aborted := playHandMatch(context.Background(), h1, &a, &b, checkStop, gracefulOnly, tallies, db, matchID, i+1)
+		rng1, rng2 := newHandRNG(seed, mixedPolicy), newHandRNG(seed, mixedPolicy)
+		w1, pot1, dSB1, dBB1, avSB1, aborted := playHandMatch(context.Background(), h1, &a, &b, checkStop, gracefulOnly, tallies, db, matchID, i+1, rng1, con)
 		if aborted {
-			fmt.Println(bad("Match aborted by user (immediate)."))
+			con.Println(bad("Match aborted by user (immediate)."))
+			finished = false
 			break
 		}
 		statsA.addNet(engine.SB, dSB1)
@@ -1983,13 +2681,14 @@ func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 		foldA1, foldB1 := foldDecisionScores(h1, true)
 
 		// Hand 2: swap seats, same deck
-		deck2 := engine.NewDeck(seed)
+		deck2 := cfg.Variant.VerifiableDeck(deckKey, i+1)
 		h2 := engine.NewHand(fmt.Sprintf("duel-%dB", i+1), cfg, deck2)
 		statsA.addHand(engine.BB)
 		statsB.addHand(engine.SB)
-		w2, pot2, dSB2, dBB2, aborted2 := playHandMatch(context.Background(), h2, &b, &a, checkStop, gracefulOnly, tallies, db, matchID, i+1)
+		w2, pot2, dSB2, dBB2, avSB2, aborted2 := playHandMatch(context.Background(), h2, &b, &a, checkStop, gracefulOnly, tallies, db, matchID, i+1, rng2, con)
 		if aborted2 {
-			fmt.Println(bad("Match aborted by user (immediate)."))
+			con.Println(bad("Match aborted by user (immediate)."))
+			finished = false
 			break
 		}
 		statsA.addNet(engine.BB, dBB2)
@@ -1999,9 +2698,9 @@ func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 		// mirrored board sanity
 		if boardA != "" && boardB != "" {
 			if boardA == boardB {
-				fmt.Println(dim("Mirror check ✓ same board: " + boardA))
+				con.Println(dim("Mirror check ✓ same board: " + boardA))
 			} else {
-				fmt.Println(bad("Mirror check ✗ boards differ: A=" + boardA + " | B=" + boardB))
+				con.Println(bad("Mirror check ✗ boards differ: A=" + boardA + " | B=" + boardB))
 			}
 		}
 
@@ -2012,6 +2711,7 @@ func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 		// ----- pair-level updates
 		chipsA := dSB1 + dBB2
 		pairPot := pot1 + pot2
+		aivatA := avSB1 - avSB2 // A sat BB in hand 2
 
 		// Elo pair update (tempered)
 		winsA := sa1 + sa2
@@ -2020,24 +2720,20 @@ func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 		foldScoreB := foldB1 + foldB2
 
 		dA, dB := elo.UpdateFromMirror(chipsA, pairPot, bb, winsA, winsB, foldScoreA, foldScoreB)
-		fmt.Printf("%s %s → chipsA=%+d potSum=%d wins=%.1f-%.1f foldScore=%.1f-%.1f  |  A:%.1f (%+.1f)  B:%.1f (%+.1f)\n",
+		con.Printf("%s %s → chipsA=%+d potSum=%d wins=%.1f-%.1f foldScore=%.1f-%.1f  |  A:%.1f (%+.1f)  B:%.1f (%+.1f)\n",
 			mag("Elo (pair)"), bold(fmt.Sprintf("seed %d", i+1)),
 			chipsA, pairPot, winsA, winsB, foldScoreA, foldScoreB,
 			elo.A, dA, elo.B, dB)
 
 		// Glicko-2 per pair (use normalized chip margin → S via tanh)
-		effStack := float64(cfg.StartStack)
-		if effStack <= 0 {
-			effStack = float64(100 * bb)
-		}
 		m := float64(chipsA) / effStack
 		S := 0.5 + 0.5*math.Tanh(m)
 
 		oldA := *gA
 		oldB := *gB
-		gA.UpdatePair(&oldB, S, tau)
-		gB.UpdatePair(&oldA, 1.0-S, tau)
-		fmt.Printf("%s %s → A:r=%.1f RD=%.0f σ=%.3f | B:r=%.1f RD=%.0f σ=%.3f\n",
+		gA.UpdatePair(&oldB, S, glickoTau)
+		gB.UpdatePair(&oldA, 1.0-S, glickoTau)
+		con.Printf("%s %s → A:r=%.1f RD=%.0f σ=%.3f | B:r=%.1f RD=%.0f σ=%.3f\n",
 			mag("Glicko2 (pair)"), bold(fmt.Sprintf("seed %d", i+1)),
 			gA.Rating, gA.RD, gA.Volatility, gB.Rating, gB.RD, gB.Volatility)
 
@@ -2050,64 +2746,112 @@ func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 			pairTies++
 		}
 		margins = append(margins, m)
+		aivatPairs = append(aivatPairs, aivatA)
+		if seq != nil {
+			seq.add(chipsA)
+		}
 
-		// rating point row
+		// raw outcome row (replayed by `ratings recompute`) + rating point row
 		if db != nil && matchID != 0 {
 			idx := i + 1
+			if err := db.InsertPairResult(context.Background(), matchID, store.PairResult{
+				Index: idx, Seed: seed, ChipsA: chipsA, PotSum: pairPot,
+				WinsA: winsA, WinsB: winsB, FoldScoreA: foldScoreA, FoldScoreB: foldScoreB,
+				AccA: elo.AccA, AccB: elo.AccB, AivatA: aivatA,
+			}); err != nil {
+				con.Logf("InsertPairResult(pair %d) failed: %v", idx, err)
+			}
 			if err := db.InsertRatingPoint(
 				context.Background(), matchID, "after_pair", &idx,
 				elo.A, elo.B,
 				gA.Rating, gA.RD, gA.Volatility,
 				gB.Rating, gB.RD, gB.Volatility,
 			); err != nil {
-				log.Printf("InsertRatingPoint(pair %d) failed: %v", idx, err)
+				con.Logf("InsertRatingPoint(pair %d) failed: %v", idx, err)
 			}
 		}
+		checkpoint(i + 1)
 
 		// conservation + bust
 		total := a.Bank + b.Bank
-		fmt.Printf("%s seed %d → %s:%d  %s:%d  | %s %d %s\n",
+		con.Printf("%s seed %d → %s:%d  %s:%d  | %s %d %s\n",
 			dim("After"), i+1, bold("A bank"), a.Bank, bold("B bank"), b.Bank,
 			dim("total chips"), total, dim("(conserved)"))
 
 		if a.Bank <= 0 || b.Bank <= 0 {
-			fmt.Println(warn("Bank reached zero; ending match."))
+			con.Println(warn("Bank reached zero; ending match."))
 			break
 		}
-		fmt.Printf("%s finished pair %d/%d\n", dim("✓"), i+1, seeds)
-		fmt.Println(dim(strings.Repeat("—", 36)))
+		con.Printf("%s finished pair %d/%d\n", dim("✓"), i+1, seeds)
+		con.Println(dim(strings.Repeat("—", 36)))
+
+		if seq != nil {
+			if verdict = seq.check(); verdict != "" {
+				con.Printf("%s %s after %d pairs (A %d – B %d, %d tied); stopping.\n",
+					bold("Sequential test →"), verdict, seq.pairs(), seq.winsA, seq.winsB, seq.ties)
+				break
+			}
+		}
+	}
+	if seq != nil && verdict == "" && finished {
+		verdict = seq.final()
 	}
 
 	// ----- summary
 	sum := a.Bank + b.Bank
-	fmt.Printf("\n%s A bank:%d (wins=%d) | B bank:%d (wins=%d) | Total:%d\n",
+	con.Printf("\n%s A bank:%d (wins=%d) | B bank:%d (wins=%d) | Total:%d\n",
 		bold("RESULTS →"), a.Bank, a.Wins, b.Bank, b.Wins, sum)
-	fmt.Printf("%s A:%.1f | B:%.1f (pairs=%d)\n",
+	con.Printf("%s A:%.1f | B:%.1f (pairs=%d)\n",
 		bold("Elo final →"), elo.A, elo.B, elo.Games)
 
 	lo, hi := WilsonCI95(pairWinsA, pairTies, pairTotal)
-	fmt.Printf("%s pairs=%d → A win-prob 95%% CI=[%.3f, %.3f]\n",
+	con.Printf("%s pairs=%d → A win-prob 95%% CI=[%.3f, %.3f]\n",
 		bold("CI (Wilson) →"), pairTotal, lo, hi)
 
 	blo, bhi := BootstrapCI95(margins, 1000)
-	fmt.Printf("%s normalized margin mean 95%% CI=[%.4f, %.4f]\n",
+	con.Printf("%s normalized margin mean 95%% CI=[%.4f, %.4f]\n",
 		bold("CI (bootstrap) →"), blo, bhi)
 
-	fmt.Printf("%s A:r=%.1f RD=%.0f | B:r=%.1f RD=%.0f (pairs=%d)\n",
+	rawChips := make([]float64, len(margins))
+	for i, m := range margins {
+		rawChips[i] = m * effStack
+	}
+	rawBB, rawSE := pairBB100(rawChips, bb)
+	avBB, avSE := pairBB100(aivatPairs, bb)
+	con.Printf("%s A raw %+.1f ± %.1f bb/100 | AIVAT %+.1f ± %.1f bb/100 (95%% CI half-widths)\n",
+		bold("Win rate →"), rawBB, 1.96*rawSE, avBB, 1.96*avSE)
+
+	if verdict != "" {
+		con.Printf("%s %s (%s, %d of max %d pairs)\n", bold("Verdict →"), verdict, seq.cfg.Rule, pairTotal, seeds)
+	}
+
+	con.Printf("%s A:r=%.1f RD=%.0f | B:r=%.1f RD=%.0f (pairs=%d)\n",
 		bold("Glicko2 final →"), gA.Rating, gA.RD, gB.Rating, gB.RD, gA.Games)
+	if !perPairGlicko {
+		con.Println(dim(fmt.Sprintf("(provisional: career Glicko-2 updates when the %s rating period closes)", runSpec.Rating.GlickoPeriod)))
+	}
 
-	fmt.Printf("%s dealt:%d net:%d | SB dealt:%d net:%d | BB dealt:%d net:%d\n",
+	con.Printf("%s dealt:%d net:%d | SB dealt:%d net:%d | BB dealt:%d net:%d\n",
 		bold("Stats A →"),
 		statsA.Overall.Hands, statsA.Overall.NetChips,
 		statsA.SB.Hands, statsA.SB.NetChips,
 		statsA.BB.Hands, statsA.BB.NetChips)
-	fmt.Printf("%s dealt:%d net:%d | SB dealt:%d net:%d | BB dealt:%d net:%d\n",
+	con.Printf("%s dealt:%d net:%d | SB dealt:%d net:%d | BB dealt:%d net:%d\n",
 		bold("Stats B →"),
 		statsB.Overall.Hands, statsB.Overall.NetChips,
 		statsB.SB.Hands, statsB.SB.NetChips,
 		statsB.BB.Hands, statsB.BB.NetChips)
 
-	printTallies(tallies, a, b)
+	printTallies(con, tallies, a, b)
+
+	// A stopped match stays open: no end point, career stats, judge or
+	// deck key until --resume plays the remaining pairs.
+	if !finished {
+		if db != nil && matchID != 0 {
+			con.Logf("match %d left open after pair %d; continue with --resume %d", matchID, pairTotal, matchID)
+		}
+		return duelResult{MatchID: matchID, Hands: statsA.Overall.Hands, GA: *gA, GB: *gB}
+	}
 
 	// ----- DB: final point, participants/tallies, career ratings, close
 	if db != nil && matchID != 0 {
@@ -2117,10 +2861,11 @@ func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 			gA.Rating, gA.RD, gA.Volatility,
 			gB.Rating, gB.RD, gB.Volatility,
 		); err != nil {
-			log.Printf("InsertRatingPoint(end) failed: %v", err)
+			con.Logf("InsertRatingPoint(end) failed: %v", err)
 		}
 
-		rePtr := strptr(os.Getenv("OPENAI_REASONING_EFFORT"))
+		reA := strptr(botVariant(a).ReasoningEffort)
+		reB := strptr(botVariant(b).ReasoningEffort)
 		aChk, aCall, aRaise, aFold := tallyCounts(tallies[a.Label])
 		bChk, bCall, bRaise, bFold := tallyCounts(tallies[b.Label])
 
@@ -2132,16 +2877,16 @@ func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 		if err := db.InsertParticipantsAndTallies(
 			context.Background(), matchID,
 			// A
-			"A", botAID, a.Model, companyLabel(), rePtr, startStack, a.Bank, a.Wins,
+			"A", botAID, botVariant(a).Label(), companyLabel(), reA, startStack, a.Bank, a.Wins,
 			handsA, statsA.SB.Hands, statsA.BB.Hands, netA,
 			// B
-			"B", botBID, b.Model, companyLabel(), rePtr, startStack, b.Bank, b.Wins,
+			"B", botBID, botVariant(b).Label(), companyLabel(), reB, startStack, b.Bank, b.Wins,
 			handsB, statsB.SB.Hands, statsB.BB.Hands, netB,
 			// tallies
 			aChk, aCall, aRaise, aFold,
 			bChk, bCall, bRaise, bFold,
 		); err != nil {
-			log.Printf("InsertParticipantsAndTallies failed: %v", err)
+			con.Logf("InsertParticipantsAndTallies failed: %v", err)
 		}
 
 		accString := func(good, total int) string {
@@ -2153,13 +2898,13 @@ func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 		}
 
 		var judgeGoodA, judgeTotalA, judgeGoodB, judgeTotalB int
-		if db != nil && matchID != 0 {
+		if db != nil && matchID != 0 && runSpec.Judge.Enabled {
 			if err := judge.EvaluateMatchMC(context.Background(), db, matchID); err != nil {
-				log.Printf("MCJudge failed for match %d: %v", matchID, err)
+				con.Logf("MCJudge failed for match %d: %v", matchID, err)
 			} else {
-				log.Printf("MCJudge complete for match %d", matchID)
+				con.Logf("MCJudge complete for match %d", matchID)
 				if accMap, err := db.MatchJudgeAccuracy(context.Background(), matchID); err != nil {
-					log.Printf("MatchJudgeAccuracy failed for match %d: %v", matchID, err)
+					con.Logf("MatchJudgeAccuracy failed for match %d: %v", matchID, err)
 				} else {
 					if acc, ok := accMap[botAID]; ok {
 						judgeGoodA, judgeTotalA = acc.Good, acc.Total
@@ -2171,94 +2916,315 @@ func runDuel(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 			}
 		}
 
-		fmt.Println(bold("MCJudge accuracy (this match):"))
-		fmt.Printf("  %s %s %s\n", bold("A"), a.Model, accString(judgeGoodA, judgeTotalA))
-		fmt.Printf("  %s %s %s\n", bold("B"), b.Model, accString(judgeGoodB, judgeTotalB))
+		con.Println(bold("MCJudge accuracy (this match):"))
+		con.Printf("  %s %s %s\n", bold("A"), a.Model, accString(judgeGoodA, judgeTotalA))
+		con.Printf("  %s %s %s\n", bold("B"), b.Model, accString(judgeGoodB, judgeTotalB))
 
 		// persist career ratings, hands, and judge accuracy
-		if err := db.UpdateBotRatings(context.Background(), botAID, elo.A, gA.Rating, gA.RD, gA.Volatility, 1, handsA, judgeGoodA, judgeTotalA); err != nil {
-			log.Printf("UpdateBotRatings(A) failed: %v", err)
-		}
-		if err := db.UpdateBotRatings(context.Background(), botBID, elo.B, gB.Rating, gB.RD, gB.Volatility, 1, handsB, judgeGoodB, judgeTotalB); err != nil {
-			log.Printf("UpdateBotRatings(B) failed: %v", err)
+		if perPairGlicko {
+			if err := db.UpdateBotRatings(context.Background(), botAID, elo.A, gA.Rating, gA.RD, gA.Volatility, 1, handsA, judgeGoodA, judgeTotalA); err != nil {
+				con.Logf("UpdateBotRatings(A) failed: %v", err)
+			}
+			if err := db.UpdateBotRatings(context.Background(), botBID, elo.B, gB.Rating, gB.RD, gB.Volatility, 1, handsB, judgeGoodB, judgeTotalB); err != nil {
+				con.Logf("UpdateBotRatings(B) failed: %v", err)
+			}
+		} else {
+			if err := db.UpdateBotCareer(context.Background(), botAID, elo.A, 1, handsA, judgeGoodA, judgeTotalA); err != nil {
+				con.Logf("UpdateBotCareer(A) failed: %v", err)
+			}
+			if err := db.UpdateBotCareer(context.Background(), botBID, elo.B, 1, handsB, judgeGoodB, judgeTotalB); err != nil {
+				con.Logf("UpdateBotCareer(B) failed: %v", err)
+			}
 		}
 		if err := db.SyncJudgeAccuracy(context.Background(), botAID, botBID); err != nil {
-			log.Printf("SyncJudgeAccuracy failed: %v", err)
+			con.Logf("SyncJudgeAccuracy failed: %v", err)
 		} else {
 			careerParts := make([]string, 0, 2)
 			if botAID != 0 {
 				if good, total, err := db.GetJudgeAccuracy(context.Background(), botAID); err != nil {
-					log.Printf("GetJudgeAccuracy(A) failed: %v", err)
+					con.Logf("GetJudgeAccuracy(A) failed: %v", err)
 				} else {
 					careerParts = append(careerParts, fmt.Sprintf("%s %s %s", bold("A"), a.Model, accString(good, total)))
 				}
 			}
 			if botBID != 0 {
 				if good, total, err := db.GetJudgeAccuracy(context.Background(), botBID); err != nil {
-					log.Printf("GetJudgeAccuracy(B) failed: %v", err)
+					con.Logf("GetJudgeAccuracy(B) failed: %v", err)
 				} else {
 					careerParts = append(careerParts, fmt.Sprintf("%s %s %s", bold("B"), b.Model, accString(good, total)))
 				}
 			}
 			if len(careerParts) > 0 {
-				fmt.Printf("%s accuracy totals → %s\n", bold("Career"), strings.Join(careerParts, " | "))
+				con.Printf("%s accuracy totals → %s\n", bold("Career"), strings.Join(careerParts, " | "))
 			}
 		}
 
-		if err := db.CompleteMatch(context.Background(), matchID); err != nil {
-			log.Printf("CompleteMatch failed: %v", err)
+		stopRule, outcome := seq.outcome(verdict)
+		if err := db.SetMatchOutcome(context.Background(), matchID, pairTotal, stopRule, outcome); err != nil {
+			con.Logf("SetMatchOutcome failed: %v", err)
+		}
+
+		// Only here, with every pair dealt, is the deck key safe to reveal.
+		if err := db.CompleteMatch(context.Background(), matchID, deckKey.String()); err != nil {
+			con.Logf("CompleteMatch failed: %v", err)
 		} else {
-			log.Printf("match %d persisted.", matchID)
+			con.Logf("match %d persisted.", matchID)
+			if n, err := closeRatingPeriods(context.Background(), db); err != nil {
+				con.Logf("closeRatingPeriods failed: %v", err)
+			} else if n > 0 && !perPairGlicko {
+				con.Logf("closed %d Glicko-2 rating period(s).", n)
+			}
 		}
 	}
+	return duelResult{MatchID: matchID, Finished: true, Hands: statsA.Overall.Hands, GA: *gA, GB: *gB}
 }
 
-// runDuelMatrix runs pairwise duels for all models listed in OPENAI_MODELS (comma-separated).
+// runSeedBase is the configured deck seed base, or a fresh crypto seed.
+func runSeedBase() uint64 {
+	if runSpec.Seeds.Base != nil {
+		return uint64(*runSpec.Seeds.Base)
+	}
+	return secureBaseSeed()
+}
+
+// matrixPlayers lists the matrix entrants: the run config's players, or the
+// comma-separated OPENAI_MODELS (OPENROUTER_MODELS) env list.
 // Example: OPENAI_MODELS="gpt-4o-mini,gpt-5-mini,gpt-4.1-mini-2025-04-14"
-func runDuelMatrix(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
+func matrixPlayers() []Player {
+	var out []Player
+	if len(runSpec.Players) > 0 {
+		for _, p := range runSpec.Players {
+			out = append(out, playerFromSpec("", p))
+		}
+		return out
+	}
 	useOpenRouter := llm.PreferOpenRouter()
 	raw := strings.TrimSpace(os.Getenv("OPENAI_MODELS"))
 	if raw == "" && useOpenRouter {
 		raw = strings.TrimSpace(os.Getenv("OPENROUTER_MODELS"))
 	}
-	if raw == "" {
-		if useOpenRouter {
-			log.Println("OPENROUTER_MODELS (or OPENAI_MODELS) is empty; supply a comma-separated list to use --duel-matrix.")
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, Player{Model: s})
+		}
+	}
+	return out
+}
+
+// runDuelMatrix plans a round-robin over the matrix entrants (format and
+// matches per pair from runSpec.Schedule) and plays it. With a DB the plan is
+// persisted first so --resume-schedule can pick it up after a crash.
+func runDuelMatrix(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
+	entrants := matrixPlayers()
+	if len(entrants) < 2 {
+		if llm.PreferOpenRouter() {
+			log.Println("Need at least two models in OPENROUTER_MODELS (or OPENAI_MODELS, or players in --config) for --duel-matrix.")
 		} else {
-			log.Println("OPENAI_MODELS is empty; supply a comma-separated list to use --duel-matrix.")
+			log.Println("Need at least two models in OPENAI_MODELS (or players in --config) for --duel-matrix.")
 		}
 		return
 	}
-	parts := []string{}
-	for _, s := range strings.Split(raw, ",") {
-		s = strings.TrimSpace(s)
-		if s != "" {
-			parts = append(parts, s)
+	mustPlayerKeys(entrants...)
+	sc := runSpec.Schedule
+	if sc.Format != config.FormatRoundRobin && sc.Format != config.FormatDoubleRoundRobin {
+		log.Printf("Unknown schedule format %q; want %s or %s.", sc.Format, config.FormatRoundRobin, config.FormatDoubleRoundRobin)
+		return
+	}
+	if sc.MatchesPerPair <= 0 {
+		sc.MatchesPerPair = 1
+	}
+
+	// Every match gets its own seed base from one stream, so the whole
+	// schedule is reproducible from a single number.
+	base := runSeedBase()
+	sm := newSeedStream(base)
+	var items []store.ScheduleItem
+	for i, p := range schedule.RoundRobin(len(entrants), sc.Legs(), sc.MatchesPerPair) {
+		items = append(items, store.ScheduleItem{
+			Index: i, Round: p.Round, A: p.A, B: p.B,
+			SeedBase: int64(sm.next()), Status: store.ItemPending,
+		})
+	}
+	log.Printf("Schedule: %s, %d entrants, %d per pair → %d matches (seed base %d)",
+		sc.Format, len(entrants), sc.MatchesPerPair, len(items), base)
+
+	var scheduleID int64
+	if db != nil {
+		spec := runSpec
+		spec.Mode = config.ModeMatrix
+		spec.Schedule = sc
+		spec.Players = spec.Players[:0:0]
+		for _, p := range entrants {
+			spec.Players = append(spec.Players, specPlayer(p))
+		}
+		text, err := spec.YAML()
+		if err == nil {
+			scheduleID, err = db.CreateSchedule(context.Background(), sc.Format, sc.MatchesPerPair, int64(base), text, items)
+		}
+		if err != nil {
+			log.Printf("CreateSchedule failed: %v (schedule will not be resumable)", err)
+		} else {
+			log.Printf("Schedule %d persisted; resume with --resume-schedule %d", scheduleID, scheduleID)
 		}
 	}
-	if len(parts) < 2 {
-		log.Println("Need at least two models in OPENAI_MODELS for --duel-matrix.")
+	runSchedule(checkStop, gracefulOnly, db, scheduleID, entrants, items)
+}
+
+// resumeSchedule reloads a persisted schedule, restores its run spec and
+// plays every item that never finished. Items stopped early or left
+// "running" by a crash continue their match from its last checkpointed
+// pair, or are replayed from scratch when there is none.
+func resumeSchedule(checkStop func(bool) bool, gracefulOnly bool, db *store.DB, id int64) {
+	if db == nil {
+		log.Println("--resume-schedule needs DATABASE_URL.")
+		return
+	}
+	sc, items, err := db.GetSchedule(context.Background(), id)
+	if err != nil {
+		log.Printf("Load schedule %d: %v", id, err)
+		return
+	}
+	if sc.EndedAt != nil {
+		log.Printf("Schedule %d already completed at %s.", id, sc.EndedAt.Format(time.RFC3339))
+		return
+	}
+	spec, err := config.Parse([]byte(sc.Spec), ".yaml")
+	if err != nil {
+		log.Printf("Schedule %d: stored spec: %v", id, err)
 		return
 	}
+	runSpec = *spec
+	applyLimits(runSpec)
+	var entrants []Player
+	for _, p := range spec.Players {
+		entrants = append(entrants, playerFromSpec("", p))
+	}
+	mustPlayerKeys(entrants...)
+	log.Printf("Resuming schedule %d (%s, %d entrants, %d matches)", id, sc.Format, len(entrants), len(items))
+	runSchedule(checkStop, gracefulOnly, db, id, entrants, items)
+}
 
-	for i := 0; i < len(parts); i++ {
-		for j := i + 1; j < len(parts); j++ {
-			if stopFlag.Load() && gracefulOnly {
-				log.Println("Stop requested; ending matrix loop.")
-				return
+// runSchedule plays the unfinished items, recording each one's match id and
+// status as it goes. scheduleID 0 runs without persistence. With
+// runSpec.Schedule.Workers > 1 independent matches run concurrently: the
+// next item in plan order starts as soon as a worker is free and neither of
+// its entrants is playing, so a bot's career rating is never read and
+// written by two matches at once. Each match draws decks and harness
+// randomness from its own seed base, so chip results do not depend on the
+// worker count; parallel output is buffered per match and flushed a pair at
+// a time.
+func runSchedule(checkStop func(bool) bool, gracefulOnly bool, db *store.DB, scheduleID int64, entrants []Player, items []store.ScheduleItem) {
+	persist := db != nil && scheduleID != 0
+	workers := max(runSpec.Schedule.Workers, 1)
+
+	var queue []store.ScheduleItem
+	for _, it := range items {
+		if it.Status == store.ItemDone {
+			continue
+		}
+		if it.A < 0 || it.B < 0 || it.A >= len(entrants) || it.B >= len(entrants) {
+			log.Printf("Schedule item %d references a missing entrant; skipping.", it.Index)
+			continue
+		}
+		queue = append(queue, it)
+	}
+	if workers > 1 {
+		log.Printf("Running %d matches on %d workers.", len(queue), workers)
+	}
+
+	play := func(it store.ScheduleItem) duelResult {
+		con := stdoutConsole
+		if workers > 1 {
+			con = newBufferedConsole(dim(fmt.Sprintf("[#%d] ", it.Index+1)))
+		}
+		a, b := entrants[it.A], entrants[it.B]
+		a.Label, a.Name = "A", "A"
+		b.Label, b.Name = "B", "B"
+		con.Logf("Matrix duel %d/%d (round %d): A=%s vs B=%s", it.Index+1, len(items), it.Round+1, a.Model, b.Model)
+
+		opts := duelOpts{SeedBase: uint64(it.SeedBase), Console: con}
+		if persist {
+			// An item stopped or cut off mid-match continues from its checkpoint.
+			if it.MatchID != nil {
+				cp, err := loadDuelCheckpoint(context.Background(), db, *it.MatchID)
+				switch {
+				case errors.Is(err, errMatchEnded):
+					// Stopped before stopped matches were left open.
+					if err := db.FinishScheduleItem(context.Background(), scheduleID, it.Index, store.ItemDone, *it.MatchID); err != nil {
+						con.Logf("FinishScheduleItem(%d) failed: %v", it.Index, err)
+					}
+					return duelResult{MatchID: *it.MatchID, Finished: true}
+				case err != nil:
+					con.Logf("Schedule item %d: %v; replaying from scratch.", it.Index, err)
+				default:
+					opts.Resume = cp
+				}
 			}
-			a := parts[i]
-			b := parts[j]
-			log.Printf("Matrix duel: A=%s vs B=%s\n", a, b)
-			// Set envs for this duel run
-			if useOpenRouter {
-				os.Setenv("OPENROUTER_MODEL_A", a)
-				os.Setenv("OPENROUTER_MODEL_B", b)
-			} else {
-				os.Setenv("OPENAI_MODEL_A", a)
-				os.Setenv("OPENAI_MODEL_B", b)
+			if err := db.StartScheduleItem(context.Background(), scheduleID, it.Index); err != nil {
+				con.Logf("StartScheduleItem(%d) failed: %v", it.Index, err)
+			}
+			opts.OnMatch = func(matchID int64) {
+				if err := db.SetScheduleItemMatch(context.Background(), scheduleID, it.Index, matchID); err != nil {
+					con.Logf("SetScheduleItemMatch(%d) failed: %v", it.Index, err)
+				}
 			}
-			runDuel(checkStop, gracefulOnly, db)
+		}
+		res := runDuel(checkStop, gracefulOnly, db, a, b, opts)
+		if persist {
+			status := store.ItemDone
+			if !res.Finished {
+				status = store.ItemStopped
+			}
+			if err := db.FinishScheduleItem(context.Background(), scheduleID, it.Index, status, res.MatchID); err != nil {
+				log.Printf("FinishScheduleItem(%d) failed: %v", it.Index, err)
+			}
+		}
+		return res
+	}
+
+	type outcome struct {
+		it  store.ScheduleItem
+		res duelResult
+	}
+	results := make(chan outcome)
+	busy := map[int]bool{}
+	running := 0
+	halted := false
+	for {
+		if !halted && stopFlag.Load() && gracefulOnly {
+			log.Println("Stop requested; ending matrix loop.")
+			halted = true
+		}
+		for k := 0; !halted && running < workers && k < len(queue); {
+			it := queue[k]
+			if busy[it.A] || busy[it.B] {
+				k++
+				continue
+			}
+			queue = append(queue[:k], queue[k+1:]...)
+			busy[it.A], busy[it.B] = true, true
+			running++
+			go func() { results <- outcome{it, play(it)} }()
+		}
+		if running == 0 {
+			break
+		}
+		o := <-results
+		running--
+		busy[o.it.A], busy[o.it.B] = false, false
+		if !o.res.Finished && !halted {
+			log.Println("Match stopped early; ending matrix loop.")
+			halted = true
+		}
+	}
+	if halted || len(queue) > 0 {
+		return
+	}
+	if persist {
+		if err := db.CompleteSchedule(context.Background(), scheduleID); err != nil {
+			log.Printf("CompleteSchedule failed: %v", err)
+		} else {
+			log.Printf("schedule %d complete.", scheduleID)
 		}
 	}
 }
@@ -2267,12 +3233,12 @@ func runDuelMatrix(checkStop func(bool) bool, gracefulOnly bool, db *store.DB) {
 // ===== misc helpers =====
 //
 
-func printTallies(t map[string]*ActionTally, a, b Player) {
+func printTallies(con *console, t map[string]*ActionTally, a, b Player) {
 	if len(t) == 0 {
 		return
 	}
-	fmt.Println()
-	fmt.Println(bold("Action mix by player:"))
+	con.Println()
+	con.Println(bold("Action mix by player:"))
 	labels := []string{"A", "B"}
 	modelOf := map[string]string{"A": a.Model, "B": b.Model}
 	for _, lbl := range labels {
@@ -2287,7 +3253,7 @@ func printTallies(t map[string]*ActionTally, a, b Player) {
 			}
 			return fmt.Sprintf("%.0f%%", 100.0*float64(n)/float64(total))
 		}
-		fmt.Printf("  %s (%s) → check:%d(%s)  call:%d(%s)  raise:%d(%s)  fold:%d(%s)  | total:%d\n",
+		con.Printf("  %s (%s) → check:%d(%s)  call:%d(%s)  raise:%d(%s)  fold:%d(%s)  | total:%d\n",
 			lbl, dim(modelShort(modelOf[lbl])),
 			x.Check, p(x.Check),
 			x.Call, p(x.Call),
@@ -2295,6 +3261,15 @@ func printTallies(t map[string]*ActionTally, a, b Player) {
 			x.Fold, p(x.Fold),
 			total,
 		)
+		parts := []string{}
+		for _, src := range parseSources {
+			if n := x.Sources[src]; n > 0 {
+				parts = append(parts, fmt.Sprintf("%s:%d(%s)", src, n, p(n)))
+			}
+		}
+		if len(parts) > 0 {
+			con.Printf("      %s %s\n", dim("parse →"), strings.Join(parts, "  "))
+		}
 	}
 }
 
@@ -2332,11 +3307,11 @@ func handScore(w engine.Seat, aWasSB bool) (sa, sb float64) {
 
 func foldDecisionScores(h *engine.Hand, aWasSB bool) (scoreA, scoreB float64) {
 	board := finalBoardForHand(h)
-	if len(h.SB.Hole) != 2 || len(h.BB.Hole) != 2 || len(board) < 5 {
+	if len(h.SB.Hole) == 0 || len(h.BB.Hole) == 0 || len(board) < 5 {
 		return 0, 0
 	}
 
-	finalWinner := simulateShowdown(h.SB.Hole, h.BB.Hole, board)
+	finalWinner := simulateShowdown(h.Cfg, h.SB.Hole, h.BB.Hole, board)
 
 	if h.SB.Folded {
 		delta := foldDelta(engine.SB, finalWinner)
@@ -2370,10 +3345,11 @@ func finalBoardForHand(h *engine.Hand) []engine.Card {
 	return append(board, h.Deck[:need]...)
 }
 
-func simulateShowdown(sbHole, bbHole, board []engine.Card) engine.Seat {
+func simulateShowdown(cfg engine.Config, sbHole, bbHole, board []engine.Card) engine.Seat {
 	sb := &engine.Player{Seat: engine.SB, Hole: append([]engine.Card{}, sbHole...)}
 	bb := &engine.Player{Seat: engine.BB, Hole: append([]engine.Card{}, bbHole...)}
 	sim := &engine.Hand{
+		Cfg:   cfg,
 		SB:    sb,
 		BB:    bb,
 		Board: append([]engine.Card{}, board...),
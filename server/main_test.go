@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"ai-thunderdome/server/config"
+)
+
+func TestPromptHashDefault(t *testing.T) {
+	for _, k := range []string{"OPENAI_TEMPERATURE", "OPENROUTER_TEMPERATURE", "LLM_PROVIDER", "OPENAI_API_BASE", "OPENAI_BASE_URL", "OPENROUTER_API_BASE", "OPENROUTER_BASE_URL", "OPENROUTER_API_KEY"} {
+		t.Setenv(k, "")
+	}
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	saved := runSpec
+	defer func() { runSpec = saved }()
+
+	runSpec = config.Default()
+	if got, want := promptHash(), defaultPromptHash(); got != want {
+		t.Fatalf("default run: promptHash %s, defaultPromptHash %s", got, want)
+	}
+	if l := botVariant(Player{Model: "gpt-5-mini"}).Label(); l != "gpt-5-mini" {
+		t.Errorf("default run labelled %q", l)
+	}
+
+	runSpec.Game.Betting = config.BettingFixedLimit
+	if promptHash() == defaultPromptHash() {
+		t.Fatal("fixed-limit prompt hashed like the default")
+	}
+	v := botVariant(Player{Model: "gpt-5-mini"})
+	if want := "gpt-5-mini (prompt " + v.PromptHash[:6] + ")"; v.Label() != want {
+		t.Errorf("fixed-limit run labelled %q, want %q", v.Label(), want)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"ai-thunderdome/server/config"
+	"ai-thunderdome/server/store"
+)
+
+// glickoTau is the Glicko-2 system constant used everywhere.
+const glickoTau = 0.5
+
+// maxRD caps RD inflation at the rating of a brand-new bot.
+const maxRD = 350.0
+
+// pairScore maps A's chip margin over a mirrored pair to a Glicko-2 score:
+// the margin in starting stacks, squashed by tanh into [0, 1].
+func pairScore(chipsA, startStack, bb int) float64 {
+	effStack := float64(startStack)
+	if effStack <= 0 {
+		effStack = float64(100 * bb)
+	}
+	return 0.5 + 0.5*math.Tanh(float64(chipsA)/effStack)
+}
+
+// applyGlickoPeriod runs one Glicko-2 rating period over ratings: each bot
+// with pairs in games is updated once from all of them, against opponents as
+// they stood when the period opened; every other bot only has its RD
+// inflated (Glickman's step for an idle period).
+func applyGlickoPeriod(ratings map[int64]*Glicko2, games []store.PeriodMatch) {
+	start := make(map[int64]Glicko2, len(ratings))
+	for id, g := range ratings {
+		start[id] = *g
+	}
+	opp := func(id int64) *Glicko2 {
+		g, ok := start[id]
+		if !ok {
+			g = *NewGlicko2()
+		}
+		return &g
+	}
+	results := map[int64][]OpponentResult{}
+	for _, m := range games {
+		oa, ob := opp(m.BotA), opp(m.BotB)
+		for _, chips := range m.ChipsA {
+			s := pairScore(chips, m.StartStack, m.BB)
+			results[m.BotA] = append(results[m.BotA], OpponentResult{Opp: ob, S: s})
+			results[m.BotB] = append(results[m.BotB], OpponentResult{Opp: oa, S: 1 - s})
+		}
+	}
+	for id := range results {
+		if ratings[id] == nil {
+			ratings[id] = NewGlicko2()
+		}
+	}
+	for id, g := range ratings {
+		g.UpdateBatch(results[id], glickoTau)
+		g.RD = math.Min(g.RD, maxRD)
+	}
+}
+
+// planRatingPeriods cuts pending matches (oldest first, ended after
+// lastClose) into the periods that are complete at now and applies them to
+// ratings in order. "matches" periods hold PeriodMatches matches each and
+// close at the last one's end; "day" periods end at UTC midnights, and days
+// without matches still close (inflating everyone's RD).
+func planRatingPeriods(r config.Rating, lastClose, now time.Time, pending []store.PeriodMatch, ratings map[int64]*Glicko2) []store.PeriodClose {
+	var closes []store.PeriodClose
+	switch r.GlickoPeriod {
+	case config.PeriodMatches:
+		n := max(r.PeriodMatches, 1)
+		for len(pending) >= n {
+			chunk := pending[:n]
+			pending = pending[n:]
+			applyGlickoPeriod(ratings, chunk)
+			closes = append(closes, store.PeriodClose{ClosedAt: chunk[n-1].EndedAt, Kind: config.PeriodMatches, Matches: n})
+		}
+	case config.PeriodDay:
+		for end := lastClose.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour); !end.After(now); end = end.Add(24 * time.Hour) {
+			k := 0
+			for k < len(pending) && !pending[k].EndedAt.After(end) {
+				k++
+			}
+			applyGlickoPeriod(ratings, pending[:k])
+			closes = append(closes, store.PeriodClose{ClosedAt: end, Kind: config.PeriodDay, Matches: k})
+			pending = pending[k:]
+		}
+	}
+	return closes
+}
+
+// closeRatingPeriods closes every rating period that is complete under
+// runSpec.Rating and writes the new career Glicko-2 states. With per-pair
+// ratings (legacy) the matches are only marked as rated, since runDuel
+// already wrote their Glicko-2 updates.
+func closeRatingPeriods(ctx context.Context, db *store.DB) (int, error) {
+	var closed int
+	err := db.CloseRatingPeriods(ctx, func(last time.Time, pending []store.PeriodMatch, bots []store.GlickoState) ([]store.PeriodClose, []store.GlickoState) {
+		if runSpec.Rating.GlickoPeriod == config.PeriodPair {
+			if len(pending) == 0 {
+				return nil, nil
+			}
+			closed = 1
+			return []store.PeriodClose{{ClosedAt: pending[len(pending)-1].EndedAt, Kind: config.PeriodPair, Matches: len(pending)}}, nil
+		}
+		ratings := make(map[int64]*Glicko2, len(bots))
+		for _, b := range bots {
+			ratings[b.BotID] = NewGlicko2With(b.Rating, b.RD, b.Sigma)
+		}
+		closes := planRatingPeriods(runSpec.Rating, last, time.Now(), pending, ratings)
+		closed = len(closes)
+		if closed == 0 {
+			return nil, nil
+		}
+		states := make([]store.GlickoState, 0, len(ratings))
+		for _, b := range bots {
+			g := ratings[b.BotID]
+			states = append(states, store.GlickoState{BotID: b.BotID, Rating: g.Rating, RD: g.RD, Sigma: g.Volatility})
+		}
+		return closes, states
+	})
+	return closed, err
+}
+
+// closeRatingPeriodsEvery closes elapsed day periods on a timer, so idle
+// bots' RDs grow on the leaderboard even when no matches are being played.
+func closeRatingPeriodsEvery(db *store.DB, every time.Duration) {
+	for {
+		if n, err := closeRatingPeriods(context.Background(), db); err != nil {
+			log.Printf("closeRatingPeriods failed: %v", err)
+		} else if n > 0 {
+			log.Printf("closed %d Glicko-2 rating period(s).", n)
+		}
+		time.Sleep(every)
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"ai-thunderdome/server/config"
+	"ai-thunderdome/server/store"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Rating replay algorithms for `ratings recompute`.
+const (
+	// algoPair is what runDuel does live: Elo and Glicko-2 both update after
+	// every mirrored pair.
+	algoPair = "pair"
+	// algoMatch keeps the per-pair Elo but treats each match as one Glicko-2
+	// rating period holding all of its pairs.
+	algoMatch = "match"
+	// algoPeriod keeps the per-pair Elo and replays Glicko-2 through the
+	// live rating periods (--period, --period-matches), RD inflation included.
+	algoPeriod = "period"
+)
+
+// recomputeParams select the replay rules; stored as a snapshot's params.
+type recomputeParams struct {
+	Algorithm string  `json:"algorithm"`
+	Tau       float64 `json:"tau"`
+	EloStart  float64 `json:"elo_start"`
+	EloK      float64 `json:"elo_k,omitempty"` // 0 → each match's stored K
+
+	Period        string `json:"period,omitempty"` // algoPeriod: matches | day
+	PeriodMatches int    `json:"period_matches,omitempty"`
+}
+
+// replayRating is one bot's state during a replay.
+type replayRating struct {
+	Elo     float64
+	G       Glicko2
+	Matches int
+	Pairs   int
+}
+
+// recomputeRatings replays matches (chronological, as loaded by
+// store.ReplayMatches) from default ratings and returns every bot's final
+// state and the number of pairs used. Matches without pair results are
+// skipped.
+func recomputeRatings(matches []store.ReplayMatch, p recomputeParams) (map[int64]*replayRating, int) {
+	out := map[int64]*replayRating{}
+	get := func(id int64) *replayRating {
+		r := out[id]
+		if r == nil {
+			r = &replayRating{Elo: p.EloStart, G: *NewGlicko2()}
+			out[id] = r
+		}
+		return r
+	}
+	pairs := 0
+	var periodGames []store.PeriodMatch
+	for _, m := range matches {
+		if len(m.Pairs) == 0 {
+			continue
+		}
+		ra, rb := get(m.BotA), get(m.BotB)
+		k := p.EloK
+		if k <= 0 {
+			k = m.EloK
+		}
+		elo := Elo{A: ra.Elo, B: rb.Elo, K: k}
+		elo.SetAccuracy(m.Pairs[0].AccA, m.Pairs[0].AccB)
+		startA, startB := ra.G, rb.G
+		var periodA, periodB []OpponentResult
+		game := store.PeriodMatch{ID: m.ID, EndedAt: m.EndedAt, BotA: m.BotA, BotB: m.BotB, BB: m.BB, StartStack: m.StartStack}
+		for _, pr := range m.Pairs {
+			elo.UpdateFromMirror(pr.ChipsA, pr.PotSum, m.BB, pr.WinsA, pr.WinsB, pr.FoldScoreA, pr.FoldScoreB)
+			S := pairScore(pr.ChipsA, m.StartStack, m.BB)
+			switch p.Algorithm {
+			case algoMatch:
+				periodA = append(periodA, OpponentResult{Opp: &startB, S: S})
+				periodB = append(periodB, OpponentResult{Opp: &startA, S: 1.0 - S})
+			case algoPeriod:
+				game.ChipsA = append(game.ChipsA, pr.ChipsA)
+			default:
+				oldA, oldB := ra.G, rb.G
+				ra.G.UpdatePair(&oldB, S, p.Tau)
+				rb.G.UpdatePair(&oldA, 1.0-S, p.Tau)
+			}
+		}
+		periodGames = append(periodGames, game)
+		if p.Algorithm == algoMatch {
+			ra.G.UpdateBatch(periodA, p.Tau)
+			rb.G.UpdateBatch(periodB, p.Tau)
+		}
+		ra.Elo, rb.Elo = elo.A, elo.B
+		ra.Matches++
+		rb.Matches++
+		ra.Pairs += len(m.Pairs)
+		rb.Pairs += len(m.Pairs)
+		pairs += len(m.Pairs)
+	}
+	if p.Algorithm == algoPeriod && len(periodGames) > 0 {
+		gs := make(map[int64]*Glicko2, len(out))
+		for id, r := range out {
+			gs[id] = &r.G
+		}
+		cfg := config.Rating{GlickoPeriod: p.Period, PeriodMatches: p.PeriodMatches}
+		planRatingPeriods(cfg, periodGames[0].EndedAt.Add(-time.Nanosecond), time.Now(), periodGames, gs)
+	}
+	return out, pairs
+}
+
+// runRatingsCmd implements `ratings <recompute|close-periods|list|show|confirm>`.
+func runRatingsCmd(args []string) {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, `usage: ai-thunderdome ratings recompute [--algo pair|match|period] [--period matches|day] [--period-matches N]
+                                   [--tau 0.5] [--elo-start 1500] [--elo-k K]
+       ai-thunderdome ratings close-periods
+       ai-thunderdome ratings list
+       ai-thunderdome ratings show <snapshot_id>
+       ai-thunderdome ratings confirm <snapshot_id>`)
+		os.Exit(2)
+	}
+	if len(args) == 0 {
+		usage()
+	}
+	dsn := getenv("DATABASE_URL", "")
+	if dsn == "" {
+		log.Fatal("ratings: DATABASE_URL is required")
+	}
+	db, err := store.Open(dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close(context.Background())
+	if asBool(os.Getenv("AUTO_MIGRATE")) {
+		if err := store.Migrate(context.Background(), db); err != nil {
+			log.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+
+	snapshotID := func() int64 {
+		if len(args) < 2 {
+			usage()
+		}
+		return parseRunID("ratings "+args[0], args[1])
+	}
+	switch args[0] {
+	case "recompute":
+		fs := flag.NewFlagSet("ratings recompute", flag.ExitOnError)
+		p := recomputeParams{}
+		fs.StringVar(&p.Algorithm, "algo", algoPair, "replay rules: pair (Glicko-2 per pair), match (one Glicko-2 period per match) or period (rating periods)")
+		fs.Float64Var(&p.Tau, "tau", 0.5, "Glicko-2 system constant")
+		fs.Float64Var(&p.EloStart, "elo-start", 1500, "initial Elo for every bot")
+		fs.Float64Var(&p.EloK, "elo-k", 0, "Elo K (0 = each match's stored K)")
+		fs.StringVar(&p.Period, "period", config.PeriodMatches, "algo period: matches or day")
+		fs.IntVar(&p.PeriodMatches, "period-matches", 1, "algo period: matches per rating period")
+		_ = fs.Parse(args[1:])
+		switch p.Algorithm {
+		case algoPair, algoMatch:
+			p.Period, p.PeriodMatches = "", 0
+		case algoPeriod:
+			if p.Period != config.PeriodMatches && p.Period != config.PeriodDay {
+				log.Fatalf("ratings recompute: unknown --period %q (want %s or %s)", p.Period, config.PeriodMatches, config.PeriodDay)
+			}
+			if p.Period == config.PeriodMatches && p.PeriodMatches < 1 {
+				log.Fatal("ratings recompute: --period-matches must be positive")
+			}
+		default:
+			log.Fatalf("ratings recompute: unknown --algo %q (want %s, %s or %s)", p.Algorithm, algoPair, algoMatch, algoPeriod)
+		}
+		if err := recomputeSnapshot(ctx, db, p); err != nil {
+			log.Fatalf("ratings recompute: %v", err)
+		}
+	case "close-periods":
+		runSpec = specFromEnv()
+		n, err := closeRatingPeriods(ctx, db)
+		if err != nil {
+			log.Fatalf("ratings close-periods: %v", err)
+		}
+		fmt.Printf("Closed %d rating period(s) (%s).\n", n, runSpec.Rating.GlickoPeriod)
+	case "list":
+		snaps, err := db.ListRatingSnapshots(ctx)
+		if err != nil {
+			log.Fatalf("ratings list: %v", err)
+		}
+		if len(snaps) == 0 {
+			fmt.Println("No rating snapshots; create one with `ratings recompute`.")
+		}
+		for _, s := range snaps {
+			applied := dim("not applied")
+			if s.AppliedAt != nil {
+				applied = good("applied " + s.AppliedAt.Format(time.RFC3339))
+			}
+			fmt.Printf("%4d  %s  %-6s matches=%-5d pairs=%-6d %s  %s\n",
+				s.ID, s.CreatedAt.Format(time.RFC3339), s.Algorithm, s.Matches, s.Pairs, dim(s.Params), applied)
+		}
+	case "show":
+		if err := printSnapshot(ctx, db, snapshotID()); err != nil {
+			log.Fatalf("ratings show: %v", err)
+		}
+	case "confirm":
+		id := snapshotID()
+		if err := db.ApplyRatingSnapshot(ctx, id); errors.Is(err, pgx.ErrNoRows) {
+			log.Fatalf("ratings confirm: no snapshot %d", id)
+		} else if err != nil {
+			log.Fatalf("ratings confirm: %v", err)
+		}
+		fmt.Printf("Snapshot %d applied to bot_ratings.\n", id)
+	default:
+		usage()
+	}
+}
+
+// recomputeSnapshot replays all stored pairs under p, stores the result as
+// a new snapshot and prints it next to the live ratings. bot_ratings is not
+// touched; `ratings confirm` applies the snapshot.
+func recomputeSnapshot(ctx context.Context, db *store.DB, p recomputeParams) error {
+	matches, err := db.ReplayMatches(ctx)
+	if err != nil {
+		return err
+	}
+	ratings, pairs := recomputeRatings(matches, p)
+	replayed := 0
+	for _, m := range matches {
+		if len(m.Pairs) > 0 {
+			replayed++
+		}
+	}
+	if skipped := len(matches) - replayed; skipped > 0 {
+		log.Printf("%d completed matches have no pair results (recorded before they were stored) and were skipped.", skipped)
+	}
+	if replayed == 0 {
+		return errors.New("no stored pair results to replay")
+	}
+
+	ids := make([]int64, 0, len(ratings))
+	for id := range ratings {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	rows := make([]store.SnapshotRating, 0, len(ids))
+	for _, id := range ids {
+		r := ratings[id]
+		rows = append(rows, store.SnapshotRating{
+			BotID: id, Elo: r.Elo,
+			GRating: r.G.Rating, GRD: r.G.RD, GSigma: r.G.Volatility,
+			Matches: r.Matches, Pairs: r.Pairs,
+		})
+	}
+	params, _ := json.Marshal(p)
+	id, err := db.CreateRatingSnapshot(ctx, p.Algorithm, string(params), replayed, pairs, rows)
+	if err != nil {
+		return err
+	}
+	if err := printSnapshot(ctx, db, id); err != nil {
+		return err
+	}
+	fmt.Printf("\nLive ratings unchanged. Apply with: ai-thunderdome ratings confirm %d\n", id)
+	return nil
+}
+
+// printSnapshot prints a snapshot's ratings beside the live ones.
+func printSnapshot(ctx context.Context, db *store.DB, id int64) error {
+	s, rows, err := db.GetRatingSnapshot(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("no snapshot %d", id)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s #%d (%s, %d matches, %d pairs) %s\n",
+		bold("Rating snapshot"), s.ID, s.Algorithm, s.Matches, s.Pairs, dim(s.Params))
+	if s.AppliedAt != nil {
+		fmt.Println(dim("applied " + s.AppliedAt.Format(time.RFC3339)))
+	}
+	fmt.Printf("  %-40s %8s %8s %8s  %13s %13s\n", "bot", "elo", "live", "Δ", "glicko r/RD", "live r/RD")
+	for _, r := range rows {
+		live, delta, liveG := "—", "", "—"
+		if r.LiveElo != nil {
+			live = fmt.Sprintf("%.1f", *r.LiveElo)
+			delta = fmt.Sprintf("%+.1f", r.Elo-*r.LiveElo)
+		}
+		if r.LiveGRating != nil && r.LiveGRD != nil {
+			liveG = fmt.Sprintf("%.0f/%.0f", *r.LiveGRating, *r.LiveGRD)
+		}
+		fmt.Printf("  %-40s %8.1f %8s %8s  %13s %13s\n",
+			r.Name, r.Elo, live, delta, fmt.Sprintf("%.0f/%.0f", r.GRating, r.GRD), liveG)
+	}
+	return nil
+}
@@ -4,15 +4,21 @@ package main
 import (
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
+	"math"
+	"math/rand"
 	"net/http"
 	"sort"
 	"strings"
 	"time"
 
+	"ai-thunderdome/server/bt"
 	"ai-thunderdome/server/engine"
 	"ai-thunderdome/server/store"
+
+	"github.com/jackc/pgx/v5"
 )
 
 // embed the /web directory so index.html and assets ship in the binary
@@ -193,17 +199,30 @@ func Router(db *store.DB) http.Handler {
 			ID        int64      `json:"id"`
 			CreatedAt time.Time  `json:"created_at"`
 			EndedAt   *time.Time `json:"ended_at"`
+			GameVar   string     `json:"game_variant"`
+			Betting   string     `json:"betting"`
+			DeckHash  *string    `json:"deck_commitment"` // see /api/verify-decks
 			SBA       int        `json:"sb"`
 			BBA       int        `json:"bb"`
 			Start     int        `json:"start_stack"`
 			Seeds     int        `json:"duel_seeds"`
+			Played    *int       `json:"pairs_played"`
+			StopRule  *string    `json:"stop_rule"`
+			Verdict   *string    `json:"verdict"`
 			ModelA    string     `json:"model_a"`
 			ModelB    string     `json:"model_b"`
+			RawBB100  *float64   `json:"raw_bb100"`   // A's win rate from pair_results
+			AivatBB   *float64   `json:"aivat_bb100"` // same, variance-reduced
 		}
 		rows, err := db.Query(ctx, `
-            SELECT m.id, m.created_at, m.ended_at, m.sb, m.bb, m.start_stack, m.duel_seeds,
+            SELECT m.id, m.created_at, m.ended_at, m.game_variant,
+                   COALESCE(m.betting, CASE m.game_variant WHEN 'plo' THEN 'pot_limit' ELSE 'no_limit' END),
+                   m.deck_commitment, m.sb, m.bb, m.start_stack, m.duel_seeds,
+                   m.pairs_played, m.stop_rule, m.verdict,
                    MAX(CASE WHEN p.label='A' THEN p.name_snapshot END) AS model_a,
-                   MAX(CASE WHEN p.label='B' THEN p.name_snapshot END) AS model_b
+                   MAX(CASE WHEN p.label='B' THEN p.name_snapshot END) AS model_b,
+                   (SELECT AVG(r.chips_a) * 50 / m.bb FROM pair_results r WHERE r.match_id = m.id),
+                   (SELECT AVG(r.aivat_a) * 50 / m.bb FROM pair_results r WHERE r.match_id = m.id)
               FROM matches m
               LEFT JOIN match_participants p ON p.match_id = m.id
              GROUP BY m.id
@@ -218,7 +237,7 @@ func Router(db *store.DB) http.Handler {
 		out := []Row{}
 		for rows.Next() {
 			var x Row
-			if err := rows.Scan(&x.ID, &x.CreatedAt, &x.EndedAt, &x.SBA, &x.BBA, &x.Start, &x.Seeds, &x.ModelA, &x.ModelB); err != nil {
+			if err := rows.Scan(&x.ID, &x.CreatedAt, &x.EndedAt, &x.GameVar, &x.Betting, &x.DeckHash, &x.SBA, &x.BBA, &x.Start, &x.Seeds, &x.Played, &x.StopRule, &x.Verdict, &x.ModelA, &x.ModelB, &x.RawBB100, &x.AivatBB); err != nil {
 				http.Error(w, err.Error(), 500)
 				return
 			}
@@ -227,6 +246,26 @@ func Router(db *store.DB) http.Handler {
 		writeJSON(w, map[string]any{"rows": out})
 	})
 
+	// Run config: the verbatim --config file a match was produced with
+	mux.HandleFunc("/api/match-config", func(w http.ResponseWriter, r *http.Request) {
+		var matchID int64
+		if _, err := fmt.Sscan(r.URL.Query().Get("id"), &matchID); err != nil {
+			http.Error(w, "bad id", 400)
+			return
+		}
+		var raw *string
+		if err := db.QueryRow(r.Context(), `SELECT run_config FROM matches WHERE id = $1`, matchID).Scan(&raw); err != nil {
+			http.Error(w, err.Error(), 404)
+			return
+		}
+		if raw == nil {
+			http.Error(w, "match was not run from a config file", 404)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(*raw))
+	})
+
 	// Leaderboard: top bots by Elo (career stats, org)
 	mux.HandleFunc("/api/leaderboard", func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -245,6 +284,14 @@ func Router(db *store.DB) http.Handler {
 			Good        int       `json:"good"`
 			Total       int       `json:"total"`
 			Acc         float64   `json:"acc"`
+			// Format compliance over tracked decisions (action_logs.parse_source)
+			ParsedActions  int     `json:"parsed_actions"`
+			ComplianceRate float64 `json:"compliance_rate"`
+			FallbackRate   float64 `json:"fallback_rate"`
+			// Variant identity; Variants > 0 marks an aggregated family row
+			VariantKey string `json:"variant_key"`
+			Family     string `json:"family"`
+			Variants   int    `json:"variants,omitempty"`
 		}
 		const leaderboardSQL = `
             WITH summary AS (
@@ -273,7 +320,8 @@ func Router(db *store.DB) http.Handler {
                      WHEN COALESCE(ja.total, c.judge_total, 0) > 0
                        THEN COALESCE(ja.good, c.judge_good, 0)::float / COALESCE(ja.total, c.judge_total, 0)::float
                      ELSE 0
-                   END AS acc
+                   END AS acc,
+                   c.variant_key, c.family
               FROM v_bot_career c
               LEFT JOIN summary s ON s.bot_id = c.id
               LEFT JOIN v_judge_accuracy ja ON ja.bot_id = c.id
@@ -302,7 +350,8 @@ func Router(db *store.DB) http.Handler {
                    COALESCE(s.total_net_chips, 0) AS net_chips,
                    COALESCE(ja.good, 0)           AS good,
                    COALESCE(ja.total, 0)          AS total,
-                   COALESCE(ja.acc, 0)            AS acc
+                   COALESCE(ja.acc, 0)            AS acc,
+                   c.variant_key, c.family
               FROM v_bot_career c
               LEFT JOIN summary s ON s.bot_id = c.id
               LEFT JOIN v_judge_accuracy ja ON ja.bot_id = c.id
@@ -322,7 +371,7 @@ func Router(db *store.DB) http.Handler {
 		out := []Row{}
 		for rows.Next() {
 			var x Row
-			if err := rows.Scan(&x.BotID, &x.Model, &x.Company, &x.Elo, &x.Matches, &x.Hands, &x.Updated, &x.CareerWins, &x.CareerHands, &x.WinRatePct, &x.NetChips, &x.Good, &x.Total, &x.Acc); err != nil {
+			if err := rows.Scan(&x.BotID, &x.Model, &x.Company, &x.Elo, &x.Matches, &x.Hands, &x.Updated, &x.CareerWins, &x.CareerHands, &x.WinRatePct, &x.NetChips, &x.Good, &x.Total, &x.Acc, &x.VariantKey, &x.Family); err != nil {
 				http.Error(w, err.Error(), 500)
 				return
 			}
@@ -341,10 +390,247 @@ func Router(db *store.DB) http.Handler {
 				}
 			}
 		}
+		if psMap, err := db.AllParseStats(ctx); err == nil {
+			for i := range out {
+				if ps, ok := psMap[out[i].BotID]; ok {
+					out[i].ParsedActions = ps.Total
+					out[i].ComplianceRate = ps.Rate(ps.Compliant())
+					out[i].FallbackRate = ps.Rate(ps.Assisted())
+				}
+			}
+		}
+
+		// ?group=family folds variants into one row per model family. Elo is
+		// the hands-weighted mean of the variants; counters are summed.
+		if r.URL.Query().Get("group") == "family" {
+			idx := map[string]int{}
+			fams := []Row{}
+			eloW := map[string]float64{}
+			for _, x := range out {
+				i, ok := idx[x.Family]
+				if !ok {
+					i = len(fams)
+					idx[x.Family] = i
+					fams = append(fams, Row{BotID: x.BotID, Model: x.Family, Company: x.Company, Family: x.Family, VariantKey: x.Family, Updated: x.Updated})
+				}
+				f := &fams[i]
+				f.Variants++
+				wt := float64(x.Hands)
+				if wt <= 0 {
+					wt = 1
+				}
+				f.Elo += x.Elo * wt
+				eloW[x.Family] += wt
+				f.Matches += x.Matches
+				f.Hands += x.Hands
+				f.CareerWins += x.CareerWins
+				f.CareerHands += x.CareerHands
+				f.NetChips += x.NetChips
+				f.Good += x.Good
+				f.Total += x.Total
+				f.ComplianceRate += x.ComplianceRate * float64(x.ParsedActions)
+				f.FallbackRate += x.FallbackRate * float64(x.ParsedActions)
+				f.ParsedActions += x.ParsedActions
+				if x.Updated.After(f.Updated) {
+					f.Updated = x.Updated
+				}
+			}
+			for i := range fams {
+				f := &fams[i]
+				f.Elo /= eloW[f.Family]
+				if f.CareerHands > 0 {
+					f.WinRatePct = int(math.Round(100 * float64(f.CareerWins) / float64(f.CareerHands)))
+				}
+				if f.Total > 0 {
+					f.Acc = float64(f.Good) / float64(f.Total)
+				}
+				if f.ParsedActions > 0 {
+					f.ComplianceRate /= float64(f.ParsedActions)
+					f.FallbackRate /= float64(f.ParsedActions)
+				}
+			}
+			sort.SliceStable(fams, func(i, j int) bool { return fams[i].Elo > fams[j].Elo })
+			out = fams
+		}
 
 		writeJSON(w, map[string]any{"rows": out})
 	})
 
+	// Bradley–Terry leaderboard: one batch fit over every mirrored pair, so
+	// ratings do not depend on match order. Ratings are on the Elo scale
+	// (1500 = field average) with standard errors relative to that average;
+	// win_prob[i][j] is P(rows[i] wins a pair against rows[j]).
+	mux.HandleFunc("/api/leaderboard-bt", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		priorSD := bt.DefaultPriorSD
+		if v := r.URL.Query().Get("prior_sd"); v != "" {
+			if _, err := fmt.Sscan(v, &priorSD); err != nil || priorSD <= 0 {
+				http.Error(w, "bad prior_sd", http.StatusBadRequest)
+				return
+			}
+		}
+		tallies, err := db.PairTallies(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+
+		type Row struct {
+			BotID   int64   `json:"bot_id"`
+			Model   string  `json:"model"`
+			Company string  `json:"company"`
+			Rating  float64 `json:"rating"`
+			SE      float64 `json:"se"`
+			CILo    float64 `json:"ci_lo"`
+			CIHi    float64 `json:"ci_hi"`
+			Pairs   int     `json:"pairs"`
+			Wins    int     `json:"wins"`
+			Losses  int     `json:"losses"`
+			Ties    int     `json:"ties"`
+		}
+		index := map[int64]int{}
+		var rows []Row
+		slot := func(id int64) int {
+			i, ok := index[id]
+			if !ok {
+				i = len(rows)
+				index[id] = i
+				rows = append(rows, Row{BotID: id})
+			}
+			return i
+		}
+		fitTallies := make([]bt.Tally, 0, len(tallies))
+		total := 0
+		for _, t := range tallies {
+			i, j := slot(t.BotA), slot(t.BotB)
+			fitTallies = append(fitTallies, bt.Tally{I: i, J: j, WinsI: float64(t.WinsA), WinsJ: float64(t.WinsB), Ties: float64(t.Ties)})
+			n := t.WinsA + t.WinsB + t.Ties
+			total += n
+			rows[i].Pairs += n
+			rows[i].Wins += t.WinsA
+			rows[i].Losses += t.WinsB
+			rows[i].Ties += t.Ties
+			rows[j].Pairs += n
+			rows[j].Wins += t.WinsB
+			rows[j].Losses += t.WinsA
+			rows[j].Ties += t.Ties
+		}
+		if len(rows) == 0 {
+			writeJSON(w, map[string]any{"rows": []Row{}, "win_prob": [][]float64{}, "pairs": 0, "prior_sd": priorSD})
+			return
+		}
+		fit, err := bt.Fit(len(rows), fitTallies, bt.Options{PriorSD: priorSD})
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+
+		ids := make([]int64, len(rows))
+		for i := range rows {
+			ids[i] = rows[i].BotID
+			rows[i].Rating = 1500 + bt.EloScale*fit.Theta[i]
+			rows[i].SE = bt.EloScale * fit.SE[i]
+			rows[i].CILo = rows[i].Rating - 1.96*rows[i].SE
+			rows[i].CIHi = rows[i].Rating + 1.96*rows[i].SE
+		}
+		names, err := db.Query(ctx, `SELECT id, name, company FROM bots WHERE id = ANY($1)`, ids)
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		defer names.Close()
+		for names.Next() {
+			var id int64
+			var name, company string
+			if err := names.Scan(&id, &name, &company); err != nil {
+				http.Error(w, err.Error(), 500)
+				return
+			}
+			rows[index[id]].Model, rows[index[id]].Company = name, company
+		}
+
+		order := make([]int, len(rows))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool { return fit.Theta[order[a]] > fit.Theta[order[b]] })
+		sorted := make([]Row, len(rows))
+		winProb := make([][]float64, len(rows))
+		diffSE := make([][]float64, len(rows))
+		for a, i := range order {
+			sorted[a] = rows[i]
+			winProb[a] = make([]float64, len(rows))
+			diffSE[a] = make([]float64, len(rows))
+			for b, j := range order {
+				if i != j {
+					winProb[a][b] = fit.WinProb(i, j)
+					diffSE[a][b] = bt.EloScale * fit.DiffSE(i, j)
+				}
+			}
+		}
+		writeJSON(w, map[string]any{
+			"rows":     sorted,
+			"win_prob": winProb,
+			"diff_se":  diffSE,
+			"pairs":    total,
+			"prior_sd": priorSD,
+		})
+	})
+
+	// AIVAT: per-bot win rates with and without variance reduction, from
+	// pair_results rows that carry an AIVAT estimate. A pair is two hands,
+	// so bb/100 is 50× the mean per-pair result in big blinds.
+	mux.HandleFunc("/api/aivat", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		type Row struct {
+			BotID    int64   `json:"bot_id"`
+			Model    string  `json:"model"`
+			Company  string  `json:"company"`
+			Pairs    int     `json:"pairs"`
+			RawBB100 float64 `json:"raw_bb100"`
+			RawSE    float64 `json:"raw_se"`
+			AivatBB  float64 `json:"aivat_bb100"`
+			AivatSE  float64 `json:"aivat_se"`
+			VarRatio float64 `json:"var_ratio"` // raw / AIVAT variance: the factor fewer hands needed
+		}
+		rows, err := db.Query(ctx, `
+            WITH sides AS (
+              SELECT p.bot_id,
+                     CASE p.label WHEN 'A' THEN 1 ELSE -1 END * r.chips_a::float8 / m.bb AS raw,
+                     CASE p.label WHEN 'A' THEN 1 ELSE -1 END * r.aivat_a::float8 / m.bb AS est
+                FROM pair_results r
+                JOIN matches m ON m.id = r.match_id
+                JOIN match_participants p ON p.match_id = r.match_id
+               WHERE r.aivat_a IS NOT NULL AND m.bb > 0
+            )
+            SELECT s.bot_id, b.name, b.company, COUNT(*)::int,
+                   AVG(s.raw) * 50, COALESCE(STDDEV_SAMP(s.raw), 0) * 50 / sqrt(COUNT(*)),
+                   AVG(s.est) * 50, COALESCE(STDDEV_SAMP(s.est), 0) * 50 / sqrt(COUNT(*))
+              FROM sides s
+              JOIN bots b ON b.id = s.bot_id
+             GROUP BY s.bot_id, b.name, b.company
+             ORDER BY AVG(s.est) DESC
+        `)
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		defer rows.Close()
+		out := []Row{}
+		for rows.Next() {
+			var x Row
+			if err := rows.Scan(&x.BotID, &x.Model, &x.Company, &x.Pairs, &x.RawBB100, &x.RawSE, &x.AivatBB, &x.AivatSE); err != nil {
+				http.Error(w, err.Error(), 500)
+				return
+			}
+			if x.AivatSE > 0 {
+				x.VarRatio = (x.RawSE * x.RawSE) / (x.AivatSE * x.AivatSE)
+			}
+			out = append(out, x)
+		}
+		writeJSON(w, map[string]any{"rows": out})
+	})
+
 	// Judge accuracy (MCJudge): good/total and accuracy per bot
 	mux.HandleFunc("/api/judge-accuracy", func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -402,13 +688,20 @@ func Router(db *store.DB) http.Handler {
 			Matches int       `json:"matches"`
 			Hands   int       `json:"hands"`
 			Updated time.Time `json:"updated_at"`
+			// Variant identity (bots.variant_key) and its model family
+			VariantKey      string  `json:"variant_key"`
+			Family          string  `json:"family"`
+			Provider        *string `json:"provider"`
+			ReasoningEffort *string `json:"reasoning_effort"`
 		}
 		err := db.QueryRow(ctx, `
             SELECT id, name, company,
                    COALESCE(elo,1500), COALESCE(g_rating,1500), COALESCE(g_rd,350), COALESCE(g_sigma,0.06),
-                   COALESCE(matches,0), COALESCE(hands,0), COALESCE(updated_at, now())
+                   COALESCE(matches,0), COALESCE(hands,0), COALESCE(updated_at, now()),
+                   variant_key, family, provider, reasoning_effort
               FROM v_bot_career WHERE id = $1
-        `, botID).Scan(&career.BotID, &career.Model, &career.Company, &career.Elo, &career.GRating, &career.GRD, &career.GSigma, &career.Matches, &career.Hands, &career.Updated)
+        `, botID).Scan(&career.BotID, &career.Model, &career.Company, &career.Elo, &career.GRating, &career.GRD, &career.GSigma, &career.Matches, &career.Hands, &career.Updated,
+			&career.VariantKey, &career.Family, &career.Provider, &career.ReasoningEffort)
 		if err != nil {
 			http.Error(w, err.Error(), 404)
 			return
@@ -462,7 +755,17 @@ func Router(db *store.DB) http.Handler {
 			}
 			list = append(list, m)
 		}
-		writeJSON(w, map[string]any{"career": career, "matches": list})
+		type Parse struct {
+			Sources        map[string]int `json:"sources"`
+			Total          int            `json:"total"`
+			ComplianceRate float64        `json:"compliance_rate"`
+			FallbackRate   float64        `json:"fallback_rate"`
+		}
+		var parse Parse
+		if ps, err := db.BotParseStats(ctx, botID); err == nil {
+			parse = Parse{Sources: ps.Sources, Total: ps.Total, ComplianceRate: ps.Rate(ps.Compliant()), FallbackRate: ps.Rate(ps.Assisted())}
+		}
+		writeJSON(w, map[string]any{"career": career, "matches": list, "parse": parse})
 	})
 
 	// Aggregated action mix for a bot across all matches (for playstyle badges)
@@ -676,12 +979,45 @@ func Router(db *store.DB) http.Handler {
 			}
 			bots = append(bots, b)
 		}
+		alpha := 0.05
+		if v := r.URL.Query().Get("alpha"); v != "" {
+			if _, err := fmt.Sscan(v, &alpha); err != nil || alpha <= 0 || alpha >= 1 {
+				http.Error(w, "bad alpha", http.StatusBadRequest)
+				return
+			}
+		}
+		// Hand-level wins come from match_participants; the pair statistics
+		// from pair_results, from a_id's point of view. A pair is won by the
+		// bot that netted chips over both hands; the p-value is an exact
+		// sign test on decisive pairs.
 		type Pair struct {
 			AID   int64 `json:"a_id"`
 			BID   int64 `json:"b_id"`
 			AWins int   `json:"a_wins"`
 			BWins int   `json:"b_wins"`
 			Hands int   `json:"hands"`
+
+			Pairs      int     `json:"pairs"`
+			PairWinsA  int     `json:"pair_wins_a"`
+			PairWinsB  int     `json:"pair_wins_b"`
+			PairTies   int     `json:"pair_ties"`
+			MeanMargin float64 `json:"mean_margin"` // starting stacks per pair
+			WilsonLo   float64 `json:"wilson_lo"`   // A's pair win rate at 1-alpha
+			WilsonHi   float64 `json:"wilson_hi"`
+			BootLo     float64 `json:"boot_lo"` // mean margin, 95% bootstrap
+			BootHi     float64 `json:"boot_hi"`
+			PValue     float64 `json:"p_value"`
+			Separated  bool    `json:"separated"` // p_value < alpha
+		}
+		margins := map[[2]int64][]float64{}
+		pms, err := db.PairMargins(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		for _, pm := range pms {
+			k := [2]int64{pm.Lo, pm.Hi}
+			margins[k] = append(margins[k], pm.Margin)
 		}
 		pairs := []Pair{}
 		rows2, err := db.Query(ctx, `
@@ -706,9 +1042,31 @@ func Router(db *store.DB) http.Handler {
 				http.Error(w, err.Error(), 500)
 				return
 			}
+			ms := margins[[2]int64{p.AID, p.BID}]
+			sum := 0.0
+			for _, m := range ms {
+				sum += m
+				switch {
+				case m > 0:
+					p.PairWinsA++
+				case m < 0:
+					p.PairWinsB++
+				default:
+					p.PairTies++
+				}
+			}
+			p.Pairs = len(ms)
+			if p.Pairs > 0 {
+				p.MeanMargin = sum / float64(p.Pairs)
+			}
+			p.WilsonLo, p.WilsonHi = WilsonCI(p.PairWinsA, p.PairTies, p.Pairs, zTwoSided(alpha))
+			// Seeded per pair so the interval doesn't move between refreshes.
+			p.BootLo, p.BootHi = BootstrapCI95Rand(ms, 1000, rand.New(rand.NewSource(p.AID<<32^p.BID)))
+			p.PValue = SignTestP(p.PairWinsA, p.PairWinsB)
+			p.Separated = p.PValue < alpha
 			pairs = append(pairs, p)
 		}
-		writeJSON(w, map[string]any{"bots": bots, "pairs": pairs})
+		writeJSON(w, map[string]any{"bots": bots, "pairs": pairs, "alpha": alpha})
 	})
 
 	// Elo history across matches per bot (end-of-match Elo and label mapping)
@@ -752,6 +1110,54 @@ func Router(db *store.DB) http.Handler {
 		writeJSON(w, map[string]any{"rows": out})
 	})
 
+	// Range-vs-range equity: a and b take hole cards or range notation,
+	// board takes 0–5 cards, iters caps Monte-Carlo samples and seed
+	// repeats an earlier sampled result.
+	mux.HandleFunc("/api/equity", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		iters := 0
+		if s := q.Get("iters"); s != "" {
+			if _, err := fmt.Sscan(s, &iters); err != nil {
+				http.Error(w, "bad iters", 400)
+				return
+			}
+		}
+		var seed uint64
+		if s := q.Get("seed"); s != "" {
+			if _, err := fmt.Sscan(s, &seed); err != nil {
+				http.Error(w, "bad seed", 400)
+				return
+			}
+		}
+		rep, err := computeEquity(q.Get("a"), q.Get("b"), q.Get("board"), iters, seed)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		writeJSON(w, rep)
+	})
+
+	mux.HandleFunc("/api/verify-decks", func(w http.ResponseWriter, r *http.Request) {
+		var matchID int64
+		if _, err := fmt.Sscan(r.URL.Query().Get("match_id"), &matchID); err != nil {
+			http.Error(w, "bad match_id", 400)
+			return
+		}
+		a, err := auditMatchDecks(r.Context(), db, matchID)
+		switch {
+		case errors.Is(err, errDeckKeyHidden):
+			http.Error(w, err.Error(), 409)
+			return
+		case errors.Is(err, pgx.ErrNoRows):
+			http.Error(w, "match not found", 404)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		writeJSON(w, a)
+	})
+
 	// Fetch all action logs for a past match (non-live replay)
 	mux.HandleFunc("/api/match-logs", func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -783,13 +1189,17 @@ func Router(db *store.DB) http.Handler {
 			BBHole      []string  `json:"bb_hole"`
 			CreatedAt   time.Time `json:"created_at"`
 			// Optional solver eval join
-			Solver          *string  `json:"solver"`
-			SolverVersion   *string  `json:"solver_version"`
-			EvalBestAction  *string  `json:"eval_best_action"`
-			EvalBestTo      *int     `json:"eval_best_to"`
-			EvalGapBB       *float64 `json:"eval_gap_bb"`
-			EvalCorrectProb *float64 `json:"eval_correct_prob"`
-			EvalIsTop       *bool    `json:"eval_is_top"`
+			Solver          *string            `json:"solver"`
+			SolverVersion   *string            `json:"solver_version"`
+			EvalBestAction  *string            `json:"eval_best_action"`
+			EvalBestTo      *int               `json:"eval_best_to"`
+			EvalGapBB       *float64           `json:"eval_gap_bb"`
+			EvalCorrectProb *float64           `json:"eval_correct_prob"`
+			EvalIsTop       *bool              `json:"eval_is_top"`
+			ParseSource     *string            `json:"parse_source"`
+			Policy          map[string]float64 `json:"policy,omitempty"`
+			Comment         *string            `json:"comment,omitempty"`
+			Reasoning       *string            `json:"reasoning,omitempty"`
 			// Server-enriched winner at end of hand
 			WinnerSeat *string `json:"winner_seat,omitempty"`
 		}
@@ -798,7 +1208,8 @@ func Router(db *store.DB) http.Handler {
                    a.pot, a.cur_bet, a.to_call, a.min_raise_to, a.max_raise_to,
                    a.sb_stack, a.bb_stack, a.sb_committed, a.bb_committed,
                    a.board, a.sb_hole, a.bb_hole, a.created_at,
-                   e.solver, e.solver_version, e.best_action, e.best_amount_to, e.ev_gap_bb, e.correctness_prob, e.is_top_action
+                   e.solver, e.solver_version, e.best_action, e.best_amount_to, e.ev_gap_bb, e.correctness_prob, e.is_top_action,
+                   a.parse_source, a.policy, a.comment, a.reasoning
               FROM action_logs a
               LEFT JOIN action_eval e ON e.action_log_id = a.id
              WHERE a.match_id = $1
@@ -816,13 +1227,18 @@ func Router(db *store.DB) http.Handler {
 				&r.Pot, &r.CurBet, &r.ToCall, &r.MinRaiseTo, &r.MaxRaiseTo,
 				&r.SBStack, &r.BBStack, &r.SBCommitted, &r.BBCommitted,
 				&r.Board, &r.SBHole, &r.BBHole, &r.CreatedAt,
-				&r.Solver, &r.SolverVersion, &r.EvalBestAction, &r.EvalBestTo, &r.EvalGapBB, &r.EvalCorrectProb, &r.EvalIsTop); err != nil {
+				&r.Solver, &r.SolverVersion, &r.EvalBestAction, &r.EvalBestTo, &r.EvalGapBB, &r.EvalCorrectProb, &r.EvalIsTop,
+				&r.ParseSource, &r.Policy, &r.Comment, &r.Reasoning); err != nil {
 				http.Error(w, err.Error(), 500)
 				return
 			}
 			out = append(out, r)
 		}
-		// Enrich end-of-hand rows with winner seat (showdown or fold)
+		// Enrich end-of-hand rows with winner seat (showdown or fold),
+		// ranked under the match's variant.
+		var gameVariant string
+		_ = db.QueryRow(ctx, `SELECT game_variant FROM matches WHERE id = $1`, matchID).Scan(&gameVariant)
+		variant, _ := engine.ParseVariant(gameVariant)
 		parseCard := func(s string) (engine.Card, bool) {
 			if len(s) < 2 {
 				return engine.Card{}, false
@@ -855,7 +1271,7 @@ func Router(db *store.DB) http.Handler {
 			return engine.Card{Rank: rank, Suit: suitCh}, true
 		}
 		computeShowdown := func(r Row) *string {
-			if len(r.Board) < 5 || len(r.SBHole) != 2 || len(r.BBHole) != 2 {
+			if len(r.Board) < 5 || len(r.SBHole) != len(r.BBHole) || (len(r.SBHole) != 2 && len(r.SBHole) != 4) {
 				return nil
 			}
 			toCards := func(ss []string) ([]engine.Card, bool) {
@@ -875,7 +1291,11 @@ func Router(db *store.DB) http.Handler {
 			if !ok1 || !ok2 || !ok3 {
 				return nil
 			}
-			h := &engine.Hand{Board: board, SB: &engine.Player{Seat: engine.SB, Hole: sb}, BB: &engine.Player{Seat: engine.BB, Hole: bb}}
+			cfg := engine.Config{Variant: variant}
+			if len(sb) == 4 {
+				cfg.Variant = engine.PLO
+			}
+			h := &engine.Hand{Cfg: cfg, Board: board, SB: &engine.Player{Seat: engine.SB, Hole: sb}, BB: &engine.Player{Seat: engine.BB, Hole: bb}}
 			seat := string(h.Showdown())
 			if seat == string(engine.SB) || seat == string(engine.BB) {
 				return &seat
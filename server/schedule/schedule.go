@@ -0,0 +1,65 @@
+// Package schedule builds tournament pairings for the duel matrix. It is
+// pure: callers own the participant list, persistence and execution.
+package schedule
+
+// Pairing is one scheduled match. A and B index the caller's participant
+// list; A takes the "A" label in the duel.
+type Pairing struct {
+	Round int // 0-based; no participant appears twice in a round
+	A, B  int
+}
+
+// RoundRobin pairs n participants with the circle method. Each leg plays
+// every unordered pair once; the second leg (legs=2, double round-robin)
+// repeats the first with A and B swapped. The whole cycle is repeated
+// perPair times, so a pair's matches are spread across the run rather than
+// played back to back. With an odd n one participant sits out each round.
+func RoundRobin(n, legs, perPair int) []Pairing {
+	if n < 2 || legs <= 0 || perPair <= 0 {
+		return nil
+	}
+	m := n
+	if m%2 == 1 {
+		m++ // index n is the bye
+	}
+
+	// One leg of rounds, rotating every slot except the first.
+	var leg [][2]int
+	slots := make([]int, m)
+	for i := range slots {
+		slots[i] = i
+	}
+	for r := 0; r < m-1; r++ {
+		for i := 0; i < m/2; i++ {
+			x, y := slots[i], slots[m-1-i]
+			if x == n || y == n {
+				continue
+			}
+			// Alternate the fixed participant's label so A/B stay balanced.
+			if i == 0 && r%2 == 1 {
+				x, y = y, x
+			}
+			leg = append(leg, [2]int{x, y})
+		}
+		last := slots[m-1]
+		copy(slots[2:], slots[1:m-1])
+		slots[1] = last
+	}
+	perRound := len(leg) / (m - 1)
+
+	out := make([]Pairing, 0, len(leg)*legs*perPair)
+	round := 0
+	for rep := 0; rep < perPair; rep++ {
+		for l := 0; l < legs; l++ {
+			for k, p := range leg {
+				a, b := p[0], p[1]
+				if l%2 == 1 {
+					a, b = b, a
+				}
+				out = append(out, Pairing{Round: round + k/perRound, A: a, B: b})
+			}
+			round += m - 1
+		}
+	}
+	return out
+}
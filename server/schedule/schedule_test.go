@@ -0,0 +1,58 @@
+package schedule
+
+import "testing"
+
+func TestRoundRobinCoversEveryPair(t *testing.T) {
+	for _, n := range []int{2, 3, 4, 5, 8} {
+		got := RoundRobin(n, 1, 1)
+		if want := n * (n - 1) / 2; len(got) != want {
+			t.Fatalf("n=%d: %d pairings, want %d", n, len(got), want)
+		}
+		seen := map[[2]int]bool{}
+		inRound := map[[2]int]bool{} // (round, participant)
+		for _, p := range got {
+			if p.A == p.B || p.A < 0 || p.B < 0 || p.A >= n || p.B >= n {
+				t.Fatalf("n=%d: bad pairing %+v", n, p)
+			}
+			key := [2]int{min(p.A, p.B), max(p.A, p.B)}
+			if seen[key] {
+				t.Fatalf("n=%d: pair %v scheduled twice", n, key)
+			}
+			seen[key] = true
+			for _, x := range []int{p.A, p.B} {
+				if inRound[[2]int{p.Round, x}] {
+					t.Fatalf("n=%d: participant %d plays twice in round %d", n, x, p.Round)
+				}
+				inRound[[2]int{p.Round, x}] = true
+			}
+		}
+	}
+}
+
+func TestDoubleRoundRobinSwapsSeatsAndRepeats(t *testing.T) {
+	n := 5
+	got := RoundRobin(n, 2, 3)
+	if want := n * (n - 1) * 3; len(got) != want {
+		t.Fatalf("%d pairings, want %d", len(got), want)
+	}
+	ordered := map[[2]int]int{}
+	for _, p := range got {
+		ordered[[2]int{p.A, p.B}]++
+	}
+	for a := 0; a < n; a++ {
+		for b := 0; b < n; b++ {
+			if a != b && ordered[[2]int{a, b}] != 3 {
+				t.Fatalf("(%d,%d) played %d times as A/B, want 3", a, b, ordered[[2]int{a, b}])
+			}
+		}
+	}
+	if got[len(got)-1].Round != 3*2*n-1 {
+		t.Fatalf("last round = %d", got[len(got)-1].Round)
+	}
+}
+
+func TestRoundRobinDegenerate(t *testing.T) {
+	if RoundRobin(1, 1, 1) != nil || RoundRobin(4, 0, 1) != nil || RoundRobin(4, 1, 0) != nil {
+		t.Fatal("expected no pairings")
+	}
+}
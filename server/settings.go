@@ -0,0 +1,160 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-thunderdome/server/config"
+	"ai-thunderdome/server/llm"
+)
+
+// runSpec is the resolved run configuration. It is built once at startup,
+// from --config when given and from the legacy env vars otherwise, so the
+// hand loop never consults the environment.
+var runSpec = config.Default()
+
+// specFromEnv maps the historical env knobs onto a spec. Players are left
+// empty; loadPlayers / matrixPlayers read them from the env instead.
+func specFromEnv() config.Spec {
+	s := config.Default()
+	if v := strings.ToLower(strings.TrimSpace(os.Getenv("GAME_VARIANT"))); v != "" {
+		s.Game.Variant = v
+	}
+	if v := strings.ToLower(strings.TrimSpace(os.Getenv("BETTING"))); v != "" {
+		s.Game.Betting = v
+	}
+	s.Game.SB = atoiDef(os.Getenv("SB"), s.Game.SB)
+	s.Game.BB = atoiDef(os.Getenv("BB"), s.Game.BB)
+	s.Game.StartStack = atoiDef(os.Getenv("START_STACK"), s.Game.StartStack)
+	s.Game.SmallBet = atoiDef(os.Getenv("SMALL_BET"), s.Game.SmallBet)
+	s.Game.BigBet = atoiDef(os.Getenv("BIG_BET"), s.Game.BigBet)
+	s.Game.RaiseCap = atoiDef(os.Getenv("RAISE_CAP"), s.Game.RaiseCap)
+
+	s.Seeds.Pairs = atoiDef(os.Getenv("DUEL_SEEDS"), s.Seeds.Pairs)
+	if s.Seeds.Pairs <= 0 {
+		s.Seeds.Pairs = (atoiDef(os.Getenv("DUEL_HANDS"), 10) + 1) / 2
+	}
+	if v, err := strconv.ParseInt(strings.TrimSpace(os.Getenv("DECK_SEED")), 10, 64); err == nil {
+		s.Seeds.Base = &v
+	}
+
+	s.Rating.EloStart = float64(atoiDef(os.Getenv("ELO_START"), int(s.Rating.EloStart)))
+	s.Rating.EloK = float64(atoiDef(os.Getenv("ELO_K"), int(s.Rating.EloK)))
+	s.Rating.WeightByPot = asBool(os.Getenv("ELO_WEIGHT_BY_POT"))
+	if v := strings.ToLower(strings.TrimSpace(os.Getenv("GLICKO_PERIOD"))); v != "" {
+		s.Rating.GlickoPeriod = v
+	}
+	s.Rating.PeriodMatches = atoiDef(os.Getenv("GLICKO_PERIOD_MATCHES"), s.Rating.PeriodMatches)
+
+	b := &s.Behavior
+	if v := strings.TrimSpace(os.Getenv("USE_TOOLS")); v != "" {
+		b.UseTools = asBool(v)
+	}
+	b.MixedPolicy = asBool(os.Getenv("MIXED_POLICY"))
+	b.CaptureRationale = asBool(os.Getenv("CAPTURE_RATIONALE"))
+	b.EncourageProbeZero = asBool(os.Getenv("ENCOURAGE_PROBE_ZERO"))
+	b.RaiseFirstZeroCall = asBool(os.Getenv("RAISE_FIRST_ZERO_CALL"))
+	b.RaiseZeroCallProb = probeProbFromEnv()
+	b.ForceNonCheck = strings.ToLower(strings.TrimSpace(os.Getenv("FORCE_NONCHECK")))
+	if v := strings.TrimSpace(os.Getenv("OPENAI_MAX_OUTPUT_TOKENS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			b.MaxOutputTokens = &n
+		}
+	}
+	b.ReasoningEffort = reasoningEffortFromEnv()
+
+	if v := strings.ToLower(strings.TrimSpace(os.Getenv("MATRIX_FORMAT"))); v != "" {
+		s.Schedule.Format = v
+	}
+	s.Schedule.MatchesPerPair = atoiDef(os.Getenv("MATRIX_MATCHES_PER_PAIR"), s.Schedule.MatchesPerPair)
+	s.Schedule.Workers = atoiDef(os.Getenv("MATRIX_WORKERS"), s.Schedule.Workers)
+
+	// Provider limits: LLM_CONCURRENCY_OPENROUTER=8, LLM_RPM_OPENAI=500, ...
+	for _, prov := range []string{"openai", "openrouter", "anthropic", "gemini"} {
+		suffix := "_" + strings.ToUpper(prov)
+		l := config.Limit{
+			Concurrency: atoiDef(os.Getenv("LLM_CONCURRENCY"+suffix), 0),
+			RPM:         atoiDef(os.Getenv("LLM_RPM"+suffix), 0),
+			TPM:         atoiDef(os.Getenv("LLM_TPM"+suffix), 0),
+		}
+		if l != (config.Limit{}) {
+			if s.Limits.Providers == nil {
+				s.Limits.Providers = map[string]config.Limit{}
+			}
+			s.Limits.Providers[prov] = l
+		}
+	}
+	if v := strings.ToLower(strings.TrimSpace(os.Getenv("SEQ_TEST"))); v != "" {
+		s.Stopping.Rule = v
+	}
+	s.Stopping.MinPairs = atoiDef(os.Getenv("SEQ_MIN_PAIRS"), s.Stopping.MinPairs)
+	s.Stopping.Alpha = floatDef(os.Getenv("SEQ_ALPHA"), s.Stopping.Alpha)
+	s.Stopping.Beta = floatDef(os.Getenv("SEQ_BETA"), s.Stopping.Beta)
+	s.Stopping.Effect = floatDef(os.Getenv("SEQ_EFFECT"), s.Stopping.Effect)
+
+	s.Ladder.BudgetHands = atoiDef(os.Getenv("LADDER_BUDGET_HANDS"), s.Ladder.BudgetHands)
+
+	s.Limits.Retry.MaxAttempts = atoiDef(os.Getenv("LLM_RETRY_MAX_ATTEMPTS"), s.Limits.Retry.MaxAttempts)
+	return s
+}
+
+// applyLimits installs the spec's provider limits and retry policy in llm.
+func applyLimits(s config.Spec) {
+	conv := func(m map[string]config.Limit) map[string]llm.Limit {
+		out := make(map[string]llm.Limit, len(m))
+		for k, l := range m {
+			out[k] = llm.Limit{Concurrency: l.Concurrency, RPM: l.RPM, TPM: l.TPM}
+		}
+		return out
+	}
+	r := s.Limits.Retry
+	llm.SetLimits(conv(s.Limits.Providers), conv(s.Limits.Models), llm.RetryPolicy{
+		MaxAttempts: r.MaxAttempts,
+		Base:        time.Duration(r.BaseMS) * time.Millisecond,
+		Max:         time.Duration(r.MaxMS) * time.Millisecond,
+	})
+}
+
+// playerFromSpec converts a spec entry into a seat with its provider overrides.
+func playerFromSpec(label string, p config.Player) Player {
+	return Player{
+		Label: label,
+		Name:  label,
+		Model: strings.TrimSpace(p.Model),
+		LLM: llm.Endpoint{
+			Provider:        strings.ToLower(strings.TrimSpace(p.Provider)),
+			BaseURL:         strings.TrimSpace(p.APIBase),
+			APIKeyEnv:       strings.TrimSpace(p.APIKeyEnv),
+			APIKeyFile:      strings.TrimSpace(p.APIKeyFile),
+			Temperature:     p.Temperature,
+			TopP:            p.TopP,
+			ReasoningEffort: strings.ToLower(strings.TrimSpace(p.ReasoningEffort)),
+			MaxOutputTokens: p.MaxOutputTokens,
+		},
+	}
+}
+
+// specPlayer is the inverse of playerFromSpec; schedules persist entrants in
+// spec form so a resumed run rebuilds identical seats.
+func specPlayer(p Player) config.Player {
+	return config.Player{
+		Model:           p.Model,
+		Provider:        p.LLM.Provider,
+		APIBase:         p.LLM.BaseURL,
+		APIKeyEnv:       p.LLM.APIKeyEnv,
+		APIKeyFile:      p.LLM.APIKeyFile,
+		Temperature:     p.LLM.Temperature,
+		TopP:            p.LLM.TopP,
+		ReasoningEffort: p.LLM.ReasoningEffort,
+		MaxOutputTokens: p.LLM.MaxOutputTokens,
+	}
+}
+
+func floatDef(s string, def float64) float64 {
+	if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+		return f
+	}
+	return def
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"ai-thunderdome/server/config"
 	"ai-thunderdome/server/engine"
 	"math"
 	"math/rand"
@@ -67,10 +68,20 @@ func (m *ModelStats) addNet(seat engine.Seat, delta int) {
 
 // WilsonCI95 for Bernoulli win rate using wins/ties/total over mirrored pairs.
 func WilsonCI95(wins, ties, total int) (low, hi float64) {
+	return WilsonCI(wins, ties, total, 1.96)
+}
+
+// zTwoSided is the normal quantile for a two-sided interval at level alpha
+// (1.96 for 0.05).
+func zTwoSided(alpha float64) float64 {
+	return math.Sqrt2 * math.Erfinv(1-alpha)
+}
+
+// WilsonCI is WilsonCI95 at an arbitrary normal quantile z.
+func WilsonCI(wins, ties, total int, z float64) (low, hi float64) {
 	if total <= 0 {
 		return 0, 1
 	}
-	z := 1.96
 	n := float64(total)
 	p := (float64(wins) + 0.5*float64(ties)) / n
 	den := 1 + (z*z)/n
@@ -81,6 +92,16 @@ func WilsonCI95(wins, ties, total int) (low, hi float64) {
 
 // BootstrapCI95 for the mean of values (e.g., normalized chip margins).
 func BootstrapCI95(vals []float64, B int) (low, hi float64) {
+	return BootstrapCI95Rand(vals, B, nil)
+}
+
+// BootstrapCI95Rand is BootstrapCI95 resampling from rng, so a fixed seed
+// gives the same interval every time; nil uses the global source.
+func BootstrapCI95Rand(vals []float64, B int, rng *rand.Rand) (low, hi float64) {
+	intn := rand.Intn
+	if rng != nil {
+		intn = rng.Intn
+	}
 	n := len(vals)
 	if n == 0 || B <= 1 {
 		return 0, 0
@@ -89,7 +110,7 @@ func BootstrapCI95(vals []float64, B int) (low, hi float64) {
 	for b := 0; b < B; b++ {
 		sum := 0.0
 		for i := 0; i < n; i++ {
-			sum += vals[rand.Intn(n)]
+			sum += vals[intn(n)]
 		}
 		res[b] = sum / float64(n)
 	}
@@ -98,3 +119,135 @@ func BootstrapCI95(vals []float64, B int) (low, hi float64) {
 	h := int(0.975 * float64(B-1))
 	return res[l], res[h]
 }
+
+// SignTestP is the exact two-sided sign test p-value for decisive pair
+// wins against losses under H0 p=0.5 (ties carry no information).
+func SignTestP(wins, losses int) float64 {
+	n := wins + losses
+	if n == 0 {
+		return 1
+	}
+	k := min(wins, losses)
+	lgN, _ := math.Lgamma(float64(n + 1))
+	tail := 0.0
+	for i := 0; i <= k; i++ {
+		lgI, _ := math.Lgamma(float64(i + 1))
+		lgR, _ := math.Lgamma(float64(n - i + 1))
+		tail += math.Exp(lgN - lgI - lgR - float64(n)*math.Ln2)
+	}
+	return math.Min(1, 2*tail)
+}
+
+// pairBB100 converts per-pair chip results (two hands each) into a mean win
+// rate in bb/100 hands and its standard error.
+func pairBB100(chips []float64, bb int) (mean, se float64) {
+	n := len(chips)
+	if n == 0 || bb <= 0 {
+		return 0, 0
+	}
+	scale := 100 / (2 * float64(bb))
+	sum := 0.0
+	for _, c := range chips {
+		sum += c
+	}
+	mu := sum / float64(n)
+	if n > 1 {
+		ss := 0.0
+		for _, c := range chips {
+			ss += (c - mu) * (c - mu)
+		}
+		se = math.Sqrt(ss/float64(n-1)/float64(n)) * scale
+	}
+	return mu * scale, se
+}
+
+// --------- sequential stopping (adaptive duels) ---------
+
+// Verdicts stored in matches.verdict.
+const (
+	verdictA    = "a_better"
+	verdictB    = "b_better"
+	verdictNone = "no_difference"
+)
+
+// seqTest is the stopping rule evaluated after every mirrored pair. A pair
+// counts as a win for whoever netted chips over both hands.
+type seqTest struct {
+	cfg                config.Stopping
+	winsA, winsB, ties int
+}
+
+func (t *seqTest) add(chipsA int) {
+	switch {
+	case chipsA > 0:
+		t.winsA++
+	case chipsA < 0:
+		t.winsB++
+	default:
+		t.ties++
+	}
+}
+
+func (t *seqTest) pairs() int { return t.winsA + t.winsB + t.ties }
+
+// check returns a verdict once the rule allows stopping, "" otherwise.
+//
+// SPRT runs two one-sided tests on decisive pairs (ties carry no
+// information): H0 p=0.5 against H1 p=0.5+Effect for each side, at Alpha/2
+// each. It stops for a side whose log-likelihood ratio crosses
+// ln((1-β)/(α/2)), or with "no difference" once both fall below
+// ln(β/(1-α/2)). The CI rule stops as soon as the Wilson interval at
+// confidence 1-Alpha excludes 0.5; it peeks every pair, so its real error
+// rate is above Alpha and MinPairs matters more.
+func (t *seqTest) check() string {
+	if t.pairs() < t.cfg.MinPairs {
+		return ""
+	}
+	switch t.cfg.Rule {
+	case config.StopSPRT:
+		a, b, p1 := t.cfg.Alpha/2, t.cfg.Beta, 0.5+t.cfg.Effect
+		upper := math.Log((1 - b) / a)
+		lower := math.Log(b / (1 - a))
+		win, loss := math.Log(p1/0.5), math.Log((1-p1)/0.5)
+		llrA := float64(t.winsA)*win + float64(t.winsB)*loss
+		llrB := float64(t.winsB)*win + float64(t.winsA)*loss
+		switch {
+		case llrA >= upper:
+			return verdictA
+		case llrB >= upper:
+			return verdictB
+		case llrA <= lower && llrB <= lower:
+			return verdictNone
+		}
+	case config.StopCI:
+		lo, hi := WilsonCI(t.winsA, t.ties, t.pairs(), zTwoSided(t.cfg.Alpha))
+		switch {
+		case lo > 0.5:
+			return verdictA
+		case hi < 0.5:
+			return verdictB
+		}
+	}
+	return ""
+}
+
+// outcome is the stopping rule and verdict SetMatchOutcome stores for a
+// finished match; a fixed-length duel (nil t) stores neither. Stopped
+// matches are left open and record no outcome until they are resumed.
+func (t *seqTest) outcome(verdict string) (rule, v string) {
+	if t == nil {
+		return "", ""
+	}
+	if verdict == "" {
+		verdict = t.final()
+	}
+	return t.cfg.Rule, verdict
+}
+
+// final is the verdict when the pair budget runs out.
+func (t *seqTest) final() string {
+	if v := t.check(); v != "" {
+		return v
+	}
+	return verdictNone
+}
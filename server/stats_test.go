@@ -0,0 +1,116 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"ai-thunderdome/server/config"
+)
+
+func TestSeqTestCheck(t *testing.T) {
+	sprt := config.Stopping{Rule: config.StopSPRT, MinPairs: 10, Alpha: 0.05, Beta: 0.1, Effect: 0.1}
+	ci := config.Stopping{Rule: config.StopCI, MinPairs: 10, Alpha: 0.05}
+	for _, tc := range []struct {
+		name               string
+		cfg                config.Stopping
+		winsA, winsB, ties int
+		check, final       string
+	}{
+		// SPRT: ln(0.9/0.025) = 3.58 is crossed by the 20th straight win
+		// (20·ln 1.2 = 3.65), not the 19th (3.46).
+		{"sprt 19-0", sprt, 19, 0, 0, "", verdictNone},
+		{"sprt 20-0", sprt, 20, 0, 0, verdictA, verdictA},
+		{"sprt 0-20", sprt, 0, 20, 3, verdictB, verdictB},
+		{"sprt lopsided", sprt, 40, 15, 5, verdictA, verdictA},
+		// Balanced pairs move both ratios down by ln(1.2·0.8) = -0.041 a pair
+		// until both pass ln(0.1/0.975) = -2.28 at 56 each.
+		{"sprt 55-55", sprt, 55, 55, 0, "", verdictNone},
+		{"sprt 56-56", sprt, 56, 56, 4, verdictNone, verdictNone},
+		{"sprt below min pairs", config.Stopping{Rule: config.StopSPRT, MinPairs: 30, Alpha: 0.05, Beta: 0.1, Effect: 0.1}, 25, 0, 0, "", verdictNone},
+		{"sprt ties only", sprt, 0, 0, 40, "", verdictNone},
+
+		{"ci 10-0", ci, 10, 0, 0, verdictA, verdictA},
+		{"ci 2-12", ci, 2, 12, 0, verdictB, verdictB},
+		{"ci 9-0 below min pairs", ci, 9, 0, 0, "", verdictNone},
+		{"ci balanced", ci, 10, 10, 5, "", verdictNone},
+		{"ci 7-3", ci, 7, 3, 0, "", verdictNone},
+
+		{"no rule", config.Stopping{Rule: config.StopNone}, 50, 0, 0, "", verdictNone},
+	} {
+		s := &seqTest{cfg: tc.cfg, winsA: tc.winsA, winsB: tc.winsB, ties: tc.ties}
+		if got := s.check(); got != tc.check {
+			t.Errorf("%s: check() = %q, want %q", tc.name, got, tc.check)
+		}
+		if got := s.final(); got != tc.final {
+			t.Errorf("%s: final() = %q, want %q", tc.name, got, tc.final)
+		}
+	}
+}
+
+func TestSeqTestAdd(t *testing.T) {
+	s := &seqTest{}
+	for _, c := range []int{120, -40, 0, 5, 0} {
+		s.add(c)
+	}
+	if s.winsA != 2 || s.winsB != 1 || s.ties != 2 || s.pairs() != 5 {
+		t.Fatalf("after add: A %d B %d ties %d pairs %d", s.winsA, s.winsB, s.ties, s.pairs())
+	}
+}
+
+func TestSeqTestOutcome(t *testing.T) {
+	var fixed *seqTest
+	if rule, v := fixed.outcome(""); rule != "" || v != "" {
+		t.Errorf("fixed-length duel: %q %q", rule, v)
+	}
+	s := &seqTest{cfg: config.Stopping{Rule: config.StopSPRT, MinPairs: 10, Alpha: 0.05, Beta: 0.1, Effect: 0.1}, winsA: 3, winsB: 4}
+	if rule, v := s.outcome(verdictB); rule != config.StopSPRT || v != verdictB {
+		t.Errorf("early stop: %q %q", rule, v)
+	}
+	// Budget exhausted without a crossing.
+	if rule, v := s.outcome(""); rule != config.StopSPRT || v != verdictNone {
+		t.Errorf("budget run out: %q %q", rule, v)
+	}
+}
+
+func TestZTwoSided(t *testing.T) {
+	for _, tc := range []struct{ alpha, want float64 }{{0.05, 1.959964}, {0.01, 2.575829}, {0.1, 1.644854}} {
+		if got := zTwoSided(tc.alpha); math.Abs(got-tc.want) > 1e-6 {
+			t.Errorf("zTwoSided(%v) = %.6f, want %.6f", tc.alpha, got, tc.want)
+		}
+	}
+}
+
+func TestSignTestP(t *testing.T) {
+	for _, tc := range []struct {
+		wins, losses int
+		want         float64
+	}{
+		{9, 1, 0.021484375}, // 2·(1+10)/2^10
+		{1, 9, 0.021484375},
+		{10, 0, 0.001953125}, // 2/2^10
+		{8, 2, 0.109375},     // 2·(1+10+45)/2^10
+		{5, 5, 1},
+		{1, 1, 1},
+		{0, 0, 1},
+	} {
+		if got := SignTestP(tc.wins, tc.losses); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("SignTestP(%d, %d) = %.9f, want %.9f", tc.wins, tc.losses, got, tc.want)
+		}
+	}
+}
+
+func TestBootstrapCI95Rand(t *testing.T) {
+	ms := []float64{0.3, -0.1, 0.05, 0.2, -0.4, 0.15, 0, 0.1}
+	lo1, hi1 := BootstrapCI95Rand(ms, 1000, rand.New(rand.NewSource(7)))
+	lo2, hi2 := BootstrapCI95Rand(ms, 1000, rand.New(rand.NewSource(7)))
+	if lo1 != lo2 || hi1 != hi2 {
+		t.Fatalf("same seed gave [%v, %v] and [%v, %v]", lo1, hi1, lo2, hi2)
+	}
+	if !(lo1 < 0.0375 && 0.0375 < hi1) { // the sample mean
+		t.Fatalf("interval [%v, %v] misses the mean", lo1, hi1)
+	}
+	if lo, hi := BootstrapCI95Rand(nil, 1000, nil); lo != 0 || hi != 0 {
+		t.Fatalf("empty sample gave [%v, %v]", lo, hi)
+	}
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// MatchCheckpoint is the runner state saved after a match's last completed
+// pair. State is opaque to the store (the duel runner's JSON).
+type MatchCheckpoint struct {
+	MatchID   int64
+	Pairs     int
+	State     []byte
+	UpdatedAt time.Time
+	EndedAt   *time.Time // the match's ended_at; non-nil → nothing to resume
+}
+
+// SaveMatchCheckpoint upserts the checkpoint for matchID.
+func (db *DB) SaveMatchCheckpoint(ctx context.Context, matchID int64, pairs int, state []byte) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO match_checkpoints(match_id, pairs, state)
+		VALUES ($1,$2,$3)
+		ON CONFLICT (match_id) DO UPDATE
+		   SET pairs = EXCLUDED.pairs, state = EXCLUDED.state, updated_at = now()
+	`, matchID, pairs, string(state))
+	return err
+}
+
+// LoadMatchCheckpoint returns the latest checkpoint of a match.
+func (db *DB) LoadMatchCheckpoint(ctx context.Context, matchID int64) (MatchCheckpoint, error) {
+	cp := MatchCheckpoint{MatchID: matchID}
+	var state string
+	err := db.QueryRow(ctx, `
+		SELECT c.pairs, c.state::text, c.updated_at, m.ended_at
+		  FROM match_checkpoints c
+		  JOIN matches m ON m.id = c.match_id
+		 WHERE c.match_id = $1
+	`, matchID).Scan(&cp.Pairs, &state, &cp.UpdatedAt, &cp.EndedAt)
+	cp.State = []byte(state)
+	return cp, err
+}
+
+// RewindMatch drops the action logs, pair results and rating points written
+// after pair `pairs`, i.e. by a pair that was cut off before it could be
+// checkpointed, so a resumed match replays it cleanly.
+func (db *DB) RewindMatch(ctx context.Context, matchID int64, pairs int) error {
+	for _, q := range rewindQueries {
+		if _, err := db.Exec(ctx, q, matchID, pairs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewindQueries clear every per-pair table; $1 is the match, $2 the last
+// pair to keep.
+var rewindQueries = []string{
+	`DELETE FROM action_logs WHERE match_id = $1 AND pair_index > $2`,
+	`DELETE FROM pair_results WHERE match_id = $1 AND pair_index > $2`,
+	`DELETE FROM rating_history WHERE match_id = $1 AND stage = 'after_pair' AND pair_index > $2`,
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Every table keyed by pair must be rewound, or a resumed match would keep
+// rows from the pair that was cut off.
+func TestRewindCoversPerPairTables(t *testing.T) {
+	sql, err := schema.ReadFile("schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := regexp.MustCompile(`(?m)^CREATE TABLE IF NOT EXISTS (\w+) \(`)
+	for _, m := range table.FindAllSubmatchIndex(sql, -1) {
+		name := string(sql[m[2]:m[3]])
+		body := sql[m[1]:]
+		if end := strings.Index(string(body), "\n);"); end >= 0 {
+			body = body[:end]
+		}
+		if !regexp.MustCompile(`(?m)^\s*pair_index\s`).Match(body) {
+			continue
+		}
+		found := false
+		for _, q := range rewindQueries {
+			if strings.Contains(q, "FROM "+name+" ") {
+				found = true
+				if !strings.Contains(q, "match_id = $1") || !strings.Contains(q, "pair_index > $2") {
+					t.Errorf("%s: rewind %q does not filter by match and pair", name, q)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("table %s has pair_index but RewindMatch leaves it alone", name)
+		}
+	}
+	if len(rewindQueries) < 3 {
+		t.Errorf("only %d rewind queries", len(rewindQueries))
+	}
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PeriodMatch is a completed match not yet rated by a Glicko-2 period.
+type PeriodMatch struct {
+	ID         int64
+	EndedAt    time.Time
+	BotA, BotB int64
+	BB         int
+	StartStack int
+	ChipsA     []int // per mirrored pair, in order
+}
+
+// GlickoState is a bot's career Glicko-2 state in bot_ratings.
+type GlickoState struct {
+	BotID             int64
+	Rating, RD, Sigma float64
+}
+
+// PeriodClose is one rating period to record.
+type PeriodClose struct {
+	ClosedAt time.Time
+	Kind     string
+	Matches  int
+}
+
+// PeriodPlan decides which periods to close given the last close time, the
+// matches ended since (oldest first) and every bot's state. It returns the
+// periods and the new states; nil states leave bot_ratings untouched.
+type PeriodPlan func(lastClose time.Time, pending []PeriodMatch, bots []GlickoState) ([]PeriodClose, []GlickoState)
+
+// CloseRatingPeriods runs plan under a transaction-scoped advisory lock, so
+// concurrent matches finishing at once close each period exactly once.
+func (db *DB) CloseRatingPeriods(ctx context.Context, plan PeriodPlan) error {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) // safe if already committed
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('rating_periods'))`); err != nil {
+		return err
+	}
+	var last time.Time
+	if err := tx.QueryRow(ctx, `
+		SELECT COALESCE(MAX(closed_at), 'epoch'::timestamptz) FROM rating_periods
+	`).Scan(&last); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT m.id, m.ended_at, a.bot_id, b.bot_id, m.bb, m.start_stack,
+		       COALESCE(array_agg(r.chips_a ORDER BY r.pair_index) FILTER (WHERE r.pair_index IS NOT NULL), '{}')
+		  FROM matches m
+		  JOIN match_participants a ON a.match_id = m.id AND a.label = 'A'
+		  JOIN match_participants b ON b.match_id = m.id AND b.label = 'B'
+		  LEFT JOIN pair_results r ON r.match_id = m.id
+		 WHERE m.ended_at > $1
+		 GROUP BY m.id, a.bot_id, b.bot_id
+		 ORDER BY m.ended_at, m.id
+	`, last)
+	if err != nil {
+		return err
+	}
+	var pending []PeriodMatch
+	for rows.Next() {
+		var m PeriodMatch
+		var chips []int32
+		if err := rows.Scan(&m.ID, &m.EndedAt, &m.BotA, &m.BotB, &m.BB, &m.StartStack, &chips); err != nil {
+			rows.Close()
+			return err
+		}
+		for _, c := range chips {
+			m.ChipsA = append(m.ChipsA, int(c))
+		}
+		pending = append(pending, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = tx.Query(ctx, `SELECT bot_id, g_rating, g_rd, g_sigma FROM bot_ratings ORDER BY bot_id`)
+	if err != nil {
+		return err
+	}
+	var bots []GlickoState
+	for rows.Next() {
+		var g GlickoState
+		if err := rows.Scan(&g.BotID, &g.Rating, &g.RD, &g.Sigma); err != nil {
+			rows.Close()
+			return err
+		}
+		bots = append(bots, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	closes, states := plan(last, pending, bots)
+	if len(closes) == 0 {
+		return nil
+	}
+	for _, c := range closes {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO rating_periods(closed_at, kind, matches) VALUES ($1,$2,$3)
+		`, c.ClosedAt, c.Kind, c.Matches); err != nil {
+			return err
+		}
+	}
+	for _, g := range states {
+		if _, err := tx.Exec(ctx, `
+			UPDATE bot_ratings SET g_rating = $2, g_rd = $3, g_sigma = $4, updated_at = now()
+			 WHERE bot_id = $1
+		`, g.BotID, g.Rating, g.RD, g.Sigma); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// UpdateBotCareer persists Elo and career counters after a match, leaving
+// the Glicko-2 state to the rating periods.
+func (db *DB) UpdateBotCareer(ctx context.Context, botID int64, elo float64, matchesInc, handsInc, judgeGoodInc, judgeTotalInc int) error {
+	_, err := db.Exec(ctx, `
+		UPDATE bot_ratings
+		   SET elo = $2,
+		       matches = matches + $3,
+		       hands = hands + $4,
+		       judge_good = judge_good + $5,
+		       judge_total = judge_total + $6,
+		       updated_at = now()
+		 WHERE bot_id = $1
+	`, botID, elo, matchesInc, handsInc, judgeGoodInc, judgeTotalInc)
+	return err
+}
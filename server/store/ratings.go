@@ -0,0 +1,321 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PairResult is one mirrored pair's raw outcome from A's point of view.
+type PairResult struct {
+	Index                  int
+	Seed                   int64
+	ChipsA, PotSum         int
+	WinsA, WinsB           float64
+	FoldScoreA, FoldScoreB float64
+	AccA, AccB             float64
+	AivatA                 float64 // A's AIVAT estimate of ChipsA
+}
+
+// InsertPairResult records a pair's outcome; a replayed pair (resume)
+// overwrites the row.
+func (db *DB) InsertPairResult(ctx context.Context, matchID int64, p PairResult) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO pair_results(
+			match_id, pair_index, seed, chips_a, pot_sum,
+			wins_a, wins_b, fold_score_a, fold_score_b, acc_a, acc_b, aivat_a
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+		ON CONFLICT (match_id, pair_index) DO UPDATE
+		   SET seed = EXCLUDED.seed, chips_a = EXCLUDED.chips_a, pot_sum = EXCLUDED.pot_sum,
+		       wins_a = EXCLUDED.wins_a, wins_b = EXCLUDED.wins_b,
+		       fold_score_a = EXCLUDED.fold_score_a, fold_score_b = EXCLUDED.fold_score_b,
+		       acc_a = EXCLUDED.acc_a, acc_b = EXCLUDED.acc_b, aivat_a = EXCLUDED.aivat_a, created_at = now()
+	`, matchID, p.Index, p.Seed, p.ChipsA, p.PotSum,
+		p.WinsA, p.WinsB, p.FoldScoreA, p.FoldScoreB, p.AccA, p.AccB, p.AivatA)
+	return err
+}
+
+// ReplayMatch is a completed match with its pairs, as needed to replay
+// rating updates.
+type ReplayMatch struct {
+	ID         int64
+	CreatedAt  time.Time
+	EndedAt    time.Time
+	BotA, BotB int64
+	BB         int
+	StartStack int
+	EloK       float64
+	Pairs      []PairResult
+}
+
+// ReplayMatches loads every completed match in chronological order with its
+// pair results. Matches recorded before pair_results existed come back with
+// no pairs.
+func (db *DB) ReplayMatches(ctx context.Context) ([]ReplayMatch, error) {
+	rows, err := db.Query(ctx, `
+		SELECT m.id, m.created_at, m.ended_at, a.bot_id, b.bot_id, m.bb, m.start_stack, m.elo_k
+		  FROM matches m
+		  JOIN match_participants a ON a.match_id = m.id AND a.label = 'A'
+		  JOIN match_participants b ON b.match_id = m.id AND b.label = 'B'
+		 WHERE m.ended_at IS NOT NULL
+		 ORDER BY m.created_at, m.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	var out []ReplayMatch
+	index := map[int64]int{}
+	for rows.Next() {
+		var m ReplayMatch
+		if err := rows.Scan(&m.ID, &m.CreatedAt, &m.EndedAt, &m.BotA, &m.BotB, &m.BB, &m.StartStack, &m.EloK); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[m.ID] = len(out)
+		out = append(out, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(ctx, `
+		SELECT match_id, pair_index, seed, chips_a, pot_sum,
+		       wins_a, wins_b, fold_score_a, fold_score_b, acc_a, acc_b
+		  FROM pair_results
+		 ORDER BY match_id, pair_index
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var matchID int64
+		var p PairResult
+		if err := rows.Scan(&matchID, &p.Index, &p.Seed, &p.ChipsA, &p.PotSum,
+			&p.WinsA, &p.WinsB, &p.FoldScoreA, &p.FoldScoreB, &p.AccA, &p.AccB); err != nil {
+			return nil, err
+		}
+		i, ok := index[matchID]
+		if !ok {
+			continue // unfinished match
+		}
+		out[i].Pairs = append(out[i].Pairs, p)
+	}
+	return out, rows.Err()
+}
+
+// RatingSnapshot is one offline recomputation of every bot's ratings.
+type RatingSnapshot struct {
+	ID        int64
+	CreatedAt time.Time
+	Algorithm string
+	Params    string // JSON
+	Matches   int
+	Pairs     int
+	AppliedAt *time.Time
+}
+
+// SnapshotRating is one bot's row in a snapshot. The Live* fields carry the
+// bot's current bot_ratings values for comparison (nil without a row).
+type SnapshotRating struct {
+	BotID                int64
+	Name                 string
+	Elo                  float64
+	GRating, GRD, GSigma float64
+	Matches, Pairs       int
+
+	LiveElo, LiveGRating, LiveGRD *float64
+}
+
+// CreateRatingSnapshot stores a snapshot and its rows in one transaction.
+func (db *DB) CreateRatingSnapshot(ctx context.Context, algorithm, params string, matches, pairs int, ratings []SnapshotRating) (int64, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx) // safe if already committed
+
+	var id int64
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO rating_snapshots(algorithm, params, matches, pairs)
+		VALUES ($1,$2,$3,$4)
+		RETURNING id
+	`, algorithm, params, matches, pairs).Scan(&id); err != nil {
+		return 0, err
+	}
+	for _, r := range ratings {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO rating_snapshot_bots(snapshot_id, bot_id, elo, g_rating, g_rd, g_sigma, matches, pairs)
+			VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		`, id, r.BotID, r.Elo, r.GRating, r.GRD, r.GSigma, r.Matches, r.Pairs); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit(ctx)
+}
+
+// ListRatingSnapshots returns all snapshots, newest first.
+func (db *DB) ListRatingSnapshots(ctx context.Context) ([]RatingSnapshot, error) {
+	rows, err := db.Query(ctx, `
+		SELECT id, created_at, algorithm, params::text, matches, pairs, applied_at
+		  FROM rating_snapshots
+		 ORDER BY id DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []RatingSnapshot
+	for rows.Next() {
+		var s RatingSnapshot
+		if err := rows.Scan(&s.ID, &s.CreatedAt, &s.Algorithm, &s.Params, &s.Matches, &s.Pairs, &s.AppliedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// GetRatingSnapshot loads a snapshot with its rows (best Elo first) next to
+// the live ratings.
+func (db *DB) GetRatingSnapshot(ctx context.Context, id int64) (RatingSnapshot, []SnapshotRating, error) {
+	var s RatingSnapshot
+	err := db.QueryRow(ctx, `
+		SELECT id, created_at, algorithm, params::text, matches, pairs, applied_at
+		  FROM rating_snapshots WHERE id = $1
+	`, id).Scan(&s.ID, &s.CreatedAt, &s.Algorithm, &s.Params, &s.Matches, &s.Pairs, &s.AppliedAt)
+	if err != nil {
+		return s, nil, err
+	}
+	rows, err := db.Query(ctx, `
+		SELECT r.bot_id, b.name, r.elo, r.g_rating, r.g_rd, r.g_sigma, r.matches, r.pairs,
+		       l.elo, l.g_rating, l.g_rd
+		  FROM rating_snapshot_bots r
+		  JOIN bots b ON b.id = r.bot_id
+		  LEFT JOIN bot_ratings l ON l.bot_id = r.bot_id
+		 WHERE r.snapshot_id = $1
+		 ORDER BY r.elo DESC
+	`, id)
+	if err != nil {
+		return s, nil, err
+	}
+	defer rows.Close()
+	var out []SnapshotRating
+	for rows.Next() {
+		var r SnapshotRating
+		if err := rows.Scan(&r.BotID, &r.Name, &r.Elo, &r.GRating, &r.GRD, &r.GSigma, &r.Matches, &r.Pairs,
+			&r.LiveElo, &r.LiveGRating, &r.LiveGRD); err != nil {
+			return s, nil, err
+		}
+		out = append(out, r)
+	}
+	return s, out, rows.Err()
+}
+
+// ApplyRatingSnapshot overwrites the live Elo and Glicko-2 state in
+// bot_ratings with a snapshot's values. Career counters (matches, hands,
+// judge accuracy) are left alone.
+func (db *DB) ApplyRatingSnapshot(ctx context.Context, id int64) error {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) // safe if already committed
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO bot_ratings(bot_id, elo, g_rating, g_rd, g_sigma)
+		SELECT bot_id, elo, g_rating, g_rd, g_sigma
+		  FROM rating_snapshot_bots
+		 WHERE snapshot_id = $1
+		ON CONFLICT (bot_id) DO UPDATE
+		   SET elo = EXCLUDED.elo, g_rating = EXCLUDED.g_rating,
+		       g_rd = EXCLUDED.g_rd, g_sigma = EXCLUDED.g_sigma,
+		       updated_at = now()
+	`, id); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `UPDATE rating_snapshots SET applied_at = now() WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return tx.Commit(ctx)
+}
+
+// PairTally is the mirrored-pair record between two bots: a pair is won by
+// whoever netted chips over both hands.
+type PairTally struct {
+	BotA, BotB   int64
+	WinsA, WinsB int
+	Ties         int
+}
+
+// PairTallies aggregates pair_results of completed matches by (A bot, B bot).
+func (db *DB) PairTallies(ctx context.Context) ([]PairTally, error) {
+	rows, err := db.Query(ctx, `
+		SELECT a.bot_id, b.bot_id,
+		       COUNT(*) FILTER (WHERE r.chips_a > 0)::int,
+		       COUNT(*) FILTER (WHERE r.chips_a < 0)::int,
+		       COUNT(*) FILTER (WHERE r.chips_a = 0)::int
+		  FROM pair_results r
+		  JOIN matches m ON m.id = r.match_id AND m.ended_at IS NOT NULL
+		  JOIN match_participants a ON a.match_id = r.match_id AND a.label = 'A'
+		  JOIN match_participants b ON b.match_id = r.match_id AND b.label = 'B'
+		 WHERE a.bot_id <> b.bot_id
+		 GROUP BY 1, 2
+		 ORDER BY 1, 2
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PairTally
+	for rows.Next() {
+		var t PairTally
+		if err := rows.Scan(&t.BotA, &t.BotB, &t.WinsA, &t.WinsB, &t.Ties); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// PairMargin is one mirrored pair's chip margin in starting stacks, from
+// the point of view of the lower bot id (Lo) against Hi.
+type PairMargin struct {
+	Lo, Hi int64
+	Margin float64
+}
+
+// PairMargins loads every stored pair between two different bots.
+func (db *DB) PairMargins(ctx context.Context) ([]PairMargin, error) {
+	rows, err := db.Query(ctx, `
+		SELECT LEAST(a.bot_id, b.bot_id), GREATEST(a.bot_id, b.bot_id),
+		       CASE WHEN a.bot_id < b.bot_id THEN r.chips_a ELSE -r.chips_a END::float8
+		         / CASE WHEN m.start_stack > 0 THEN m.start_stack ELSE 100 * m.bb END
+		  FROM pair_results r
+		  JOIN matches m ON m.id = r.match_id
+		  JOIN match_participants a ON a.match_id = r.match_id AND a.label = 'A'
+		  JOIN match_participants b ON b.match_id = r.match_id AND b.label = 'B'
+		 WHERE a.bot_id <> b.bot_id
+		 ORDER BY r.match_id, r.pair_index
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PairMargin
+	for rows.Next() {
+		var p PairMargin
+		if err := rows.Scan(&p.Lo, &p.Hi, &p.Margin); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Schedule item states. "stopped" items ended early on a graceful stop and
+// were persisted as short matches; resume replays only pending/running ones.
+const (
+	ItemPending = "pending"
+	ItemRunning = "running"
+	ItemStopped = "stopped"
+	ItemDone    = "done"
+)
+
+// Schedule is one persisted round-robin plan.
+type Schedule struct {
+	ID             int64
+	CreatedAt      time.Time
+	EndedAt        *time.Time
+	Format         string
+	MatchesPerPair int
+	SeedBase       int64
+	Spec           string // resolved run spec (YAML), including the entrants
+}
+
+// ScheduleItem is one scheduled match between two entrants (indices into
+// the spec's players).
+type ScheduleItem struct {
+	Index    int
+	Round    int
+	A, B     int
+	SeedBase int64
+	Status   string
+	MatchID  *int64
+}
+
+// CreateSchedule stores a plan and all of its items in one transaction.
+func (db *DB) CreateSchedule(ctx context.Context, format string, matchesPerPair int, seedBase int64, spec string, items []ScheduleItem) (int64, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx) // safe if already committed
+
+	var id int64
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO schedules(format, matches_per_pair, seed_base, spec)
+		VALUES ($1,$2,$3,$4)
+		RETURNING id
+	`, format, matchesPerPair, seedBase, spec).Scan(&id); err != nil {
+		return 0, err
+	}
+	for _, it := range items {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO schedule_items(schedule_id, idx, round, entrant_a, entrant_b, seed_base)
+			VALUES ($1,$2,$3,$4,$5,$6)
+		`, id, it.Index, it.Round, it.A, it.B, it.SeedBase); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit(ctx)
+}
+
+// GetSchedule loads a plan and its items in play order.
+func (db *DB) GetSchedule(ctx context.Context, id int64) (Schedule, []ScheduleItem, error) {
+	var s Schedule
+	err := db.QueryRow(ctx, `
+		SELECT id, created_at, ended_at, format, matches_per_pair, seed_base, spec
+		  FROM schedules WHERE id = $1
+	`, id).Scan(&s.ID, &s.CreatedAt, &s.EndedAt, &s.Format, &s.MatchesPerPair, &s.SeedBase, &s.Spec)
+	if err != nil {
+		return s, nil, err
+	}
+	rows, err := db.Query(ctx, `
+		SELECT idx, round, entrant_a, entrant_b, seed_base, status, match_id
+		  FROM schedule_items
+		 WHERE schedule_id = $1
+		 ORDER BY idx
+	`, id)
+	if err != nil {
+		return s, nil, err
+	}
+	defer rows.Close()
+	var items []ScheduleItem
+	for rows.Next() {
+		var it ScheduleItem
+		if err := rows.Scan(&it.Index, &it.Round, &it.A, &it.B, &it.SeedBase, &it.Status, &it.MatchID); err != nil {
+			return s, nil, err
+		}
+		items = append(items, it)
+	}
+	return s, items, rows.Err()
+}
+
+// StartScheduleItem marks an item running before its match is created, so a
+// crash leaves it visibly unfinished.
+func (db *DB) StartScheduleItem(ctx context.Context, scheduleID int64, idx int) error {
+	_, err := db.Exec(ctx, `
+		UPDATE schedule_items SET status = 'running', started_at = now(), finished_at = NULL
+		 WHERE schedule_id = $1 AND idx = $2
+	`, scheduleID, idx)
+	return err
+}
+
+// FinishScheduleItem records the match that settled an item (status done or
+// stopped). A zero matchID stores NULL.
+func (db *DB) FinishScheduleItem(ctx context.Context, scheduleID int64, idx int, status string, matchID int64) error {
+	var mid any
+	if matchID != 0 {
+		mid = matchID
+	}
+	_, err := db.Exec(ctx, `
+		UPDATE schedule_items SET status = $3, match_id = $4, finished_at = now()
+		 WHERE schedule_id = $1 AND idx = $2
+	`, scheduleID, idx, status, mid)
+	return err
+}
+
+func (db *DB) CompleteSchedule(ctx context.Context, scheduleID int64) error {
+	_, err := db.Exec(ctx, `UPDATE schedules SET ended_at = now() WHERE id = $1`, scheduleID)
+	return err
+}
+
+// PairMatchCounts counts completed matches between each pair of the given
+// bots, keyed by (lower id, higher id).
+func (db *DB) PairMatchCounts(ctx context.Context, botIDs []int64) (map[[2]int64]int, error) {
+	rows, err := db.Query(ctx, `
+		SELECT LEAST(a.bot_id, b.bot_id), GREATEST(a.bot_id, b.bot_id), COUNT(*)::int
+		  FROM match_participants a
+		  JOIN match_participants b ON b.match_id = a.match_id AND b.label = 'B'
+		  JOIN matches m ON m.id = a.match_id
+		 WHERE a.label = 'A'
+		   AND m.ended_at IS NOT NULL
+		   AND a.bot_id = ANY($1) AND b.bot_id = ANY($1)
+		 GROUP BY 1, 2
+	`, botIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[[2]int64]int{}
+	for rows.Next() {
+		var lo, hi int64
+		var n int
+		if err := rows.Scan(&lo, &hi, &n); err != nil {
+			return nil, err
+		}
+		out[[2]int64{lo, hi}] = n
+	}
+	return out, rows.Err()
+}
+
+// SetScheduleItemMatch links a running item to its match as soon as the
+// match row exists, so a resumed schedule can continue that match from its
+// checkpoint instead of replaying it.
+func (db *DB) SetScheduleItemMatch(ctx context.Context, scheduleID int64, idx int, matchID int64) error {
+	_, err := db.Exec(ctx, `
+		UPDATE schedule_items SET match_id = $3 WHERE schedule_id = $1 AND idx = $2
+	`, scheduleID, idx, matchID)
+	return err
+}
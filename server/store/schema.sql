@@ -3,12 +3,29 @@
 -- =========================
 CREATE TABLE IF NOT EXISTS bots (
   id                BIGSERIAL PRIMARY KEY,
-  name              TEXT NOT NULL UNIQUE,   -- 'gpt-5-mini'
+  name              TEXT NOT NULL,          -- display label, e.g. 'gpt-5-mini (high)'
   company           TEXT NOT NULL,          -- 'OpenAI' (or your vendor tag)
   reasoning_effort  TEXT,                   -- NULL for non-thinking models
   created_at        TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
+-- Composite identity: one row per (model, provider, effort, temperature,
+-- prompt hash) variant, grouped by model family. Pre-existing rows keep their
+-- name as variant key so their ratings stay attached to legacy runs.
+ALTER TABLE bots
+  ADD COLUMN IF NOT EXISTS variant_key TEXT,
+  ADD COLUMN IF NOT EXISTS family      TEXT,
+  ADD COLUMN IF NOT EXISTS provider    TEXT,
+  ADD COLUMN IF NOT EXISTS temperature REAL,
+  ADD COLUMN IF NOT EXISTS prompt_hash TEXT;
+UPDATE bots SET variant_key = name WHERE variant_key IS NULL;
+UPDATE bots SET family = name WHERE family IS NULL;
+ALTER TABLE bots ALTER COLUMN variant_key SET NOT NULL;
+ALTER TABLE bots ALTER COLUMN family SET NOT NULL;
+ALTER TABLE bots DROP CONSTRAINT IF EXISTS bots_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS ux_bots_variant_key ON bots (variant_key);
+CREATE INDEX IF NOT EXISTS ix_bots_family ON bots (family);
+
 -- Persisted ratings so each bot continues from its last known skill
 CREATE TABLE IF NOT EXISTS bot_ratings (
   bot_id     BIGINT PRIMARY KEY REFERENCES bots(id) ON DELETE CASCADE,
@@ -47,6 +64,133 @@ CREATE TABLE IF NOT EXISTS matches (
   elo_weight_by_pot BOOL NOT NULL
 );
 
+-- Verbatim run spec (--config file) that produced the match; NULL for env runs.
+ALTER TABLE matches
+  ADD COLUMN IF NOT EXISTS run_config TEXT;
+
+-- Adaptive duels: pairs actually played, the sequential rule (sprt|ci) and
+-- its verdict (a_better|b_better|no_difference). NULL rule/verdict = fixed.
+ALTER TABLE matches
+  ADD COLUMN IF NOT EXISTS pairs_played INT,
+  ADD COLUMN IF NOT EXISTS stop_rule    TEXT,
+  ADD COLUMN IF NOT EXISTS verdict      TEXT;
+
+-- Game variant the match was dealt under (holdem | plo).
+ALTER TABLE matches
+  ADD COLUMN IF NOT EXISTS game_variant TEXT NOT NULL DEFAULT 'holdem';
+
+-- Betting structure (no_limit | pot_limit | fixed_limit); rows from before
+-- the column are hold'em no limit or PLO pot limit.
+ALTER TABLE matches
+  ADD COLUMN IF NOT EXISTS betting TEXT;
+
+-- Commit-reveal shuffles: SHA-256 of the deck key, published at the start,
+-- and the key itself, written when the match completes. NULL for matches
+-- dealt before verifiable shuffles.
+ALTER TABLE matches
+  ADD COLUMN IF NOT EXISTS deck_commitment TEXT,
+  ADD COLUMN IF NOT EXISTS deck_key        TEXT;
+
+-- Resumable duels: runner state after the last completed mirrored pair
+-- (seed stream position, banks, ratings, stats, tallies) as JSON.
+CREATE TABLE IF NOT EXISTS match_checkpoints (
+  match_id   BIGINT PRIMARY KEY REFERENCES matches(id) ON DELETE CASCADE,
+  pairs      INT NOT NULL,                   -- completed pairs
+  state      JSONB NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Raw per-pair outcomes: exactly what the pair-level rating updates consume,
+-- so `ratings recompute` can replay history under changed rating rules.
+CREATE TABLE IF NOT EXISTS pair_results (
+  match_id     BIGINT NOT NULL REFERENCES matches(id) ON DELETE CASCADE,
+  pair_index   INT NOT NULL,                   -- 1..N per duel
+  seed         BIGINT NOT NULL,                -- deck seed shared by both hands
+  chips_a      INT NOT NULL,                   -- A's net over both hands
+  pot_sum      INT NOT NULL,
+  wins_a       REAL NOT NULL,                  -- hand wins (ties count 0.5)
+  wins_b       REAL NOT NULL,
+  fold_score_a REAL NOT NULL,                  -- fold quality (+1 good fold, -1 folded the winner)
+  fold_score_b REAL NOT NULL,
+  acc_a        REAL NOT NULL,                  -- judge accuracy Elo blended in
+  acc_b        REAL NOT NULL,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (match_id, pair_index)
+);
+-- A's variance-reduced (AIVAT) chips over the pair; NULL for older rows.
+ALTER TABLE pair_results
+  ADD COLUMN IF NOT EXISTS aivat_a REAL;
+
+-- =========================
+-- GLICKO-2 RATING PERIODS
+-- =========================
+-- Career Glicko-2 state in bot_ratings is as of the latest closed period;
+-- matches that ended after it are rated when the next period closes.
+CREATE TABLE IF NOT EXISTS rating_periods (
+  id         BIGSERIAL PRIMARY KEY,
+  closed_at  TIMESTAMPTZ NOT NULL,             -- covers matches ended up to here
+  kind       TEXT NOT NULL,                    -- matches | day | pair | migrate
+  matches    INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- Migration: ratings written before periods existed (per-pair updates) are
+-- the starting state, so earlier matches are not rated a second time.
+INSERT INTO rating_periods(closed_at, kind)
+SELECT now(), 'migrate'
+ WHERE NOT EXISTS (SELECT 1 FROM rating_periods);
+
+-- =========================
+-- RATING SNAPSHOTS (offline recomputations; applied to bot_ratings on confirm)
+-- =========================
+CREATE TABLE IF NOT EXISTS rating_snapshots (
+  id          BIGSERIAL PRIMARY KEY,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  algorithm   TEXT NOT NULL,
+  params      JSONB NOT NULL DEFAULT '{}',
+  matches     INT NOT NULL,                    -- matches replayed
+  pairs       INT NOT NULL,                    -- mirrored pairs replayed
+  applied_at  TIMESTAMPTZ                      -- copied into bot_ratings
+);
+
+CREATE TABLE IF NOT EXISTS rating_snapshot_bots (
+  snapshot_id BIGINT NOT NULL REFERENCES rating_snapshots(id) ON DELETE CASCADE,
+  bot_id      BIGINT NOT NULL REFERENCES bots(id) ON DELETE CASCADE,
+  elo         REAL NOT NULL,
+  g_rating    REAL NOT NULL,
+  g_rd        REAL NOT NULL,
+  g_sigma     REAL NOT NULL,
+  matches     INT NOT NULL,
+  pairs       INT NOT NULL,
+  PRIMARY KEY (snapshot_id, bot_id)
+);
+
+-- =========================
+-- SCHEDULES (--duel-matrix round-robin plans; resumable after a crash)
+-- =========================
+CREATE TABLE IF NOT EXISTS schedules (
+  id               BIGSERIAL PRIMARY KEY,
+  created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+  ended_at         TIMESTAMPTZ,
+  format           TEXT NOT NULL,            -- round_robin | double_round_robin
+  matches_per_pair INT NOT NULL,
+  seed_base        BIGINT NOT NULL,
+  spec             TEXT NOT NULL             -- resolved run spec (YAML) incl. entrants
+);
+
+CREATE TABLE IF NOT EXISTS schedule_items (
+  schedule_id BIGINT NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+  idx         INT NOT NULL,                  -- play order
+  round       INT NOT NULL,
+  entrant_a   INT NOT NULL,                  -- index into the spec's players
+  entrant_b   INT NOT NULL,
+  seed_base   BIGINT NOT NULL,
+  status      TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending','running','stopped','done')),
+  match_id    BIGINT REFERENCES matches(id) ON DELETE SET NULL,
+  started_at  TIMESTAMPTZ,
+  finished_at TIMESTAMPTZ,
+  PRIMARY KEY (schedule_id, idx)
+);
+
 -- =========================
 -- PARTICIPANTS (final snapshot per bot in a match)
 -- =========================
@@ -165,7 +309,8 @@ CREATE VIEW v_bot_career AS
 SELECT
   b.id, b.name, b.company, r.elo,
   r.g_rating, r.g_rd, r.g_sigma, r.matches, r.hands,
-  r.judge_good, r.judge_total, r.updated_at
+  r.judge_good, r.judge_total, r.updated_at,
+  b.variant_key, b.family, b.provider, b.reasoning_effort
 FROM bots b
 LEFT JOIN bot_ratings r ON r.bot_id = b.id
 ORDER BY b.name;
@@ -206,6 +351,17 @@ ALTER TABLE action_logs
   ADD COLUMN IF NOT EXISTS sb_hole TEXT[] NOT NULL DEFAULT '{}';
 ALTER TABLE action_logs
   ADD COLUMN IF NOT EXISTS bb_hole TEXT[] NOT NULL DEFAULT '{}';
+-- Which parse path produced the decision (tool|schema|policy|json|yaml|nl|fallback|forced).
+-- NULL for rows logged before tracking existed.
+ALTER TABLE action_logs
+  ADD COLUMN IF NOT EXISTS parse_source TEXT;
+-- Model-provided mixed strategy the action was sampled from (MIXED_POLICY=1).
+ALTER TABLE action_logs
+  ADD COLUMN IF NOT EXISTS policy JSONB;
+-- Short model rationale and provider reasoning summary (CAPTURE_RATIONALE=1).
+ALTER TABLE action_logs
+  ADD COLUMN IF NOT EXISTS comment TEXT,
+  ADD COLUMN IF NOT EXISTS reasoning TEXT;
 
 -- =========================
 -- SOLVER EVALUATION (per action, optional)
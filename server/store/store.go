@@ -4,6 +4,7 @@ import (
 	"context"
 	"embed"
 	"errors"
+	"strconv"
 	"strings"
 
 	"github.com/jackc/pgx/v5"
@@ -39,24 +40,88 @@ func Migrate(ctx context.Context, db *DB) error {
    Minimal write helpers
 ------------------------------*/
 
-// Upsert a bot and return its id.
-func (db *DB) UpsertBot(ctx context.Context, name, company string, reasoningEffort *string) (int64, error) {
-	var id int64
-	var re any
-	if reasoningEffort != nil {
-		v := strings.TrimSpace(*reasoningEffort)
-		if v != "" {
-			re = v
+// BotVariant identifies one rated configuration of a model. Two runs share a
+// rating row only when every field matches; Family groups variants of the
+// same underlying model for the family leaderboard view.
+type BotVariant struct {
+	Model           string   // identifier as sent to the provider
+	Family          string   // provider-neutral model name, e.g. "gpt-5-mini"
+	Provider        string   // openai | openrouter | anthropic | gemini
+	ReasoningEffort string   // empty for non-thinking / default
+	Temperature     *float64 // nil = provider default
+	PromptHash      string   // hash of the prompt templates in use
+	DefaultPrompt   string   // PromptHash of a default run; not part of the key
+}
+
+// Key is the canonical variant key stored in bots.variant_key.
+func (v BotVariant) Key() string {
+	parts := []string{strings.TrimSpace(v.Model)}
+	add := func(k, val string) {
+		if val = strings.TrimSpace(val); val != "" {
+			parts = append(parts, k+"="+strings.ToLower(val))
 		}
 	}
+	add("provider", v.Provider)
+	add("effort", v.ReasoningEffort)
+	if v.Temperature != nil {
+		add("temp", strconv.FormatFloat(*v.Temperature, 'g', -1, 64))
+	}
+	add("prompt", v.PromptHash)
+	return strings.Join(parts, "|")
+}
+
+// Label is the display name stored in bots.name: the model plus whichever
+// settings distinguish it from a default run. A prompt other than the
+// default one is tagged with the first six characters of its hash.
+func (v BotVariant) Label() string {
+	var tags []string
+	if p := strings.TrimSpace(v.Provider); p != "" && p != "openai" {
+		tags = append(tags, p)
+	}
+	if e := strings.TrimSpace(v.ReasoningEffort); e != "" {
+		tags = append(tags, e)
+	}
+	if v.Temperature != nil {
+		tags = append(tags, "t="+strconv.FormatFloat(*v.Temperature, 'g', -1, 64))
+	}
+	if h := strings.TrimSpace(v.PromptHash); h != "" && h != strings.TrimSpace(v.DefaultPrompt) {
+		tags = append(tags, "prompt "+h[:min(6, len(h))])
+	}
+	if len(tags) == 0 {
+		return strings.TrimSpace(v.Model)
+	}
+	return strings.TrimSpace(v.Model) + " (" + strings.Join(tags, ", ") + ")"
+}
+
+// UpsertBot registers a bot variant (keyed by v.Key()) and returns its id.
+func (db *DB) UpsertBot(ctx context.Context, v BotVariant, company string) (int64, error) {
+	var id int64
+	var re, temp, prompt, provider any
+	if e := strings.TrimSpace(v.ReasoningEffort); e != "" {
+		re = e
+	}
+	if v.Temperature != nil {
+		temp = *v.Temperature
+	}
+	if h := strings.TrimSpace(v.PromptHash); h != "" {
+		prompt = h
+	}
+	if p := strings.TrimSpace(v.Provider); p != "" {
+		provider = p
+	}
+	family := strings.TrimSpace(v.Family)
+	if family == "" {
+		family = strings.TrimSpace(v.Model)
+	}
 	err := db.QueryRow(ctx, `
-        INSERT INTO bots(name, company, reasoning_effort)
-        VALUES ($1,$2,$3)
-        ON CONFLICT (name) DO UPDATE
-          SET company = EXCLUDED.company,
-              reasoning_effort = EXCLUDED.reasoning_effort
+        INSERT INTO bots(name, company, reasoning_effort, variant_key, family, provider, temperature, prompt_hash)
+        VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+        ON CONFLICT (variant_key) DO UPDATE
+          SET name = EXCLUDED.name,
+              company = EXCLUDED.company,
+              family = EXCLUDED.family
         RETURNING id
-    `, name, company, re).Scan(&id)
+    `, v.Label(), company, re, v.Key(), family, provider, temp, prompt).Scan(&id)
 	return id, err
 }
 
@@ -277,20 +342,28 @@ func uniquePositiveInt64(ids []int64) []int64 {
 // Create a match row and return the id.
 func (db *DB) CreateMatch(
 	ctx context.Context,
+	gameVariant string, // holdem | plo
+	betting string, // no_limit | pot_limit | fixed_limit
+	deckCommitment string, // SHA-256 of the deck key, hex
 	sb, bb, startStack, duelSeeds int,
 	deckSeedBase int64,
 	eloStart, eloK float64,
 	eloPerHand, eloWeightByPot bool,
+	runConfig string, // verbatim --config file; empty for env-driven runs
 ) (int64, error) {
 	var id int64
+	var rc any
+	if strings.TrimSpace(runConfig) != "" {
+		rc = runConfig
+	}
 	err := db.QueryRow(ctx, `
 		INSERT INTO matches(
 			sb, bb, start_stack, duel_seeds, deck_seed_base,
-			elo_start, elo_k, elo_per_hand, elo_weight_by_pot
+			elo_start, elo_k, elo_per_hand, elo_weight_by_pot, run_config, game_variant, betting, deck_commitment
 		)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
 		RETURNING id
-	`, sb, bb, startStack, duelSeeds, deckSeedBase, eloStart, eloK, eloPerHand, eloWeightByPot).Scan(&id)
+	`, sb, bb, startStack, duelSeeds, deckSeedBase, eloStart, eloK, eloPerHand, eloWeightByPot, rc, gameVariant, betting, deckCommitment).Scan(&id)
 	return id, err
 }
 
@@ -399,8 +472,30 @@ func (db *DB) InsertParticipantsAndTallies(
 	return tx.Commit(ctx)
 }
 
-func (db *DB) CompleteMatch(ctx context.Context, matchID int64) error {
-	_, err := db.Exec(ctx, `UPDATE matches SET ended_at = now() WHERE id = $1`, matchID)
+// SetMatchOutcome records how many pairs were played and, for adaptive
+// duels, the stopping rule and its verdict (empty strings store NULL).
+func (db *DB) SetMatchOutcome(ctx context.Context, matchID int64, pairsPlayed int, stopRule, verdict string) error {
+	nullable := func(s string) any {
+		if s == "" {
+			return nil
+		}
+		return s
+	}
+	_, err := db.Exec(ctx, `
+		UPDATE matches SET pairs_played = $2, stop_rule = $3, verdict = $4 WHERE id = $1
+	`, matchID, pairsPlayed, nullable(stopRule), nullable(verdict))
+	return err
+}
+
+// CompleteMatch marks the match ended and reveals its deck key, so the
+// shuffles can be checked against the commitment from CreateMatch. Call it
+// only once every pair has been dealt: the key predicts any deal to come.
+func (db *DB) CompleteMatch(ctx context.Context, matchID int64, deckKey string) error {
+	var key any
+	if deckKey != "" {
+		key = deckKey
+	}
+	_, err := db.Exec(ctx, `UPDATE matches SET ended_at = now(), deck_key = $2 WHERE id = $1`, matchID, key)
 	return err
 }
 
@@ -419,35 +514,123 @@ func (db *DB) InsertActionLog(
 	board []string,
 	sbHole []string,
 	bbHole []string,
+	parseSource string, // tool|schema|policy|json|yaml|nl|fallback|forced
+	policy map[string]float64, // sampled mixed strategy, nil otherwise
+	comment, reasoning string, // CAPTURE_RATIONALE=1 only
 ) error {
-	var amt any
+	var amt, src, pol, cmt, rsn any
 	if amount != nil {
 		amt = *amount
 	}
+	if v := strings.TrimSpace(comment); v != "" {
+		cmt = v
+	}
+	if v := strings.TrimSpace(reasoning); v != "" {
+		rsn = v
+	}
+	if len(policy) > 0 {
+		pol = policy
+	}
+	if v := strings.TrimSpace(parseSource); v != "" {
+		src = v
+	}
 	_, err := db.Exec(ctx, `
         INSERT INTO action_logs(
             match_id, pair_index, hand_id, street,
             actor_label, action, amount,
             pot, cur_bet, to_call, min_raise_to, max_raise_to,
             sb_stack, bb_stack, sb_committed, bb_committed,
-            board, sb_hole, bb_hole
+            board, sb_hole, bb_hole, parse_source, policy,
+            comment, reasoning
         ) VALUES (
             $1,$2,$3,$4,
             $5,$6,$7,
             $8,$9,$10,$11,$12,
             $13,$14,$15,$16,
-            $17,$18,$19
+            $17,$18,$19,$20,$21,
+            $22,$23
         )
     `,
 		matchID, pairIndex, handID, street,
 		actorLabel, action, amt,
 		pot, curBet, toCall, minTo, maxTo,
 		sbStack, bbStack, sbCommitted, bbCommitted,
-		board, sbHole, bbHole,
+		board, sbHole, bbHole, src, pol,
+		cmt, rsn,
 	)
 	return err
 }
 
+// ParseStats counts decisions per parse path for one bot.
+type ParseStats struct {
+	Sources map[string]int
+	Total   int
+}
+
+// Compliant counts decisions the model produced in the requested format
+// (tool call, structured output, or a structured mixed-strategy policy)
+// without any harness help.
+func (ps ParseStats) Compliant() int {
+	return ps.Sources["tool"] + ps.Sources["schema"] + ps.Sources["policy"]
+}
+
+// Assisted counts decisions where the harness picked or changed the action.
+func (ps ParseStats) Assisted() int { return ps.Sources["fallback"] + ps.Sources["forced"] }
+
+func (ps ParseStats) Rate(n int) float64 {
+	if ps.Total <= 0 {
+		return 0
+	}
+	return float64(n) / float64(ps.Total)
+}
+
+// AllParseStats aggregates action_logs.parse_source per bot. Rows logged
+// before the column existed are skipped.
+func (db *DB) AllParseStats(ctx context.Context) (map[int64]ParseStats, error) {
+	return db.parseStats(ctx, "")
+}
+
+func (db *DB) BotParseStats(ctx context.Context, botID int64) (ParseStats, error) {
+	m, err := db.parseStats(ctx, " AND p.bot_id = $1", botID)
+	if err != nil {
+		return ParseStats{}, err
+	}
+	if ps, ok := m[botID]; ok {
+		return ps, nil
+	}
+	return ParseStats{Sources: map[string]int{}}, nil
+}
+
+func (db *DB) parseStats(ctx context.Context, where string, args ...any) (map[int64]ParseStats, error) {
+	rows, err := db.Query(ctx, `
+                SELECT p.bot_id, a.parse_source, COUNT(*)::int
+                  FROM action_logs a
+                  JOIN match_participants p ON p.match_id = a.match_id AND p.label = a.actor_label
+                 WHERE a.parse_source IS NOT NULL`+where+`
+                 GROUP BY p.bot_id, a.parse_source`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[int64]ParseStats)
+	for rows.Next() {
+		var botID int64
+		var src string
+		var n int
+		if err := rows.Scan(&botID, &src, &n); err != nil {
+			return nil, err
+		}
+		ps, ok := out[botID]
+		if !ok {
+			ps = ParseStats{Sources: map[string]int{}}
+		}
+		ps.Sources[src] += n
+		ps.Total += n
+		out[botID] = ps
+	}
+	return out, rows.Err()
+}
+
 // InsertActionEval records a solver evaluation for a specific action log id.
 func (db *DB) InsertActionEval(
 	ctx context.Context,
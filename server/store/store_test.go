@@ -0,0 +1,39 @@
+package store
+
+import "testing"
+
+func TestBotVariantLabel(t *testing.T) {
+	temp := 0.7
+	for _, tc := range []struct {
+		v    BotVariant
+		want string
+	}{
+		{BotVariant{Model: "gpt-5-mini", Provider: "openai", PromptHash: "abc123def456", DefaultPrompt: "abc123def456"}, "gpt-5-mini"},
+		{BotVariant{Model: "gpt-5-mini", Provider: "openrouter", ReasoningEffort: "high", Temperature: &temp}, "gpt-5-mini (openrouter, high, t=0.7)"},
+		{BotVariant{Model: "gpt-5-mini", PromptHash: "9f8e7d6c5b4a", DefaultPrompt: "abc123def456"}, "gpt-5-mini (prompt 9f8e7d)"},
+		{BotVariant{Model: "gpt-5-mini", ReasoningEffort: "low", PromptHash: "9f8e7d6c5b4a", DefaultPrompt: "abc123def456"}, "gpt-5-mini (low, prompt 9f8e7d)"},
+	} {
+		if got := tc.v.Label(); got != tc.want {
+			t.Errorf("Label() = %q, want %q", got, tc.want)
+		}
+	}
+	a := BotVariant{Model: "m", PromptHash: "9f8e7d6c5b4a", DefaultPrompt: "abc123def456"}
+	b := a
+	b.DefaultPrompt = ""
+	if a.Key() != b.Key() {
+		t.Errorf("DefaultPrompt changed the key: %q vs %q", a.Key(), b.Key())
+	}
+}
+
+func TestParseStatsRates(t *testing.T) {
+	ps := ParseStats{Sources: map[string]int{"tool": 3, "schema": 2, "policy": 4, "json": 5, "fallback": 1, "forced": 1}, Total: 16}
+	if got := ps.Compliant(); got != 9 {
+		t.Errorf("Compliant() = %d, want 9 (tool, schema and policy)", got)
+	}
+	if got := ps.Assisted(); got != 2 {
+		t.Errorf("Assisted() = %d, want 2", got)
+	}
+	if got := ps.Rate(ps.Compliant()); got != 9.0/16 {
+		t.Errorf("Rate = %v", got)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"ai-thunderdome/server/engine"
+	"ai-thunderdome/server/store"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// dealt is what one deck gives each seat and the board.
+type dealt struct {
+	SBHole []string `json:"sb_hole"`
+	BBHole []string `json:"bb_hole"`
+	Board  []string `json:"board"`
+}
+
+// dealFrom deals a deck the way engine.NewHand and NextStreet do: SB's hole,
+// BB's hole, then five board cards, all from the top.
+func dealFrom(v engine.Variant, deck []engine.Card) dealt {
+	n := v.HoleCards()
+	strs := func(cs []engine.Card) []string {
+		out := make([]string, len(cs))
+		for i, c := range cs {
+			out[i] = c.String()
+		}
+		return out
+	}
+	return dealt{SBHole: strs(deck[:n]), BBHole: strs(deck[n : 2*n]), Board: strs(deck[2*n : 2*n+5])}
+}
+
+type pairDeal struct {
+	Pair int    `json:"pair"`
+	Deck string `json:"deck"`
+	dealt
+}
+
+// dealMismatch is a logged hand whose cards differ from its recomputed deck.
+type dealMismatch struct {
+	HandID string `json:"hand_id"`
+	Pair   int    `json:"pair"`
+	Want   dealt  `json:"want"`
+	Got    dealt  `json:"got"`
+}
+
+// deckAudit is the result of /api/verify-decks and `verify -match`.
+type deckAudit struct {
+	MatchID      int64          `json:"match_id"`
+	Variant      string         `json:"variant"`
+	Commitment   string         `json:"deck_commitment"`
+	DeckKey      string         `json:"deck_key"`
+	CommitmentOK bool           `json:"commitment_ok"`
+	Hands        int            `json:"hands_checked"`
+	Mismatches   []dealMismatch `json:"mismatches"`
+	OK           bool           `json:"ok"` // commitment holds and every hand matches
+	Pairs        []pairDeal     `json:"pairs"`
+}
+
+// errDeckKeyHidden means the match has not completed, so its key is secret.
+var errDeckKeyHidden = errors.New("the deck key is revealed when the match completes")
+
+// revealedDeckKey checks that a match's deck key may be used: it must have
+// a commitment and have completed. An open match, including one stopped
+// early and waiting for --resume, is unverifiable even if a key is stored,
+// since its remaining pairs are still to be dealt from that key.
+func revealedDeckKey(matchID int64, commitment, key *string, ended bool) error {
+	switch {
+	case commitment == nil:
+		return fmt.Errorf("match %d predates verifiable shuffles", matchID)
+	case !ended:
+		return fmt.Errorf("match %d: %w", matchID, errDeckKeyHidden)
+	case key == nil:
+		return fmt.Errorf("match %d completed without revealing its deck key", matchID)
+	}
+	return nil
+}
+
+// recomputeDecks deals pairs 1..n under key.
+func recomputeDecks(v engine.Variant, key engine.DeckKey, n int) []pairDeal {
+	out := make([]pairDeal, 0, n)
+	for p := 1; p <= n; p++ {
+		deck := v.VerifiableDeck(key, p)
+		parts := make([]string, len(deck))
+		for i, c := range deck {
+			parts[i] = c.String()
+		}
+		out = append(out, pairDeal{Pair: p, Deck: strings.Join(parts, " "), dealt: dealFrom(v, deck)})
+	}
+	return out
+}
+
+// auditMatchDecks checks a completed match's revealed key against its
+// commitment and every logged hand's cards against the recomputed decks.
+func auditMatchDecks(ctx context.Context, db *store.DB, matchID int64) (deckAudit, error) {
+	a := deckAudit{MatchID: matchID}
+	var commitment, key *string
+	var ended bool
+	err := db.QueryRow(ctx, `
+        SELECT game_variant, deck_commitment, deck_key, ended_at IS NOT NULL
+          FROM matches WHERE id = $1
+    `, matchID).Scan(&a.Variant, &commitment, &key, &ended)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return a, fmt.Errorf("match %d: %w", matchID, err)
+	}
+	if err != nil {
+		return a, err
+	}
+	if err := revealedDeckKey(matchID, commitment, key, ended); err != nil {
+		return a, err
+	}
+	a.Commitment, a.DeckKey = *commitment, *key
+	k, err := engine.ParseDeckKey(a.DeckKey)
+	if err != nil {
+		return a, fmt.Errorf("match %d: %w", matchID, err)
+	}
+	v, err := engine.ParseVariant(a.Variant)
+	if err != nil {
+		return a, err
+	}
+	a.CommitmentOK = k.Commitment() == a.Commitment
+
+	// The last row of each hand has the longest board.
+	rows, err := db.Query(ctx, `
+        SELECT DISTINCT ON (hand_id) hand_id, pair_index, sb_hole, bb_hole, board
+          FROM action_logs
+         WHERE match_id = $1
+         ORDER BY hand_id, id DESC
+    `, matchID)
+	if err != nil {
+		return a, err
+	}
+	defer rows.Close()
+	type hand struct {
+		id   string
+		pair int
+		got  dealt
+	}
+	var hands []hand
+	maxPair := 0
+	for rows.Next() {
+		var h hand
+		if err := rows.Scan(&h.id, &h.pair, &h.got.SBHole, &h.got.BBHole, &h.got.Board); err != nil {
+			return a, err
+		}
+		h.got.Board = slices.DeleteFunc(h.got.Board, func(s string) bool { return s == "" })
+		hands = append(hands, h)
+		maxPair = max(maxPair, h.pair)
+	}
+	if err := rows.Err(); err != nil {
+		return a, err
+	}
+	a.Pairs = recomputeDecks(v, k, maxPair)
+	a.Mismatches = []dealMismatch{}
+	for _, h := range hands {
+		if h.pair < 1 {
+			continue
+		}
+		want := a.Pairs[h.pair-1].dealt
+		if !slices.Equal(h.got.SBHole, want.SBHole) || !slices.Equal(h.got.BBHole, want.BBHole) ||
+			len(h.got.Board) > len(want.Board) || !slices.Equal(h.got.Board, want.Board[:len(h.got.Board)]) {
+			a.Mismatches = append(a.Mismatches, dealMismatch{HandID: h.id, Pair: h.pair, Want: want, Got: h.got})
+		}
+		a.Hands++
+	}
+	a.OK = a.CommitmentOK && len(a.Mismatches) == 0
+	return a, nil
+}
+
+// runVerifyCmd is `ai-thunderdome verify`: recompute a match's decks from
+// its revealed key, either checking a logged match in the database or
+// offline from a key alone.
+func runVerifyCmd(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	matchID := fs.Int64("match", 0, "check a completed match in DATABASE_URL against its action logs")
+	keyHex := fs.String("key", "", "revealed deck key (64 hex characters)")
+	commitment := fs.String("commitment", "", "published deck commitment to check the key against")
+	variant := fs.String("variant", "holdem", "game variant: holdem, plo or short_deck")
+	pairs := fs.Int("pairs", 1, "number of mirrored pairs to deal")
+	asJSON := fs.Bool("json", false, "print JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, `usage: ai-thunderdome verify -match N [-json]
+       ai-thunderdome verify -key HEX [-commitment HEX] [-variant holdem] [-pairs N] [-json]`)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	var a deckAudit
+	switch {
+	case *matchID != 0:
+		dsn := getenv("DATABASE_URL", "")
+		if dsn == "" {
+			log.Fatal("verify: DATABASE_URL is required with -match")
+		}
+		db, err := store.Open(dsn)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close(context.Background())
+		a, err = auditMatchDecks(context.Background(), db, *matchID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "verify:", err)
+			os.Exit(2)
+		}
+	case *keyHex != "":
+		k, err := engine.ParseDeckKey(*keyHex)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "verify:", err)
+			os.Exit(2)
+		}
+		v, err := engine.ParseVariant(*variant)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "verify:", err)
+			os.Exit(2)
+		}
+		a = deckAudit{Variant: string(v), DeckKey: k.String(), Commitment: strings.ToLower(strings.TrimSpace(*commitment))}
+		a.CommitmentOK = a.Commitment == "" || a.Commitment == k.Commitment()
+		a.OK = a.CommitmentOK
+		a.Pairs = recomputeDecks(v, k, max(*pairs, 1))
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(a)
+	} else {
+		printDeckAudit(a)
+	}
+	if !a.OK {
+		os.Exit(1)
+	}
+}
+
+func printDeckAudit(a deckAudit) {
+	if a.MatchID != 0 {
+		fmt.Printf("Match       %d (%s)\n", a.MatchID, a.Variant)
+	}
+	fmt.Printf("Deck key    %s\n", a.DeckKey)
+	switch {
+	case a.Commitment == "":
+		k, _ := engine.ParseDeckKey(a.DeckKey)
+		fmt.Printf("Commitment  %s (computed; pass -commitment to check a published one)\n", k.Commitment())
+	case a.CommitmentOK:
+		fmt.Printf("Commitment  %s ok\n", a.Commitment)
+	default:
+		fmt.Printf("Commitment  %s DOES NOT MATCH the key\n", a.Commitment)
+	}
+	if a.MatchID != 0 {
+		fmt.Printf("Hands       %d checked, %d mismatched\n", a.Hands, len(a.Mismatches))
+		for _, m := range a.Mismatches {
+			fmt.Printf("  %s: logged SB %v BB %v board %v, deck deals SB %v BB %v board %v\n",
+				m.HandID, m.Got.SBHole, m.Got.BBHole, m.Got.Board, m.Want.SBHole, m.Want.BBHole, m.Want.Board)
+		}
+	}
+	fmt.Println()
+	for _, p := range a.Pairs {
+		fmt.Printf("Pair %-4d SB %-12s BB %-12s Board %s\n", p.Pair,
+			strings.Join(p.SBHole, " "), strings.Join(p.BBHole, " "), strings.Join(p.Board, " "))
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRevealedDeckKey(t *testing.T) {
+	commitment, key := "c0ffee", "5eed"
+	for _, tc := range []struct {
+		name       string
+		commitment *string
+		key        *string
+		ended      bool
+		hidden     bool
+		ok         bool
+	}{
+		{name: "completed", commitment: &commitment, key: &key, ended: true, ok: true},
+		{name: "running", commitment: &commitment, hidden: true},
+		{name: "stopped with a stored key", commitment: &commitment, key: &key, hidden: true},
+		{name: "completed without key", commitment: &commitment, ended: true},
+		{name: "legacy", key: &key, ended: true},
+	} {
+		err := revealedDeckKey(1, tc.commitment, tc.key, tc.ended)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v", tc.name, err)
+		}
+		if errors.Is(err, errDeckKeyHidden) != tc.hidden {
+			t.Errorf("%s: hidden = %v, want %v (%v)", tc.name, !tc.hidden, tc.hidden, err)
+		}
+	}
+}